@@ -14,12 +14,30 @@ import (
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// List jobs
+	// (GET /api/jobs)
+	GetApiJobs(w http.ResponseWriter, r *http.Request, params GetApiJobsParams)
 	// Create a job
 	// (POST /api/jobs)
 	PostApiJobs(w http.ResponseWriter, r *http.Request)
 	// Claim the next queued job
 	// (POST /api/jobs/claim)
 	PostApiJobsClaim(w http.ResponseWriter, r *http.Request)
+	// List jobs that exhausted their attempts
+	// (GET /api/jobs/dead-letter)
+	GetApiJobsDeadLetter(w http.ResponseWriter, r *http.Request)
+	// Re-queue a dead-lettered job
+	// (POST /api/jobs/dead-letter/{job_id}/redrive)
+	PostApiJobsDeadLetterJobIdRedrive(w http.ResponseWriter, r *http.Request, jobId string)
+	// Stream compatible queued jobs for a worker
+	// (GET /api/jobs/stream)
+	GetApiJobsStream(w http.ResponseWriter, r *http.Request, params GetApiJobsStreamParams)
+	// Get all jobs of a workflow with their dependencies
+	// (GET /api/jobs/workflows/{workflow_id})
+	GetApiJobsWorkflowsWorkflowId(w http.ResponseWriter, r *http.Request, workflowId string)
+	// Stream status events for every job in a workflow
+	// (GET /api/jobs/workflows/{workflow_id}/events)
+	GetApiJobsWorkflowsWorkflowIdEvents(w http.ResponseWriter, r *http.Request, workflowId string)
 	// Get job status
 	// (GET /api/jobs/{job_id})
 	GetApiJobsJobId(w http.ResponseWriter, r *http.Request, jobId string)
@@ -29,15 +47,27 @@ type ServerInterface interface {
 	// Stream job events
 	// (GET /api/jobs/{job_id}/events)
 	GetApiJobsJobIdEvents(w http.ResponseWriter, r *http.Request, jobId string)
+	// Extend the lease of a claimed job
+	// (POST /api/jobs/{job_id}/heartbeat)
+	PostApiJobsJobIdHeartbeat(w http.ResponseWriter, r *http.Request, jobId string)
+	// Append a partial result to an in-flight job
+	// (POST /api/jobs/{job_id}/partial)
+	PostApiJobsJobIdPartial(w http.ResponseWriter, r *http.Request, jobId string)
 	// Request payload transfer channel
 	// (POST /api/jobs/{job_id}/payload)
 	PostApiJobsJobIdPayload(w http.ResponseWriter, r *http.Request, jobId string)
 	// Request result transfer channel
 	// (POST /api/jobs/{job_id}/result)
 	PostApiJobsJobIdResult(w http.ResponseWriter, r *http.Request, jobId string)
+	// Download a retained job result
+	// (GET /api/jobs/{job_id}/results/{key})
+	GetApiJobsJobIdResultsKey(w http.ResponseWriter, r *http.Request, jobId string, key string)
 	// Update job status
 	// (POST /api/jobs/{job_id}/status)
 	PostApiJobsJobIdStatus(w http.ResponseWriter, r *http.Request, jobId string)
+	// Re-send the job's current status to its webhook
+	// (POST /api/jobs/{job_id}/webhook/test)
+	PostApiJobsJobIdWebhookTest(w http.ResponseWriter, r *http.Request, jobId string)
 	// Get server state
 	// (GET /api/state)
 	GetApiState(w http.ResponseWriter, r *http.Request)
@@ -49,10 +79,16 @@ type ServerInterface interface {
 	PostApiTransfer(w http.ResponseWriter, r *http.Request)
 	// Receive transfer data (reader)
 	// (GET /api/transfer/{channel_id})
-	GetApiTransferChannelId(w http.ResponseWriter, r *http.Request, channelId string)
+	GetApiTransferChannelId(w http.ResponseWriter, r *http.Request, channelId string, params GetApiTransferChannelIdParams)
+	// Report transfer progress
+	// (HEAD /api/transfer/{channel_id})
+	HeadApiTransferChannelId(w http.ResponseWriter, r *http.Request, channelId string)
 	// Send transfer data (writer)
 	// (POST /api/transfer/{channel_id})
-	PostApiTransferChannelId(w http.ResponseWriter, r *http.Request, channelId string)
+	PostApiTransferChannelId(w http.ResponseWriter, r *http.Request, channelId string, params PostApiTransferChannelIdParams)
+	// Attach to a streaming channel over WebSocket
+	// (GET /api/transfer/{channel_id}/ws)
+	GetApiTransferChannelIdWs(w http.ResponseWriter, r *http.Request, channelId string, params GetApiTransferChannelIdWsParams)
 	// Health check
 	// (GET /healthz)
 	GetHealthz(w http.ResponseWriter, r *http.Request)
@@ -62,6 +98,12 @@ type ServerInterface interface {
 
 type Unimplemented struct{}
 
+// List jobs
+// (GET /api/jobs)
+func (_ Unimplemented) GetApiJobs(w http.ResponseWriter, r *http.Request, params GetApiJobsParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Create a job
 // (POST /api/jobs)
 func (_ Unimplemented) PostApiJobs(w http.ResponseWriter, r *http.Request) {
@@ -74,6 +116,36 @@ func (_ Unimplemented) PostApiJobsClaim(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// List jobs that exhausted their attempts
+// (GET /api/jobs/dead-letter)
+func (_ Unimplemented) GetApiJobsDeadLetter(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Re-queue a dead-lettered job
+// (POST /api/jobs/dead-letter/{job_id}/redrive)
+func (_ Unimplemented) PostApiJobsDeadLetterJobIdRedrive(w http.ResponseWriter, r *http.Request, jobId string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Stream compatible queued jobs for a worker
+// (GET /api/jobs/stream)
+func (_ Unimplemented) GetApiJobsStream(w http.ResponseWriter, r *http.Request, params GetApiJobsStreamParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Get all jobs of a workflow with their dependencies
+// (GET /api/jobs/workflows/{workflow_id})
+func (_ Unimplemented) GetApiJobsWorkflowsWorkflowId(w http.ResponseWriter, r *http.Request, workflowId string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Stream status events for every job in a workflow
+// (GET /api/jobs/workflows/{workflow_id}/events)
+func (_ Unimplemented) GetApiJobsWorkflowsWorkflowIdEvents(w http.ResponseWriter, r *http.Request, workflowId string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Get job status
 // (GET /api/jobs/{job_id})
 func (_ Unimplemented) GetApiJobsJobId(w http.ResponseWriter, r *http.Request, jobId string) {
@@ -92,6 +164,18 @@ func (_ Unimplemented) GetApiJobsJobIdEvents(w http.ResponseWriter, r *http.Requ
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// Extend the lease of a claimed job
+// (POST /api/jobs/{job_id}/heartbeat)
+func (_ Unimplemented) PostApiJobsJobIdHeartbeat(w http.ResponseWriter, r *http.Request, jobId string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Append a partial result to an in-flight job
+// (POST /api/jobs/{job_id}/partial)
+func (_ Unimplemented) PostApiJobsJobIdPartial(w http.ResponseWriter, r *http.Request, jobId string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Request payload transfer channel
 // (POST /api/jobs/{job_id}/payload)
 func (_ Unimplemented) PostApiJobsJobIdPayload(w http.ResponseWriter, r *http.Request, jobId string) {
@@ -104,12 +188,24 @@ func (_ Unimplemented) PostApiJobsJobIdResult(w http.ResponseWriter, r *http.Req
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// Download a retained job result
+// (GET /api/jobs/{job_id}/results/{key})
+func (_ Unimplemented) GetApiJobsJobIdResultsKey(w http.ResponseWriter, r *http.Request, jobId string, key string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Update job status
 // (POST /api/jobs/{job_id}/status)
 func (_ Unimplemented) PostApiJobsJobIdStatus(w http.ResponseWriter, r *http.Request, jobId string) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// Re-send the job's current status to its webhook
+// (POST /api/jobs/{job_id}/webhook/test)
+func (_ Unimplemented) PostApiJobsJobIdWebhookTest(w http.ResponseWriter, r *http.Request, jobId string) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
 // Get server state
 // (GET /api/state)
 func (_ Unimplemented) GetApiState(w http.ResponseWriter, r *http.Request) {
@@ -130,13 +226,25 @@ func (_ Unimplemented) PostApiTransfer(w http.ResponseWriter, r *http.Request) {
 
 // Receive transfer data (reader)
 // (GET /api/transfer/{channel_id})
-func (_ Unimplemented) GetApiTransferChannelId(w http.ResponseWriter, r *http.Request, channelId string) {
+func (_ Unimplemented) GetApiTransferChannelId(w http.ResponseWriter, r *http.Request, channelId string, params GetApiTransferChannelIdParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Report transfer progress
+// (HEAD /api/transfer/{channel_id})
+func (_ Unimplemented) HeadApiTransferChannelId(w http.ResponseWriter, r *http.Request, channelId string) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
 // Send transfer data (writer)
 // (POST /api/transfer/{channel_id})
-func (_ Unimplemented) PostApiTransferChannelId(w http.ResponseWriter, r *http.Request, channelId string) {
+func (_ Unimplemented) PostApiTransferChannelId(w http.ResponseWriter, r *http.Request, channelId string, params PostApiTransferChannelIdParams) {
+	w.WriteHeader(http.StatusNotImplemented)
+}
+
+// Attach to a streaming channel over WebSocket
+// (GET /api/transfer/{channel_id}/ws)
+func (_ Unimplemented) GetApiTransferChannelIdWs(w http.ResponseWriter, r *http.Request, channelId string, params GetApiTransferChannelIdWsParams) {
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
@@ -155,6 +263,108 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(http.Handler) http.Handler
 
+// GetApiJobs operation middleware
+func (siw *ServerInterfaceWrapper) GetApiJobs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetApiJobsParams
+
+	// ------------- Optional query parameter "type" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "type", r.URL.Query(), &params.Type)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "type", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", r.URL.Query(), &params.Status)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "status", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "worker_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "worker_id", r.URL.Query(), &params.WorkerId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "worker_id", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "worker_group" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "worker_group", r.URL.Query(), &params.WorkerGroup)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "worker_group", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "metadata" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "metadata", r.URL.Query(), &params.Metadata)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "metadata", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "created_after" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_after", r.URL.Query(), &params.CreatedAfter)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "created_after", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "created_before" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "created_before", r.URL.Query(), &params.CreatedBefore)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "created_before", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "sort" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "sort", r.URL.Query(), &params.Sort)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "sort", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetApiJobs(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
 // PostApiJobs operation middleware
 func (siw *ServerInterfaceWrapper) PostApiJobs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -189,6 +399,153 @@ func (siw *ServerInterfaceWrapper) PostApiJobsClaim(w http.ResponseWriter, r *ht
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
+// GetApiJobsDeadLetter operation middleware
+func (siw *ServerInterfaceWrapper) GetApiJobsDeadLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetApiJobsDeadLetter(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostApiJobsDeadLetterJobIdRedrive operation middleware
+func (siw *ServerInterfaceWrapper) PostApiJobsDeadLetterJobIdRedrive(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "job_id" -------------
+	var jobId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "job_id", runtime.ParamLocationPath, chi.URLParam(r, "job_id"), &jobId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "job_id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostApiJobsDeadLetterJobIdRedrive(w, r, jobId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetApiJobsStream operation middleware
+func (siw *ServerInterfaceWrapper) GetApiJobsStream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetApiJobsStreamParams
+
+	// ------------- Optional query parameter "types" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "types", r.URL.Query(), &params.Types)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "types", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "worker_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "worker_id", r.URL.Query(), &params.WorkerId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "worker_id", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "worker_group" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "worker_group", r.URL.Query(), &params.WorkerGroup)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "worker_group", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetApiJobsStream(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetApiJobsWorkflowsWorkflowId operation middleware
+func (siw *ServerInterfaceWrapper) GetApiJobsWorkflowsWorkflowId(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "workflow_id" -------------
+	var workflowId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "workflow_id", runtime.ParamLocationPath, chi.URLParam(r, "workflow_id"), &workflowId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workflow_id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetApiJobsWorkflowsWorkflowId(w, r, workflowId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetApiJobsWorkflowsWorkflowIdEvents operation middleware
+func (siw *ServerInterfaceWrapper) GetApiJobsWorkflowsWorkflowIdEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "workflow_id" -------------
+	var workflowId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "workflow_id", runtime.ParamLocationPath, chi.URLParam(r, "workflow_id"), &workflowId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "workflow_id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetApiJobsWorkflowsWorkflowIdEvents(w, r, workflowId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
 // GetApiJobsJobId operation middleware
 func (siw *ServerInterfaceWrapper) GetApiJobsJobId(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -273,6 +630,62 @@ func (siw *ServerInterfaceWrapper) GetApiJobsJobIdEvents(w http.ResponseWriter,
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
+// PostApiJobsJobIdHeartbeat operation middleware
+func (siw *ServerInterfaceWrapper) PostApiJobsJobIdHeartbeat(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "job_id" -------------
+	var jobId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "job_id", runtime.ParamLocationPath, chi.URLParam(r, "job_id"), &jobId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "job_id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostApiJobsJobIdHeartbeat(w, r, jobId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// PostApiJobsJobIdPartial operation middleware
+func (siw *ServerInterfaceWrapper) PostApiJobsJobIdPartial(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "job_id" -------------
+	var jobId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "job_id", runtime.ParamLocationPath, chi.URLParam(r, "job_id"), &jobId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "job_id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostApiJobsJobIdPartial(w, r, jobId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
 // PostApiJobsJobIdPayload operation middleware
 func (siw *ServerInterfaceWrapper) PostApiJobsJobIdPayload(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -329,6 +742,43 @@ func (siw *ServerInterfaceWrapper) PostApiJobsJobIdResult(w http.ResponseWriter,
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
+// GetApiJobsJobIdResultsKey operation middleware
+func (siw *ServerInterfaceWrapper) GetApiJobsJobIdResultsKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "job_id" -------------
+	var jobId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "job_id", runtime.ParamLocationPath, chi.URLParam(r, "job_id"), &jobId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "job_id", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "key" -------------
+	var key string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "key", runtime.ParamLocationPath, chi.URLParam(r, "key"), &key)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "key", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetApiJobsJobIdResultsKey(w, r, jobId, key)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
 // PostApiJobsJobIdStatus operation middleware
 func (siw *ServerInterfaceWrapper) PostApiJobsJobIdStatus(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -357,6 +807,34 @@ func (siw *ServerInterfaceWrapper) PostApiJobsJobIdStatus(w http.ResponseWriter,
 	handler.ServeHTTP(w, r.WithContext(ctx))
 }
 
+// PostApiJobsJobIdWebhookTest operation middleware
+func (siw *ServerInterfaceWrapper) PostApiJobsJobIdWebhookTest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "job_id" -------------
+	var jobId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "job_id", runtime.ParamLocationPath, chi.URLParam(r, "job_id"), &jobId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "job_id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostApiJobsJobIdWebhookTest(w, r, jobId)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
 // GetApiState operation middleware
 func (siw *ServerInterfaceWrapper) GetApiState(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -425,8 +903,64 @@ func (siw *ServerInterfaceWrapper) GetApiTransferChannelId(w http.ResponseWriter
 
 	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
 
+	ctx = context.WithValue(ctx, TransferTokenScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetApiTransferChannelIdParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Range" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Range")]; found {
+		var Range string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Range", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Range", runtime.ParamLocationHeader, valueList[0], &Range)
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Range", Err: err})
+			return
+		}
+
+		params.Range = &Range
+
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetApiTransferChannelId(w, r, channelId)
+		siw.Handler.GetApiTransferChannelId(w, r, channelId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// HeadApiTransferChannelId operation middleware
+func (siw *ServerInterfaceWrapper) HeadApiTransferChannelId(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "channel_id" -------------
+	var channelId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "channel_id", runtime.ParamLocationPath, chi.URLParam(r, "channel_id"), &channelId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "channel_id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, TransferTokenScopes, []string{})
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.HeadApiTransferChannelId(w, r, channelId)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -453,8 +987,82 @@ func (siw *ServerInterfaceWrapper) PostApiTransferChannelId(w http.ResponseWrite
 
 	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
 
+	ctx = context.WithValue(ctx, TransferTokenScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PostApiTransferChannelIdParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Content-Range" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Content-Range")]; found {
+		var ContentRange string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Content-Range", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Content-Range", runtime.ParamLocationHeader, valueList[0], &ContentRange)
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Content-Range", Err: err})
+			return
+		}
+
+		params.ContentRange = &ContentRange
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PostApiTransferChannelId(w, r, channelId, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// GetApiTransferChannelIdWs operation middleware
+func (siw *ServerInterfaceWrapper) GetApiTransferChannelIdWs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "channel_id" -------------
+	var channelId string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "channel_id", runtime.ParamLocationPath, chi.URLParam(r, "channel_id"), &channelId)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "channel_id", Err: err})
+		return
+	}
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	ctx = context.WithValue(ctx, TransferTokenScopes, []string{})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetApiTransferChannelIdWsParams
+
+	// ------------- Required query parameter "role" -------------
+
+	if paramValue := r.URL.Query().Get("role"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "role"})
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "role", r.URL.Query(), &params.Role)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "role", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.PostApiTransferChannelId(w, r, channelId)
+		siw.Handler.GetApiTransferChannelIdWs(w, r, channelId, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -592,12 +1200,30 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/jobs", wrapper.GetApiJobs)
+	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/api/jobs", wrapper.PostApiJobs)
 	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/api/jobs/claim", wrapper.PostApiJobsClaim)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/jobs/dead-letter", wrapper.GetApiJobsDeadLetter)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/api/jobs/dead-letter/{job_id}/redrive", wrapper.PostApiJobsDeadLetterJobIdRedrive)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/jobs/stream", wrapper.GetApiJobsStream)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/jobs/workflows/{workflow_id}", wrapper.GetApiJobsWorkflowsWorkflowId)
+	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/jobs/workflows/{workflow_id}/events", wrapper.GetApiJobsWorkflowsWorkflowIdEvents)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/api/jobs/{job_id}", wrapper.GetApiJobsJobId)
 	})
@@ -607,15 +1233,27 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/api/jobs/{job_id}/events", wrapper.GetApiJobsJobIdEvents)
 	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/api/jobs/{job_id}/heartbeat", wrapper.PostApiJobsJobIdHeartbeat)
+	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/api/jobs/{job_id}/partial", wrapper.PostApiJobsJobIdPartial)
+	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/api/jobs/{job_id}/payload", wrapper.PostApiJobsJobIdPayload)
 	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/api/jobs/{job_id}/result", wrapper.PostApiJobsJobIdResult)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/jobs/{job_id}/results/{key}", wrapper.GetApiJobsJobIdResultsKey)
+	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/api/jobs/{job_id}/status", wrapper.PostApiJobsJobIdStatus)
 	})
+	r.Group(func(r chi.Router) {
+		r.Post(options.BaseURL+"/api/jobs/{job_id}/webhook/test", wrapper.PostApiJobsJobIdWebhookTest)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/api/state", wrapper.GetApiState)
 	})
@@ -628,9 +1266,15 @@ func HandlerWithOptions(si ServerInterface, options ChiServerOptions) http.Handl
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/api/transfer/{channel_id}", wrapper.GetApiTransferChannelId)
 	})
+	r.Group(func(r chi.Router) {
+		r.Head(options.BaseURL+"/api/transfer/{channel_id}", wrapper.HeadApiTransferChannelId)
+	})
 	r.Group(func(r chi.Router) {
 		r.Post(options.BaseURL+"/api/transfer/{channel_id}", wrapper.PostApiTransferChannelId)
 	})
+	r.Group(func(r chi.Router) {
+		r.Get(options.BaseURL+"/api/transfer/{channel_id}/ws", wrapper.GetApiTransferChannelIdWs)
+	})
 	r.Group(func(r chi.Router) {
 		r.Get(options.BaseURL+"/healthz", wrapper.GetHealthz)
 	})
@@ -18,27 +18,81 @@ import (
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/+xZXW/bNhf+KwLf92IF1Mhrd1HoLi2KLhmwBfW2myAwaOkkYiqRDHmUxAv03weS+rBs",
-	"0ZbcOMuA3SXUOeQ5z3O+SD+RRBRScOCoSfxEdJJBQe2f52L5Kaes+Ap3JWg0S1IJCQoZWIGCPi4eKMOF",
-	"hkTw1K7hSgKJCeMIN6BIFdoV+4khFOsyGhXjN40IiQlViq5I1S2I5S0kaCQ6W7QUXMO2MbdiuWDp4PYF",
-	"IE0pUvORpilDJjjNL9bUUZUwcKpb2NqxComCu5IpSEl82Zxci195rFdAEfxQHmqiVEwohis/9vsdGGP3",
-	"AbBrpFjqCfjVCh5LPisl1LYBiUjBw7rW9GaE+3aHTt5z/Nwa94dMd9EIjY3/V3BNYvK/qMuuqE6tqPXF",
-	"0iduFGg9mXeD1QjfnJjHpT8ZPAwAahlPF9T6dy1UYf4ixu+3yAog4TbWk/1+7kyVdJULmu7AcbdtvyvK",
-	"9TWoM34tSDWYZwcSdVdCCQsptFUZTlMFuszxeMZ7M9FbIUJS2kifEgYbodeVxNaAcD24ekcMRWjj1r4S",
-	"lGSUc8h9ZQgeJVOgD3dkbf/ebrtstlQc39KQfIOVL5MyMXxMqfIRRXHd6XozpzoaA2+VHLa52r/VC8aA",
-	"H1kFNAW1GEYxJA+KoffzwZFlkhiS0nT6ucl65/VHoArUaYlZO7oZpaVd7nzKECWpzB6sDktkmJsv/Fo9",
-	"BqcXZyQk96C0LVDkx5PZycy4IiRwKhmJyfuT2cl7YsosZvbkiEoW3Yql/UcKx7IhhJridZaSmFwIjaeS",
-	"nRsh5zZo/CjSlevaHIFbLSplzhKrF91qVyJdZRvRUPpjVdUH2FRkV11t1Fhb381mxzi/jktrQAo6UUy6",
-	"cm/6bFBXvR6NJL7sE3h5VV2FRJdFQdWKxMRtHdDgViytZot5lJhReBTydmg+Ivzr94Oqhv+IaPeuAD6w",
-	"jZABOyTvZj+ZXfsyvwqDqA7oPWU5XeYwkRazfYAZBBweMbDtPR3g6MmNtZUx4AYGWPoCDUnnYnmW2uxS",
-	"tAAEpa0VzFhrMo6EhFOb2e2o3A/ycA3CzYpzdVxK7AzpYaLu+pPg/QJowFzX3cY0SihPIB+VARbcT07+",
-	"lULcb2W7rk2bXWE4Aayzk8uN1RosNy3scN88E4yJ6M9O+h8DHeERnclvNSqgRR/1zQ23sLT2B7XqJCzn",
-	"VsfGcQ2ZB9D62jI+kC9qhWOC+vydYnMiPHKn8E2NAxw3okE9ih3WquuDgprOADd29dHv7n3j2f/q5P8j",
-	"/zWS78gczX1X5sdxP2+u0P8m7j3vZkeY05+xhzqLg/p1YlowOD8HJ5j2xW5H75xbme+EYsLz1LDzMH1k",
-	"06DuQQW61e5cjrrmu89z1zfJ6+/sw+42qb83qZvCQ16gDu6/oT5PGWyvq/4C2HyJnrqHj333o9YNpzHy",
-	"ntR7WDnWIC8ShOEAbB+YlowbdML9IekCC9KgMSBYmkI8sRMlwO6hYyClSIMf3IPVG/uKPSYqXw7qMQ3n",
-	"u1B+RV2lyzJRyBwm59cceLrJrHtrfOMyLAOaY/bXrnz6uRZ5MVQ2f4oa/HVvG6vffnHYtM47w4Mkg+Sb",
-	"U3AV2EWlfWu1r5xxFOUioXkmNMYfZh9mpLqq/g4AAP//1w2aU+MeAAA=",
+	"H4sIAAAAAAAC/+w8+2/bOJP/CqE7YDeAHbvZdm+/BN8P6eO27dd+DZLs9YBNYdDS2GIjkypJJfEG+d8P",
+	"M6RetmRLSdwHbn9LLD6G857hDG+DUC1SJUFaExzeBiaMYcHpz7dq+iLhYnEKXzIwFn9KtUpBWwE0YMFv",
+	"Jtdc2ImBUMmIfrPLFILDQEgLc9DB3YB+oU/CwqI6xlgt5DwfEhwGXGu+xP+vlb4EPZlrlaWNE/wAETV8",
+	"LddT088QWhxfHsWkShpYPwu3FhapbT5CiHMnVl2CxAERmFCL1Aolg8PgTQTSipkAw2wsDKPBR0zDl0xo",
+	"iJiSzFhuM8OyNOIWDOMyYjFwbafArdkPBusHpEUgmmzFxMrARowMgs9q2vYpAW5gAjep0GAmnDAwU3qB",
+	"fwUI79CKBTTBuADLI245oS+KBOKDJycVtFqdQQM1Ui2UFnbZzi+biH5vrsgpEhz+mSPE7/aphWU0cAut",
+	"7B9BCjIyE9XEFC8NUzP2WU2RK7hli8xYhqKWgAU2hZnS4Pjls5oyYdiXDDKIkBm6y8m9KSCVnTgYupN7",
+	"M9U0WC7kRIPJEmvWEXJm8cD+M8qEjYEZ0FegmVH435KFXLIpsEhdy0TxCCLGZxY0DXW0ZTMhhYmhKjRT",
+	"pRLg0gOhCb7/1DALDoP/GJXKbeQ12+itmp7iuBOViJDQiF+iLIF1qP9bXMFwJiCJWKiVZHCTajBGKMl+",
+	"/uP8xR4LkUWEnDPONISZRmSxCBBMXKJRttsZHKaxUpcdDvDRj3yoVLivs0Rd++/14/+OixqmIeEWIuLm",
+	"IyZkDFrg/zOtFkScmdDGspRrkJZdxyCZWghrHTtvFsQu8temszdotfswuNPSPXSHn9AC/SutlV4HOlRR",
+	"M/UXYAyfw/b9aYVyfMv2r3MD06q/Nlq1c/yZabCZlhCxa2FjIjVN2mfv+RJF1ZOZXcciAUamxDCugUXC",
+	"8GmC1k/nwv5ZTX8ynlW8tW1mj6bTvBPGbmSEuoexRXz+R8B1kz6VcGMnYaaN0usYeUG/s5lyCgnHspTP",
+	"4YjxqUHG9+dMuHEftjM/wd1CvxOureDJj0W9QZCbozo8x3LJ3p59+De74kkGR0yl/EsGzComZ/pmfw0x",
+	"tEoLYqrKew0rUx5eqtlsssgSK9JEgK7Ik8wWU2et8mHrjms5Br1bf9IWzxZHdFmJzJJ3E7oa9xWE1IBp",
+	"QcwZqaM/yMncCdv84f1X0vtcMuAaMew+o7uDRnDoHBlyaziZfAQxX+3p+B/oDScw2T0rQq6At2gDp6jJ",
+	"w1FzDcb0dqfQEHRQ3G5YC/VIJ7WFJS0MGHIZQjLh4aVU1wlEc4h6efB+fgH+issG3ph7pLNrbpibUlCK",
+	"545ZjD6SsEesCgxTMoSq+2asSlPnFoDMFogU7TgVomAQVOcGn5oAftywiLy3njiLgEeTBKwF3XtmNV7o",
+	"7uX35uO2SI8bO9kUCeL3rZitjvp+Qsp7BTR8iWHGBnHfjPJzzaWZgX4jZyq46x3n3lvbkIKdpMoIJ6jN",
+	"4VgRh+3kaOvh3k6isXUf3X9sY71WH35D2OXSMv1kuXeolrucjxuubbY3ZYKjQExN59WO3mKWPpYHrVsm",
+	"A6EG22A1xFwaFkEirkALMM7uv35//GJ49vr44NmvR0wCRv2FmzFdkok4PnnTHC6DXgjJk4mSybKZ0zKd",
+	"bMcGDtp8yJcO6GVfM1wo6DXY4QqkbfyySQf73XoxpPbB0WQlvqyAWcpGbnvRHuH8QeDpRVZ4xkXSaH+b",
+	"2MudsMJf27zUqjw0pNRytukTzq2Sr8GG7pqJTiHhy3Ou500i8UJJ6XxgPncZEmWAYeQAMqomwjSuYhhn",
+	"xmrgC8wlhTGXEhIMlTD41GoxYOrKJ8VouZ8MC5W0WiUsdBv5xFMdtTHwCPRGi9DmiVQsGrfx+vme+5Pg",
+	"1yOWm1Vy308+nJ1DhNALS3nvPAM4A4uq3MUSwjot8fur80YV0COxWw710DZRKzdvWxK8iFaQdgIyVCQn",
+	"ayd/5b9g8IMEmapoOWAmC2PGDZv/JdKBoy2hQDsS4KcXbulhvsA+OxN/AWHIxPzg2a+Mp2myxFm4LkFA",
+	"qtJCy4WBhzW3cnU430MkOMNvK5Cuwta4NsW5uPP6wu+Q5Y2l5fz1h2dYHoaQutuNQoMJaX99GgwadNPC",
+	"a6366heBE4SLgP0cwYxnid1jqUgBM8jArrWwoJmQKBwS/BmO2EUwzWYz0BBdBMykSiWmODMlmz35KZoh",
+	"jlx41KNh4klBKPxMjMpEc4ipS6FaFXmf6ccRKNE5RAV+CPUmvwkQloWJMmCKg7Ine/uNqHL8sb7la7hh",
+	"Z6+Ph8g8FSIf0V/5kRkqd8q/L4RZcBvGjecy4q8Gcry64aEnNQ5gQjqGdBGeIwbdcxiQUSfC33UQzrak",
+	"n0dkm6d0r/CjLTfr6FymTdYG0Jd7hTwOb61Lr+Z+y0PXjrhJy5ET3xd9Tbpvq9J5FCJcwrJxrZoW6qJS",
+	"wMaq+XCtdF7x+nrlgFrE8iWECUfBjwRqygHz+WP3L1sANxl+xryyi6qgsJIbZbMDClrSfC8KnwLTfXlC",
+	"O0yE8ya0SoD97OUZv+YWfeAVo4eVQNxrhPH+4tDJC6tJgaezm9pZKP42+luMficx/NsZeBzl8S38CAo2",
+	"tkVX1bjm/5Hv4bXDV3U+WgVuB8bK+zPNyvZ+rNHL1rgorbA1FbNS2pqKAdpoa7wD1d9wbLEUH32qrTlT",
+	"8VhXzhsSlr1yfdXBlWxMyw0zbgxhhsnpM4TRnek5cA36OHMxPgGPk6b0c4n62Nq0KiznzZQ/AT3MlZQn",
+	"sNI5VYkdjhhPjPLGASI0kcgcF46XLgKsidJLlnLNF2BBI/kFLu1yGcEgkJwg/N9hDsvQAVPiORX/ArxO",
+	"vcOpzg+2wib4Da+eMesYDIIr0MZB/WR/vD/G06kUJE9FcBj8sj/e/8WnEwhPI56KUc4APuODvMHx5G8i",
+	"LJwBe5yKtzhkEBTwm+DwzwYFv+BDAzjI19iQyTbFYQkJ5VnzfC6xViNfdNnCMUj7LgUD9djnPap0r5GL",
+	"Wz2kOV3GocPkbwHfvGzbtpq92cXOlHbfsjmN6bm/v7ZiM5EgndE6XcLykKodUINRPWgK3Dqvi8Q2dzDa",
+	"wCnuwqqgdC8fuG1ctMj+zyzo2spdjNS2Vf0N3H2WXblEUNoyqrsbsFTDTNzkBQRDMgg42uWumdKoWQpv",
+	"Z1ic0O61MrfStgZkng+v3Y0M225KBsGw9l9xzTcIhsXfnzqc8oTPwXkzBfjPxgO24Dfs2XjcCn4iFsI2",
+	"MWjFtVndqlLZVJbtpRquhMpMUa3USFuatFEePpXXD8SbB+NxJZLCPzGuESHpx9Fn4y4ty/W2mM5a7Rdp",
+	"8pXiIoI/L7dFWXjqAFgpyZVXPBGRl9EBQy4YMEImqQp3zqppJHVdNYp/fsKzmmyx4HqJAY8wttg0VabB",
+	"EpwoUzEFXjk9V9HyMTFUz2Pf1X0DqzO42y2FVlJ1DTR6q6bMy1M/FLulGUc008zC+I5IuZNntg3zVPu/",
+	"Q/RXuyTuPPp3iO1aJ0MbshMqgEHGPBg/XZeGfytXnM6vuEiwwqonWXD5sg6yrPlaoVEEPBq6apkOztJL",
+	"4NE7N/iBKNyFs74eMK6j/mV5XocPM2ALZSzTEIK0rmrtnkrGNRLATcwz8m9sDEKz4r61FfGjW1f0czfS",
+	"EGlxBZ1kpqTFWzV9E536qWvuLNkMumkrTEZRIF1XQt/QhHRTUEXxorMhDVLz1vVqSGVZVKV0P4qe+n0Y",
+	"r6/SID8u5dVBdM7cwC3RxofUpQhYuBITJMhkalbGYp3ikK0BwoN8/Af56NsZysKNHVH5wrDEcvuCaxzz",
+	"Cqf6m3pEnde43h/oww+OdkiTlFsxTaCiTw35u3mZ5Qp35EG/Gd1W4v+7DuySJzeKP95EnUS7nmb4PuS7",
+	"lqhpIFT+nc01T+NW2S6GoXTPVCZ7SvXvYBlPEkc0KobOkVUUUgvNXBEoyFCA6UZNx6PmfkR95eZ+W9I+",
+	"pqTdS7J8/6VDJAkUht5L13onK5RaoUhuODvgnozkD2od2wTnbZEo6i8Jn+tz13E6cpXknXwRQu4LN/47",
+	"RfFKceSGVrKtPiS5777MvqdXTrMag6UC7Z21CSG9h/7YCdK/uepAPvYoa0Fo0cndnZWL3rydInYnse5a",
+	"W+EOsg11Wbp/N8GDpPAdbsvgxoKM8ojgHxsjAiHZLBHzuGd894q2cK2DtCf5DhVvso3xUtch2J3tfEvh",
+	"j8d0K72QO2c5A186Vdh04SMPu7/DZGk2TYSJc45qyFO+F8ZQFzm3fGMgWvFTezBnUYDkuvbcvawwriuv",
+	"H+cep+jLMs7S+hmtwu5AIYdux808TNe9fXjYTfixeHi16mjHScK2MoYG9syHFvUk98rS+o3y23tmV1Zt",
+	"I79jmO7UP3Xj/yb+90j8XPp70d6Mbi9h2TnCcgxg8E5/R0wwaFzoEpa9VukXTqjQQrODW5igqZCcEmFb",
+	"XV6HIlcd5dL/DQVbp8UtOY5jmst5USXohbLN9Pj10agYq7S7aHfuWU/GeekfemG8rHz9THlYB0Az35Re",
+	"XTed4drifzynp6mdf/eez0Nc5rPqM1c9ecGds0PawHdejmxevduJCXyL2DlO+mrB7MEDCOE74ZZd3zhr",
+	"uopyK7AOdxqFK4myHHN0Gdl1/sZQz6sNk0cz7p2GMNPaxeDEHNQJZmqLE5GLNwg2WIEzGvNAfu9RJ9nM",
+	"4dA/GZa/c1XMLo/c7YaH9i2ueL77dGvjcXO/YKvQ5l5JsFt3bK1o4ms4ZdsvIh/HJyvKJtq9sfzL6Las",
+	"it3mhxXHcDM6ZrxrVbe9PLHV1nI5T6Dqsfy8Wg5vGDb47g0Y7M/3sfZ/acH888n44OnwImitHz3FxYLv",
+	"1J9zQuWaggiASml9pfD1zHeQyKj2K1aXWc1FAtqwn1G709nNwL3NUmkowFUxEZA/UrjHHBxT/4JLrBK3",
+	"dcWp/Dr+bO6qFl7qehsEWbcnTY4uzSFnlVthZqJTrcvgdrW6ed3QhSCuKs0NVP/pK9n3EBxEdEOv9BrD",
+	"akiVtlWifZjNDNhB9ad3IOc2XqVu0R0Sl408dbF9DTz62nLbIivNfQHCMJWCbPVO8nH1ZNeTDStS/0uv",
+	"oKQLtYlGBbGLt1u21f19W4V5St1MtbahLMWIq64fGenHg/HT/xodjJ/+tkFR5h1sHRVmF+P9IF3xuMGP",
+	"Irnb9njGPVzx0qZ7gfV1eQffI7DP66ySB/lZSm9Q0K5HeZ8cFTbUmYLE85cGxYfmBW5CgMgV6nsm/8m4",
+	"EmhX1UyznzU+oUF7nC9TcM9d8EiE1q0U5S22HpVUuNRqEf5wx4oUGJ/E4NoyTkXAxbu8kB/boxkXOzho",
+	"OVWxFDW11UGisymdd8c9gi46o/iqbnZcV83eFududF29913FylzzCChA4+wjTM9UeAmIF8vDuGzOKam2",
+	"2tPjPBJlY9DMiAjYgi9ZZoC9Pj8/2WfHfhg16BnmZJr5R0yxKCNJ1HX5GhAGMflXdntBbHsRHF4EoGYX",
+	"wd0R4zkA2lnitSX32XPlym4km+NJ8PFgntRWHuDSTlpocXV5EdzlVty49iSca0rnCgm6h6e+vXAP/1wE",
+	"h6GK4G5AnyuvufhGTJKRJ+PxmF4Cz8IQjMH5T/GnNMkcXhFJeYzs83C0egVx7qqGmhyngJj1LRFaJfBP",
+	"N2b/QgaDbp77R7NjU9RUvoegblynfBXQGx53rqZ2ilU348n4ScNrVNfChjFep/mm6pKzU62sClWy9SJO",
+	"aSZ87wDB389VabqXW3UCjwo58UrXNGhWJqSxwB/DnTkmoXaivv7UED0vVODJ6ZQYeGLjvzaFh6/9kK+W",
+	"j1x9WrPxKeZ1+/bhXw6DBTYc4CyMIbx0E5wAO5mgvlLqeTwcjRIV8iRWxh7+Nv5tHNx9uvu/AQA6wXr0",
+	"QGEAAA==",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
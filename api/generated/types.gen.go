@@ -8,7 +8,37 @@ import (
 )
 
 const (
-	BearerAuthScopes = "BearerAuth.Scopes"
+	BearerAuthScopes    = "BearerAuth.Scopes"
+	TransferTokenScopes = "TransferToken.Scopes"
+)
+
+// Defines values for JobViewCancelState.
+const (
+	Acknowledged JobViewCancelState = "acknowledged"
+	Requested    JobViewCancelState = "requested"
+)
+
+// Defines values for JobWebhookDeliveryStatus.
+const (
+	Delivered JobWebhookDeliveryStatus = "delivered"
+	Failed    JobWebhookDeliveryStatus = "failed"
+	Pending   JobWebhookDeliveryStatus = "pending"
+)
+
+// Defines values for GetApiJobsParamsSort.
+const (
+	CreatedAt      GetApiJobsParamsSort = "created_at"
+	MinusCreatedAt GetApiJobsParamsSort = "-created_at"
+	MinusPriority  GetApiJobsParamsSort = "-priority"
+	MinusUpdatedAt GetApiJobsParamsSort = "-updated_at"
+	Priority       GetApiJobsParamsSort = "priority"
+	UpdatedAt      GetApiJobsParamsSort = "updated_at"
+)
+
+// Defines values for GetApiTransferChannelIdWsParamsRole.
+const (
+	Reader GetApiTransferChannelIdWsParamsRole = "reader"
+	Writer GetApiTransferChannelIdWsParamsRole = "writer"
 )
 
 // JobClaimRequest defines model for JobClaimRequest.
@@ -21,10 +51,16 @@ type JobClaimRequest struct {
 
 // JobClaimResponse defines model for JobClaimResponse.
 type JobClaimResponse struct {
+	Attempt *int `json:"attempt,omitempty"`
+
+	// ClaimToken Identifies this claim; required on status updates and heartbeats.
+	ClaimToken         *string                 `json:"claim_token,omitempty"`
 	ClaimedWorkerGroup *string                 `json:"claimed_worker_group,omitempty"`
 	ClaimedWorkerId    *string                 `json:"claimed_worker_id,omitempty"`
 	JobId              string                  `json:"job_id"`
+	LeaseExpiresAt     *time.Time              `json:"lease_expires_at,omitempty"`
 	Metadata           *map[string]interface{} `json:"metadata,omitempty"`
+	Priority           *int                    `json:"priority,omitempty"`
 	Type               string                  `json:"type"`
 	WorkerGroup        *string                 `json:"worker_group,omitempty"`
 	WorkerId           *string                 `json:"worker_id,omitempty"`
@@ -32,17 +68,32 @@ type JobClaimResponse struct {
 
 // JobCreateRequest defines model for JobCreateRequest.
 type JobCreateRequest struct {
-	Metadata    *map[string]interface{} `json:"metadata,omitempty"`
-	Priority    *int                    `json:"priority,omitempty"`
-	Type        string                  `json:"type"`
-	WorkerGroup *string                 `json:"worker_group,omitempty"`
-	WorkerId    *string                 `json:"worker_id,omitempty"`
+	// DependsOn IDs of jobs that must complete before this job is queued.
+	DependsOn *[]string               `json:"depends_on,omitempty"`
+	Metadata  *map[string]interface{} `json:"metadata,omitempty"`
+	NotBefore *time.Time              `json:"not_before,omitempty"`
+	Priority  *int                    `json:"priority,omitempty"`
+
+	// RetainResults Store results on the server so they can be downloaded after the worker finishes.
+	RetainResults *bool           `json:"retain_results,omitempty"`
+	Retry         *JobRetryPolicy `json:"retry,omitempty"`
+
+	// Schedule Five-field cron expression (UTC) creating a recurring definition.
+	Schedule    *string     `json:"schedule,omitempty"`
+	Type        string      `json:"type"`
+	Webhook     *JobWebhook `json:"webhook,omitempty"`
+	WorkerGroup *string     `json:"worker_group,omitempty"`
+	WorkerId    *string     `json:"worker_id,omitempty"`
+
+	// WorkflowId Groups related jobs; inherited from the first parent when omitted.
+	WorkflowId *string `json:"workflow_id,omitempty"`
 }
 
 // JobCreateResponse defines model for JobCreateResponse.
 type JobCreateResponse struct {
-	JobId  string `json:"job_id"`
-	Status string `json:"status"`
+	JobId     string     `json:"job_id"`
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	Status    string     `json:"status"`
 }
 
 // JobError defines model for JobError.
@@ -51,52 +102,209 @@ type JobError struct {
 	Message string `json:"message"`
 }
 
+// JobHeartbeatRequest defines model for JobHeartbeatRequest.
+type JobHeartbeatRequest struct {
+	// ClaimToken Token returned with the claim. May be omitted while leases are disabled or on the job's first attempt.
+	ClaimToken *string `json:"claim_token,omitempty"`
+}
+
+// JobListResponse defines model for JobListResponse.
+type JobListResponse struct {
+	Jobs []JobView `json:"jobs"`
+
+	// NextCursor Cursor for the next page; absent on the last page.
+	NextCursor *string `json:"next_cursor,omitempty"`
+}
+
+// JobPartialRequest defines model for JobPartialRequest.
+type JobPartialRequest struct {
+	// ClaimToken Token returned with the claim. May be omitted while leases are disabled or on the job's first attempt.
+	ClaimToken *string `json:"claim_token,omitempty"`
+
+	// Data Any JSON value; opaque to nfrx.
+	Data interface{} `json:"data"`
+}
+
+// JobRetryPolicy defines model for JobRetryPolicy.
+type JobRetryPolicy struct {
+	BackoffMultiplier *float32  `json:"backoff_multiplier,omitempty"`
+	BackoffSeconds    *float32  `json:"backoff_seconds,omitempty"`
+	MaxAttempts       int       `json:"max_attempts"`
+	MaxBackoffSeconds *float32  `json:"max_backoff_seconds,omitempty"`
+	RetryOn           *[]string `json:"retry_on,omitempty"`
+}
+
 // JobStatusUpdateRequest defines model for JobStatusUpdateRequest.
 type JobStatusUpdateRequest struct {
-	Error    *JobError               `json:"error,omitempty"`
-	Progress *map[string]interface{} `json:"progress,omitempty"`
-	State    string                  `json:"state"`
+	// ClaimToken Token returned with the claim. Updates from an earlier claim of a re-queued job are rejected with 409 stale_claim. May be omitted while leases are disabled or on the job's first attempt.
+	ClaimToken *string                 `json:"claim_token,omitempty"`
+	Error      *JobError               `json:"error,omitempty"`
+	Progress   *map[string]interface{} `json:"progress,omitempty"`
+	State      string                  `json:"state"`
 }
 
 // JobView defines model for JobView.
 type JobView struct {
+	Attempts             *int       `json:"attempts,omitempty"`
+	CancelAcknowledgedAt *time.Time `json:"cancel_acknowledged_at,omitempty"`
+
+	// CancelState Set when the job was canceled while a worker held it; acknowledged once the worker stopped.
+	CancelState        *JobViewCancelState      `json:"cancel_state,omitempty"`
 	ClaimedWorkerGroup *string                  `json:"claimed_worker_group,omitempty"`
 	ClaimedWorkerId    *string                  `json:"claimed_worker_id,omitempty"`
 	CreatedAt          time.Time                `json:"created_at"`
+	DeadLetteredAt     *time.Time               `json:"dead_lettered_at,omitempty"`
+	DependsOn          *[]string                `json:"depends_on,omitempty"`
 	Error              *JobError                `json:"error,omitempty"`
 	Id                 string                   `json:"id"`
+	LastJobId          *string                  `json:"last_job_id,omitempty"`
+	LastWorkerGroup    *string                  `json:"last_worker_group,omitempty"`
+	LastWorkerId       *string                  `json:"last_worker_id,omitempty"`
+	LeaseExpiresAt     *time.Time               `json:"lease_expires_at,omitempty"`
 	Metadata           *map[string]interface{}  `json:"metadata,omitempty"`
+	NotBefore          *time.Time               `json:"not_before,omitempty"`
 	Payloads           *map[string]TransferInfo `json:"payloads,omitempty"`
+	Priority           *int                     `json:"priority,omitempty"`
 	Progress           *map[string]interface{}  `json:"progress,omitempty"`
 	QueuePosition      *int                     `json:"queue_position,omitempty"`
 	Results            *map[string]TransferInfo `json:"results,omitempty"`
+	RetainResults      *bool                    `json:"retain_results,omitempty"`
+	Retry              *JobRetryPolicy          `json:"retry,omitempty"`
+	Schedule           *string                  `json:"schedule,omitempty"`
+	ScheduleId         *string                  `json:"schedule_id,omitempty"`
 	Status             string                   `json:"status"`
 	Type               string                   `json:"type"`
 	UpdatedAt          time.Time                `json:"updated_at"`
+	Webhook            *JobWebhookView          `json:"webhook,omitempty"`
 	WorkerGroup        *string                  `json:"worker_group,omitempty"`
 	WorkerId           *string                  `json:"worker_id,omitempty"`
+	WorkflowId         *string                  `json:"workflow_id,omitempty"`
+}
+
+// JobViewCancelState Set when the job was canceled while a worker held it; acknowledged once the worker stopped.
+type JobViewCancelState string
+
+// JobWebhook defines model for JobWebhook.
+type JobWebhook struct {
+	// Secret Signs deliveries with HMAC-SHA256; never returned by the API.
+	Secret       *string `json:"secret,omitempty"`
+	TerminalOnly *bool   `json:"terminal_only,omitempty"`
+	Url          string  `json:"url"`
+}
+
+// JobWebhookDelivery defines model for JobWebhookDelivery.
+type JobWebhookDelivery struct {
+	Attempts      int                      `json:"attempts"`
+	Error         *string                  `json:"error,omitempty"`
+	Event         string                   `json:"event"`
+	Id            string                   `json:"id"`
+	LastAttemptAt *time.Time               `json:"last_attempt_at,omitempty"`
+	ResponseCode  *int                     `json:"response_code,omitempty"`
+	Status        JobWebhookDeliveryStatus `json:"status"`
+}
+
+// JobWebhookDeliveryStatus defines model for JobWebhookDelivery.Status.
+type JobWebhookDeliveryStatus string
+
+// JobWebhookView defines model for JobWebhookView.
+type JobWebhookView struct {
+	Deliveries   *[]JobWebhookDelivery `json:"deliveries,omitempty"`
+	TerminalOnly *bool                 `json:"terminal_only,omitempty"`
+	Url          string                `json:"url"`
+}
+
+// RelayTarget Connected agent whose backend the server relays a streaming channel to or from, over the agent's control connection.
+type RelayTarget struct {
+	Headers *map[string]string `json:"headers,omitempty"`
+
+	// Path Backend path; payloads are POSTed to it and results fetched from it with GET.
+	Path     string `json:"path"`
+	WorkerId string `json:"worker_id"`
+}
+
+// TransferCreateRequest defines model for TransferCreateRequest.
+type TransferCreateRequest struct {
+	// ContentEncoding Encoding of the body, such as gzip, served to readers as Content-Encoding. Size and sha256 apply to the encoded bytes.
+	ContentEncoding *string `json:"content_encoding,omitempty"`
+
+	// ContentType Media type of the body, served to readers.
+	ContentType *string `json:"content_type,omitempty"`
+
+	// MaxBytes Largest body this channel accepts.
+	MaxBytes *int64 `json:"max_bytes,omitempty"`
+
+	// Mode "stream" (default) pipes one writer into one reader; "buffered" spools the body so transfers can resume and several readers can fetch it.
+	Mode *string `json:"mode,omitempty"`
+
+	// Readers Complete reads a buffered channel serves before it closes (default 1).
+	Readers *int `json:"readers,omitempty"`
+
+	// Sha256 Hex SHA-256 of the body; the transfer fails on mismatch.
+	Sha256 *string `json:"sha256,omitempty"`
+
+	// Size Exact body size in bytes the writer must send.
+	Size *int64 `json:"size,omitempty"`
 }
 
 // TransferCreateResponse defines model for TransferCreateResponse.
 type TransferCreateResponse struct {
-	ChannelId string    `json:"channel_id"`
-	ExpiresAt time.Time `json:"expires_at"`
+	ChannelId      string     `json:"channel_id"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	Mode           *string    `json:"mode,omitempty"`
+	ReaderToken    *string    `json:"reader_token,omitempty"`
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
+	WriterToken    *string    `json:"writer_token,omitempty"`
 }
 
 // TransferInfo defines model for TransferInfo.
 type TransferInfo struct {
-	ChannelId  string                  `json:"channel_id"`
-	ExpiresAt  time.Time               `json:"expires_at"`
-	Key        *string                 `json:"key,omitempty"`
-	Method     string                  `json:"method"`
-	Properties *map[string]interface{} `json:"properties,omitempty"`
-	Url        string                  `json:"url"`
+	ChannelId       string                  `json:"channel_id"`
+	ContentEncoding *string                 `json:"content_encoding,omitempty"`
+	ContentType     *string                 `json:"content_type,omitempty"`
+	ExpiresAt       time.Time               `json:"expires_at"`
+	Key             *string                 `json:"key,omitempty"`
+	MaxBytes        *int64                  `json:"max_bytes,omitempty"`
+	Method          string                  `json:"method"`
+	Mode            *string                 `json:"mode,omitempty"`
+	Properties      *map[string]interface{} `json:"properties,omitempty"`
+
+	// Sha256 Declared digest, or the digest measured for retained results.
+	Sha256 *string `json:"sha256,omitempty"`
+	Size   *int64  `json:"size,omitempty"`
+
+	// Token Channel token for the client's role (writer for payloads, reader for results).
+	Token          *string    `json:"token,omitempty"`
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
+	Url            string     `json:"url"`
 }
 
 // TransferRequest defines model for TransferRequest.
 type TransferRequest struct {
-	Key        *string                 `json:"key,omitempty"`
+	// ContentEncoding Encoding of the body, such as gzip, served to readers as Content-Encoding. Size and sha256 apply to the encoded bytes.
+	ContentEncoding *string `json:"content_encoding,omitempty"`
+
+	// ContentType Media type of the body, served to readers.
+	ContentType *string `json:"content_type,omitempty"`
+	Key         *string `json:"key,omitempty"`
+
+	// MaxBytes Largest body this channel accepts.
+	MaxBytes *int64 `json:"max_bytes,omitempty"`
+
+	// Mode "stream" (default) pipes one writer into one reader; "buffered" spools the body so transfers can resume and several readers can fetch it.
+	Mode       *string                 `json:"mode,omitempty"`
 	Properties *map[string]interface{} `json:"properties,omitempty"`
+
+	// Readers Complete reads a buffered channel serves before it closes (default 1).
+	Readers *int `json:"readers,omitempty"`
+
+	// Relay Connected agent whose backend the server relays a streaming channel to or from, over the agent's control connection.
+	Relay *RelayTarget `json:"relay,omitempty"`
+
+	// Sha256 Hex SHA-256 of the body; the transfer fails on mismatch.
+	Sha256 *string `json:"sha256,omitempty"`
+
+	// Size Exact body size in bytes the writer must send.
+	Size *int64 `json:"size,omitempty"`
 }
 
 // TransferRequestResponse defines model for TransferRequestResponse.
@@ -104,17 +312,97 @@ type TransferRequestResponse struct {
 	ChannelId  string                  `json:"channel_id"`
 	ExpiresAt  time.Time               `json:"expires_at"`
 	Key        *string                 `json:"key,omitempty"`
+	Mode       *string                 `json:"mode,omitempty"`
 	Properties *map[string]interface{} `json:"properties,omitempty"`
 	ReaderUrl  *string                 `json:"reader_url,omitempty"`
-	WriterUrl  *string                 `json:"writer_url,omitempty"`
+
+	// Relay Connected agent whose backend the server relays a streaming channel to or from, over the agent's control connection.
+	Relay *RelayTarget `json:"relay,omitempty"`
+
+	// Token Channel token for the worker's role (reader for payloads, writer for results).
+	Token     *string `json:"token,omitempty"`
+	WriterUrl *string `json:"writer_url,omitempty"`
 }
 
+// WorkflowView defines model for WorkflowView.
+type WorkflowView struct {
+	Jobs       []JobView `json:"jobs"`
+	Status     string    `json:"status"`
+	WorkflowId string    `json:"workflow_id"`
+}
+
+// GetApiJobsParams defines parameters for GetApiJobs.
+type GetApiJobsParams struct {
+	// Type Comma-separated job types.
+	Type *string `form:"type,omitempty" json:"type,omitempty"`
+
+	// Status Comma-separated job statuses.
+	Status *string `form:"status,omitempty" json:"status,omitempty"`
+
+	// WorkerId Matches the requested or claiming worker ID.
+	WorkerId *string `form:"worker_id,omitempty" json:"worker_id,omitempty"`
+
+	// WorkerGroup Matches the requested or claiming worker group.
+	WorkerGroup *string `form:"worker_group,omitempty" json:"worker_group,omitempty"`
+
+	// Metadata Metadata filters in key:value form; repeat to require several.
+	Metadata      *[]string  `form:"metadata,omitempty" json:"metadata,omitempty"`
+	CreatedAfter  *time.Time `form:"created_after,omitempty" json:"created_after,omitempty"`
+	CreatedBefore *time.Time `form:"created_before,omitempty" json:"created_before,omitempty"`
+
+	// Sort Sort field, prefixed with - for descending order (default -created_at).
+	Sort *GetApiJobsParamsSort `form:"sort,omitempty" json:"sort,omitempty"`
+
+	// Limit Page size (default 50, max 500).
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Cursor next_cursor from the previous page.
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+}
+
+// GetApiJobsParamsSort defines parameters for GetApiJobs.
+type GetApiJobsParamsSort string
+
+// GetApiJobsStreamParams defines parameters for GetApiJobsStream.
+type GetApiJobsStreamParams struct {
+	// Types Optional comma-separated list of accepted job types.
+	Types       *string `form:"types,omitempty" json:"types,omitempty"`
+	WorkerId    *string `form:"worker_id,omitempty" json:"worker_id,omitempty"`
+	WorkerGroup *string `form:"worker_group,omitempty" json:"worker_group,omitempty"`
+}
+
+// GetApiTransferChannelIdParams defines parameters for GetApiTransferChannelId.
+type GetApiTransferChannelIdParams struct {
+	// Range Single byte range (buffered channels only), e.g. "bytes=1024-".
+	Range *string `json:"Range,omitempty"`
+}
+
+// PostApiTransferChannelIdParams defines parameters for PostApiTransferChannelId.
+type PostApiTransferChannelIdParams struct {
+	// ContentRange Resumes a buffered upload, e.g. "bytes 1024-2047/2048".
+	ContentRange *string `json:"Content-Range,omitempty"`
+}
+
+// GetApiTransferChannelIdWsParams defines parameters for GetApiTransferChannelIdWs.
+type GetApiTransferChannelIdWsParams struct {
+	Role GetApiTransferChannelIdWsParamsRole `form:"role" json:"role"`
+}
+
+// GetApiTransferChannelIdWsParamsRole defines parameters for GetApiTransferChannelIdWs.
+type GetApiTransferChannelIdWsParamsRole string
+
 // PostApiJobsJSONRequestBody defines body for PostApiJobs for application/json ContentType.
 type PostApiJobsJSONRequestBody = JobCreateRequest
 
 // PostApiJobsClaimJSONRequestBody defines body for PostApiJobsClaim for application/json ContentType.
 type PostApiJobsClaimJSONRequestBody = JobClaimRequest
 
+// PostApiJobsJobIdHeartbeatJSONRequestBody defines body for PostApiJobsJobIdHeartbeat for application/json ContentType.
+type PostApiJobsJobIdHeartbeatJSONRequestBody = JobHeartbeatRequest
+
+// PostApiJobsJobIdPartialJSONRequestBody defines body for PostApiJobsJobIdPartial for application/json ContentType.
+type PostApiJobsJobIdPartialJSONRequestBody = JobPartialRequest
+
 // PostApiJobsJobIdPayloadJSONRequestBody defines body for PostApiJobsJobIdPayload for application/json ContentType.
 type PostApiJobsJobIdPayloadJSONRequestBody = TransferRequest
 
@@ -123,3 +411,6 @@ type PostApiJobsJobIdResultJSONRequestBody = TransferRequest
 
 // PostApiJobsJobIdStatusJSONRequestBody defines body for PostApiJobsJobIdStatus for application/json ContentType.
 type PostApiJobsJobIdStatusJSONRequestBody = JobStatusUpdateRequest
+
+// PostApiTransferJSONRequestBody defines body for PostApiTransfer for application/json ContentType.
+type PostApiTransferJSONRequestBody = TransferCreateRequest
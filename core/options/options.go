@@ -1,6 +1,9 @@
 package options
 
-import "strconv"
+import (
+	"strconv"
+	"time"
+)
 
 // String returns a plugin option value or the provided default when absent.
 func String(pluginOptions map[string]map[string]string, pluginID, key, def string) string {
@@ -62,3 +65,15 @@ func Float(pluginOptions map[string]map[string]string, pluginID, key string, def
 	}
 	return def
 }
+
+// Duration parses and returns a plugin option as time.Duration, falling back to def on error/absence.
+func Duration(pluginOptions map[string]map[string]string, pluginID, key string, def time.Duration) time.Duration {
+	v := String(pluginOptions, pluginID, key, "")
+	if v == "" {
+		return def
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return d
+	}
+	return def
+}
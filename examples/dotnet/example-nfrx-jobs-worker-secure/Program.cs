@@ -31,7 +31,7 @@ class Program
             try
             {
                 var secure = GetSecureMetadata(job);
-                await worker.UpdateStatusAsync(job.JobId, "claimed").ConfigureAwait(false);
+                await worker.UpdateStatusAsync(job.JobId, job.ClaimToken, "claimed").ConfigureAwait(false);
 
                 var payloadChannel = await worker.RequestPayloadChannelAsync(
                     job.JobId,
@@ -47,7 +47,7 @@ class Program
                 var payloadEnvelope = SecureTransfer.ParseHeaderBodyEnvelope(payloadPlaintext);
                 Console.WriteLine("payload headers: " + JsonSerializer.Serialize(payloadEnvelope.Headers));
 
-                await worker.UpdateStatusAsync(job.JobId, "running").ConfigureAwait(false);
+                await worker.UpdateStatusAsync(job.JobId, job.ClaimToken, "running").ConfigureAwait(false);
 
                 var resultEnvelope = SecureTransfer.BuildHeaderBodyEnvelope(
                     payloadEnvelope.Headers["Content-Type"],
@@ -71,7 +71,7 @@ class Program
                 {
                     File.WriteAllBytes(debugResultFile, payloadEnvelope.Body);
                 }
-                await worker.UpdateStatusAsync(job.JobId, "completed").ConfigureAwait(false);
+                await worker.UpdateStatusAsync(job.JobId, job.ClaimToken, "completed").ConfigureAwait(false);
                 Console.WriteLine($"completed secure job: {job.JobId}");
                 return 0;
             }
@@ -79,6 +79,7 @@ class Program
             {
                 await worker.UpdateStatusAsync(
                     job.JobId,
+                    job.ClaimToken,
                     "failed",
                     error: new JobError { Code = "secure_transfer_error", Message = exc.Message }).ConfigureAwait(false);
                 Console.WriteLine("error: " + exc.Message);
@@ -365,12 +365,13 @@ public sealed class NfrxJobsWorker : IDisposable
 
     public async Task UpdateStatusAsync(
         string jobId,
+        string claimToken,
         string state,
         Dictionary<string, object>? progress = null,
         JobError? error = null,
         CancellationToken cancellationToken = default)
     {
-        var body = new Dictionary<string, object> { { "state", state } };
+        var body = new Dictionary<string, object> { { "claim_token", claimToken }, { "state", state } };
         if (progress != null)
         {
             body["progress"] = progress;
@@ -425,7 +426,7 @@ public sealed class NfrxJobsWorker : IDisposable
             return false;
         }
         var jobId = job.JobId;
-        await UpdateStatusAsync(jobId, "claimed", cancellationToken: cancellationToken).ConfigureAwait(false);
+        await UpdateStatusAsync(jobId, job.ClaimToken, "claimed", cancellationToken: cancellationToken).ConfigureAwait(false);
         if (onStatus != null)
         {
             await onStatus("claimed", null).ConfigureAwait(false);
@@ -439,7 +440,7 @@ public sealed class NfrxJobsWorker : IDisposable
         var payload = await ReadPayloadAsync(payloadChannel.ReaderUrl ?? payloadChannel.ChannelId, cancellationToken)
             .ConfigureAwait(false);
 
-        await UpdateStatusAsync(jobId, "running", cancellationToken: cancellationToken).ConfigureAwait(false);
+        await UpdateStatusAsync(jobId, job.ClaimToken, "running", cancellationToken: cancellationToken).ConfigureAwait(false);
         if (onStatus != null)
         {
             await onStatus("running", null).ConfigureAwait(false);
@@ -449,7 +450,7 @@ public sealed class NfrxJobsWorker : IDisposable
         if (!string.IsNullOrWhiteSpace(errorMessage))
         {
             var error = new JobError { Code = "handler_error", Message = errorMessage };
-            await UpdateStatusAsync(jobId, "failed", error: error, cancellationToken: cancellationToken).ConfigureAwait(false);
+            await UpdateStatusAsync(jobId, job.ClaimToken, "failed", error: error, cancellationToken: cancellationToken).ConfigureAwait(false);
             if (onStatus != null)
             {
                 await onStatus("failed", error).ConfigureAwait(false);
@@ -465,7 +466,7 @@ public sealed class NfrxJobsWorker : IDisposable
         await WriteResultAsync(resultChannel.WriterUrl ?? resultChannel.ChannelId, result, "application/octet-stream", cancellationToken)
             .ConfigureAwait(false);
 
-        await UpdateStatusAsync(jobId, "completed", cancellationToken: cancellationToken).ConfigureAwait(false);
+        await UpdateStatusAsync(jobId, job.ClaimToken, "completed", cancellationToken: cancellationToken).ConfigureAwait(false);
         if (onStatus != null)
         {
             await onStatus("completed", null).ConfigureAwait(false);
@@ -513,6 +514,8 @@ public sealed class JobCreateResponse
 
 public sealed class JobClaimResponse
 {
+    [JsonPropertyName("claim_token")]
+    public string ClaimToken { get; set; } = string.Empty;
     [JsonPropertyName("claimed_worker_group")]
     public string? ClaimedWorkerGroup { get; set; }
     [JsonPropertyName("claimed_worker_id")]
@@ -25,6 +25,7 @@ class AuthConfig:
 class JobClaim(TypedDict):
     job_id: str
     type: str
+    claim_token: str
     metadata: Dict[str, Any]
 
 
@@ -105,8 +106,10 @@ class NfrxJobsWorker:
         resp.raise_for_status()
         return resp.json()
 
-    async def update_status(self, job_id: str, state: str, payload: Optional[Dict[str, Any]] = None) -> None:
-        body = {"state": state}
+    async def update_status(
+        self, job_id: str, claim_token: str, state: str, payload: Optional[Dict[str, Any]] = None
+    ) -> None:
+        body = {"claim_token": claim_token, "state": state}
         if payload:
             body.update(payload)
         resp = await self._client.post(
@@ -142,9 +145,10 @@ class NfrxJobsWorker:
             print("no job available")
             return False
         job_id = job.get("job_id") or job.get("id")
+        claim_token = job.get("claim_token", "")
         print(f"claimed job: {job_id}")
         try:
-            await self.update_status(job_id, "claimed")
+            await self.update_status(job_id, claim_token, "claimed")
             if on_status:
                 await on_status("claimed", None)
 
@@ -155,7 +159,7 @@ class NfrxJobsWorker:
                 await on_status("awaiting_payload", payload_channel)
             payload_bytes = await self.read_payload(payload_url)
 
-            await self.update_status(job_id, "running")
+            await self.update_status(job_id, claim_token, "running")
             if on_status:
                 await on_status("running", None)
             result_bytes, error_message = await handler(job, payload_bytes)
@@ -163,6 +167,7 @@ class NfrxJobsWorker:
             if error_message:
                 await self.update_status(
                     job_id,
+                    claim_token,
                     "failed",
                     payload={"error": {"code": "handler_error", "message": error_message}},
                 )
@@ -176,13 +181,14 @@ class NfrxJobsWorker:
                 await on_status("awaiting_result", result_channel)
             await self.write_result(result_url, result_bytes)
 
-            await self.update_status(job_id, "completed")
+            await self.update_status(job_id, claim_token, "completed")
             if on_status:
                 await on_status("completed", None)
             return True
         except httpx.HTTPError as exc:
             await self.update_status(
                 job_id,
+                claim_token,
                 "failed",
                 payload={"error": {"code": "http_error", "message": str(exc)}},
             )
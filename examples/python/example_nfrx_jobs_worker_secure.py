@@ -83,8 +83,10 @@ class NfrxJobsWorker:
         resp.raise_for_status()
         return resp.json()
 
-    async def update_status(self, job_id: str, state: str, payload: Optional[Dict[str, Any]] = None) -> None:
-        body = {"state": state}
+    async def update_status(
+        self, job_id: str, claim_token: str, state: str, payload: Optional[Dict[str, Any]] = None
+    ) -> None:
+        body = {"claim_token": claim_token, "state": state}
         if payload:
             body.update(payload)
         resp = await self._client.post(f"/api/jobs/{job_id}/status", json=body, headers=self._headers())
@@ -145,10 +147,11 @@ async def run(args: argparse.Namespace) -> int:
                 continue
 
             job_id = job["job_id"]
+            claim_token = job.get("claim_token", "")
             print(f"claimed secure job: {job_id}")
             try:
                 secure = _secure_metadata(job)
-                await worker.update_status(job_id, "claimed")
+                await worker.update_status(job_id, claim_token, "claimed")
 
                 payload_properties = {
                     "encryption_scheme": SCHEME,
@@ -164,7 +167,7 @@ async def run(args: argparse.Namespace) -> int:
                 headers, body = parse_header_body_envelope(plaintext)
                 print("payload headers:", json.dumps(headers, indent=2))
 
-                await worker.update_status(job_id, "running")
+                await worker.update_status(job_id, claim_token, "running")
 
                 result_envelope = build_header_body_envelope(
                     headers["content-type"],
@@ -183,12 +186,13 @@ async def run(args: argparse.Namespace) -> int:
                     raise ValueError("result channel missing writer_url")
                 await worker.write_result(result_url, result_ciphertext)
                 _write_result(args.debug_result_file, body)
-                await worker.update_status(job_id, "completed")
+                await worker.update_status(job_id, claim_token, "completed")
                 print(f"completed secure job: {job_id}")
                 return 0
             except Exception as exc:
                 await worker.update_status(
                     job_id,
+                    claim_token,
                     "failed",
                     payload={"error": {"code": "secure_transfer_error", "message": str(exc)}},
                 )
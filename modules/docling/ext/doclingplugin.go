@@ -52,6 +52,10 @@ func (p *Plugin) RegisterRoutes(r spi.Router) {
 	})
 }
 
+// TransferRelay lets job transfers, such as documents to convert, reach
+// docling agents over their connection.
+func (p *Plugin) TransferRelay() spi.TransferRelay { return p.reg }
+
 func (p *Plugin) RegisterMetrics(reg spi.MetricsRegistry) { basemetrics.Register(reg) }
 
 func (p *Plugin) RegisterState(reg spi.StateRegistry) {
@@ -131,6 +135,7 @@ func (p *Plugin) RegisterState(reg spi.StateRegistry) {
 }
 
 var _ spi.Plugin = (*Plugin)(nil)
+var _ spi.TransferRelayProvider = (*Plugin)(nil)
 
 func New(state spi.ServerState, version, sha, date string, srvOpts spi.Options, authMW spi.Middleware) *Plugin {
 	reg := baseworker.NewRegistry()
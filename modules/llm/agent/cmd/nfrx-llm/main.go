@@ -112,39 +112,49 @@ func main() {
 		modelsURL := normalizedBase + "/models"
 		client := openaiclient.New(normalizedBase, cfg.CompletionAPIKey)
 		probe = func(pctx context.Context) (wp.ProbeResult, error) {
-			models, err := client.Models(pctx)
+			models, limits, err := client.ModelsWithLimits(pctx)
 			if err != nil {
 				return wp.ProbeResult{Ready: false}, fmt.Errorf("probe %s: %w", modelsURL, err)
 			}
-			return wp.ProbeResult{Ready: true, Models: models, MaxConcurrency: cfg.MaxConcurrency}, nil
+			return wp.ProbeResult{Ready: true, Models: models, MaxConcurrency: cfg.MaxConcurrency, AgentConfig: modelLimitsAgentConfig(limits)}, nil
 		}
 	case "ollama":
 		base := parentBase(normalizedBase)
 		tagsURL := base + "/api/tags"
 		client := ollama.New(base)
+		limits := &ollamaLimitCache{show: client.Show}
 		probe = func(pctx context.Context) (wp.ProbeResult, error) {
 			models, err := client.Health(pctx)
 			if err != nil {
 				return wp.ProbeResult{Ready: false}, fmt.Errorf("probe %s: %w", tagsURL, err)
 			}
-			return wp.ProbeResult{Ready: true, Models: models, MaxConcurrency: cfg.MaxConcurrency}, nil
+			return wp.ProbeResult{Ready: true, Models: models, MaxConcurrency: cfg.MaxConcurrency, AgentConfig: modelLimitsAgentConfig(limits.For(pctx, models))}, nil
 		}
 	default:
 		logx.Log.Warn().Str("api_style", cfg.APIStyle).Msg("unknown api style; defaulting to openai")
 		modelsURL := normalizedBase + "/models"
 		client := openaiclient.New(normalizedBase, cfg.CompletionAPIKey)
 		probe = func(pctx context.Context) (wp.ProbeResult, error) {
-			models, err := client.Models(pctx)
+			models, limits, err := client.ModelsWithLimits(pctx)
 			if err != nil {
 				return wp.ProbeResult{Ready: false}, fmt.Errorf("probe %s: %w", modelsURL, err)
 			}
-			return wp.ProbeResult{Ready: true, Models: models, MaxConcurrency: cfg.MaxConcurrency}, nil
+			return wp.ProbeResult{Ready: true, Models: models, MaxConcurrency: cfg.MaxConcurrency, AgentConfig: modelLimitsAgentConfig(limits)}, nil
 		}
 	}
 	agentCfg := map[string]string{}
 	if cfg.EmbeddingBatchSize > 0 {
 		agentCfg["embedding_batch_size"] = strconv.Itoa(cfg.EmbeddingBatchSize)
 	}
+	if cfg.CostPer1KInputTokens > 0 {
+		agentCfg["cost_per_1k_input_tokens"] = strconv.FormatFloat(cfg.CostPer1KInputTokens, 'f', -1, 64)
+	}
+	if cfg.CostPer1KOutputTokens > 0 {
+		agentCfg["cost_per_1k_output_tokens"] = strconv.FormatFloat(cfg.CostPer1KOutputTokens, 'f', -1, 64)
+	}
+	if cfg.PowerWatts > 0 {
+		agentCfg["power_watts"] = strconv.FormatFloat(cfg.PowerWatts, 'f', -1, 64)
+	}
 	for k, v := range backendAgentConfig(backendInfoFromOverride(cfg.CompletionAgentVersion)) {
 		agentCfg[k] = v
 	}
@@ -193,7 +203,12 @@ func wrapProbeWithBackendInfo(probe wp.ProbeFunc, baseURL, apiKey string) wp.Pro
 		}
 		if shouldDiscoverBackendInfo(wp.GetAgentConfig()) {
 			if cfg := backendAgentConfig(discoverBackendInfo(ctx, baseURL, apiKey)); len(cfg) > 0 {
-				res.AgentConfig = cfg
+				if res.AgentConfig == nil {
+					res.AgentConfig = map[string]string{}
+				}
+				for k, v := range cfg {
+					res.AgentConfig[k] = v
+				}
 			}
 		}
 		return res, nil
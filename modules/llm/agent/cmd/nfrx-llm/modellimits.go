@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gaspardpetit/nfrx/core/logx"
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
+)
+
+const modelLimitsAgentConfigKey = "model_limits"
+
+// modelLimitsAgentConfig encodes per-model limits for the server's admission
+// control. It returns nil when no limits are known.
+func modelLimitsAgentConfig(limits map[string]spi.ModelLimits) map[string]string {
+	if len(limits) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(limits)
+	if err != nil {
+		return nil
+	}
+	return map[string]string{modelLimitsAgentConfigKey: string(b)}
+}
+
+// ollamaLimitCache remembers /api/show results so each model is probed once.
+type ollamaLimitCache struct {
+	show   func(ctx context.Context, model string) (spi.ModelLimits, error)
+	limits map[string]spi.ModelLimits
+}
+
+// For returns the known limits of models, probing those not seen before.
+// Models whose probe fails are retried on the next call.
+func (c *ollamaLimitCache) For(ctx context.Context, models []string) map[string]spi.ModelLimits {
+	if c.limits == nil {
+		c.limits = map[string]spi.ModelLimits{}
+	}
+	out := map[string]spi.ModelLimits{}
+	for _, m := range models {
+		l, ok := c.limits[m]
+		if !ok {
+			var err error
+			l, err = c.show(ctx, m)
+			if err != nil {
+				logx.Log.Debug().Err(err).Str("model", m).Msg("model limits probe failed")
+				continue
+			}
+			c.limits[m] = l
+		}
+		if l != (spi.ModelLimits{}) {
+			out[m] = l
+		}
+	}
+	return out
+}
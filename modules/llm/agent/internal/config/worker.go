@@ -24,6 +24,9 @@ type WorkerConfig struct {
 	APIStyle               string
 	MaxConcurrency         int
 	EmbeddingBatchSize     int
+	CostPer1KInputTokens   float64
+	CostPer1KOutputTokens  float64
+	PowerWatts             float64
 	ClientID               string
 	ClientName             string
 	StatusAddr             string
@@ -60,6 +63,15 @@ func (c *WorkerConfig) BindFlags() {
 	if v, err := strconv.Atoi(eb); err == nil {
 		c.EmbeddingBatchSize = v
 	}
+	if v, err := strconv.ParseFloat(commoncfg.GetEnv("COST_PER_1K_INPUT_TOKENS", "0"), 64); err == nil {
+		c.CostPer1KInputTokens = v
+	}
+	if v, err := strconv.ParseFloat(commoncfg.GetEnv("COST_PER_1K_OUTPUT_TOKENS", "0"), 64); err == nil {
+		c.CostPer1KOutputTokens = v
+	}
+	if v, err := strconv.ParseFloat(commoncfg.GetEnv("POWER_WATTS", "0"), 64); err == nil {
+		c.PowerWatts = v
+	}
 	c.ClientID = commoncfg.GetEnv("CLIENT_ID", "")
 	c.StatusAddr = commoncfg.GetEnv("STATUS_ADDR", "")
 	mp := commoncfg.GetEnv("METRICS_PORT", "")
@@ -100,6 +112,9 @@ func (c *WorkerConfig) BindFlags() {
 	flag.StringVar(&c.APIStyle, "api-style", c.APIStyle, "backend API style for model discovery (openai or ollama)")
 	flag.IntVar(&c.MaxConcurrency, "max-concurrency", c.MaxConcurrency, "maximum number of jobs processed concurrently")
 	flag.IntVar(&c.EmbeddingBatchSize, "embedding-batch-size", c.EmbeddingBatchSize, "ideal embedding batch size for embeddings")
+	flag.Float64Var(&c.CostPer1KInputTokens, "cost-per-1k-input-tokens", c.CostPer1KInputTokens, "declared price per 1k input tokens used for cost-aware routing")
+	flag.Float64Var(&c.CostPer1KOutputTokens, "cost-per-1k-output-tokens", c.CostPer1KOutputTokens, "declared price per 1k output tokens used for cost-aware routing")
+	flag.Float64Var(&c.PowerWatts, "power-watts", c.PowerWatts, "declared power draw in watts while processing, used for energy accounting")
 	flag.StringVar(&c.ClientID, "client-id", c.ClientID, "client identifier; randomly generated if omitted")
 	flag.StringVar(&c.ClientName, "client-name", c.ClientName, "client display name shown in logs and status")
 	flag.StringVar(&c.StatusAddr, "status-addr", c.StatusAddr, "local status HTTP listen address (enables /status; e.g. 127.0.0.1:4555)")
@@ -8,9 +8,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	llmcommon "github.com/gaspardpetit/nfrx/modules/llm/common"
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
 )
 
 // Client is a tiny HTTP client for talking to local Ollama.
@@ -61,6 +63,60 @@ func (c *Client) Health(ctx context.Context) ([]string, error) {
 	return c.Tags(ctx)
 }
 
+// Show fetches model metadata from /api/show and extracts its limits. A
+// num_ctx or num_predict parameter configured on the model takes precedence
+// over the architecture's trained context length.
+func (c *Client) Show(ctx context.Context, model string) (spi.ModelLimits, error) {
+	b, _ := json.Marshal(map[string]string{"model": model})
+	base := strings.TrimRight(c.BaseURL, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/api/show", bytes.NewReader(b))
+	if err != nil {
+		return spi.ModelLimits{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return spi.ModelLimits{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return spi.ModelLimits{}, fmt.Errorf("show status %s body=%q", resp.Status, summarizeBody(body, 256))
+	}
+	var v struct {
+		Parameters string         `json:"parameters"`
+		ModelInfo  map[string]any `json:"model_info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return spi.ModelLimits{}, err
+	}
+	var l spi.ModelLimits
+	for k, val := range v.ModelInfo {
+		if n, ok := val.(float64); ok && strings.HasSuffix(k, ".context_length") {
+			l.ContextLength = int(n)
+		}
+	}
+	for _, line := range strings.Split(v.Parameters, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n <= 0 {
+			continue
+		}
+		switch fields[0] {
+		case "num_ctx":
+			l.ContextLength = n
+		case "num_predict":
+			l.MaxOutputTokens = n
+		}
+	}
+	return l, nil
+}
+
 func (c *Client) GenerateStream(ctx context.Context, req llmcommon.GenerateRequest) (io.ReadCloser, error) {
 	b, _ := json.Marshal(req)
 	base := strings.TrimRight(c.BaseURL, "/")
@@ -34,3 +34,25 @@ func TestTags(t *testing.T) {
 		t.Fatalf("unexpected models: %q", got)
 	}
 }
+
+func TestShowPrefersConfiguredNumCtx(t *testing.T) {
+	t.Parallel()
+
+	const payload = `{"parameters":"num_ctx                        8192\nstop                           \"<|im_end|>\"\nnum_predict                    512","model_info":{"general.architecture":"llama","llama.context_length":131072}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/show" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(payload))
+	}))
+	t.Cleanup(srv.Close)
+
+	l, err := New(srv.URL).Show(context.Background(), "llama3")
+	if err != nil {
+		t.Fatalf("Show error: %v", err)
+	}
+	if l.ContextLength != 8192 || l.MaxOutputTokens != 512 {
+		t.Fatalf("unexpected limits %+v", l)
+	}
+}
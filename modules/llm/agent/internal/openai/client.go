@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
 )
 
 // Client is a tiny HTTP client for talking to OpenAI-compatible endpoints.
@@ -25,40 +27,60 @@ func New(base, apiKey string) *Client {
 }
 
 func (c *Client) Models(ctx context.Context) ([]string, error) {
+	models, _, err := c.ModelsWithLimits(ctx)
+	return models, err
+}
+
+// ModelsWithLimits lists models along with any context window or output limits
+// the backend reports in its model metadata (e.g. vLLM's max_model_len).
+// Models without reported limits are absent from the returned map.
+func (c *Client) ModelsWithLimits(ctx context.Context) ([]string, map[string]spi.ModelLimits, error) {
 	base := strings.TrimRight(c.BaseURL, "/")
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/models", nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if c.APIKey != "" {
 		req.Header.Set("Authorization", "Bearer "+c.APIKey)
 	}
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
-		return nil, fmt.Errorf("models status %s body=%q", resp.Status, summarizeBody(body, 256))
+		return nil, nil, fmt.Errorf("models status %s body=%q", resp.Status, summarizeBody(body, 256))
 	}
 	var v struct {
 		Data []struct {
-			ID string `json:"id"`
+			ID              string `json:"id"`
+			MaxModelLen     int    `json:"max_model_len"`
+			ContextLength   int    `json:"context_length"`
+			MaxOutputTokens int    `json:"max_output_tokens"`
 		} `json:"data"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	var models []string
+	limits := map[string]spi.ModelLimits{}
 	for _, m := range v.Data {
-		if m.ID != "" {
-			models = append(models, m.ID)
+		if m.ID == "" {
+			continue
+		}
+		models = append(models, m.ID)
+		l := spi.ModelLimits{ContextLength: m.MaxModelLen, MaxOutputTokens: m.MaxOutputTokens}
+		if l.ContextLength == 0 {
+			l.ContextLength = m.ContextLength
+		}
+		if l != (spi.ModelLimits{}) {
+			limits[m.ID] = l
 		}
 	}
-	return models, nil
+	return models, limits, nil
 }
 
 func summarizeBody(body []byte, limit int) string {
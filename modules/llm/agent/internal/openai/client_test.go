@@ -37,3 +37,28 @@ func TestModels(t *testing.T) {
 		t.Fatalf("unexpected models: %q", got)
 	}
 }
+
+func TestModelsWithLimitsReadsMaxModelLen(t *testing.T) {
+	t.Parallel()
+
+	const payload = `{"object":"list","data":[{"id":"qwen","object":"model","max_model_len":32768},{"id":"plain","object":"model"}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(payload))
+	}))
+	t.Cleanup(srv.Close)
+
+	models, limits, err := New(srv.URL+"/v1", "").ModelsWithLimits(context.Background())
+	if err != nil {
+		t.Fatalf("ModelsWithLimits error: %v", err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("unexpected models: %v", models)
+	}
+	if got := limits["qwen"].ContextLength; got != 32768 {
+		t.Fatalf("unexpected qwen context length %d", got)
+	}
+	if _, ok := limits["plain"]; ok {
+		t.Fatalf("expected no limits for plain")
+	}
+}
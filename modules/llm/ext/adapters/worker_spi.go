@@ -19,6 +19,10 @@ func (w WorkerRef) RemoveJob(id string)                   { w.w.RemoveJob(id) }
 func (w WorkerRef) LastHeartbeat() time.Time              { return w.w.LastHeartbeat }
 func (w WorkerRef) PreferredBatchSize() int               { return w.w.PreferredBatchSize }
 func (w WorkerRef) InFlight() int                         { return w.w.InFlight }
+func (w WorkerRef) Pricing() spi.WorkerPricing            { return w.w.PricingValue() }
+func (w WorkerRef) ModelLimits(model string) (spi.ModelLimits, bool) {
+	return w.w.ModelLimitsFor(model)
+}
 
 type WorkerRegistry struct {
 	r         *baseworker.Registry
@@ -34,8 +38,37 @@ func (r *WorkerRegistry) WorkersForLabel(model string) []spi.WorkerRef {
 	}
 	return res
 }
+
+// WorkersServing returns every connected worker serving model, busy or not.
+func (r *WorkerRegistry) WorkersServing(model string) []spi.WorkerRef {
+	ws := r.r.WorkersServing(model)
+	res := make([]spi.WorkerRef, 0, len(ws))
+	for _, w := range ws {
+		res = append(res, WorkerRef{w})
+	}
+	return res
+}
+
 func (r *WorkerRegistry) IncInFlight(id string) { r.r.IncInFlight(id) }
 func (r *WorkerRegistry) DecInFlight(id string) { r.r.DecInFlight(id) }
+
+// ObserveLatency records a time-to-first-byte sample used by cost-aware scheduling.
+func (r *WorkerRegistry) ObserveLatency(id, model string, d time.Duration) {
+	r.r.ObserveLatency(id, model, d)
+}
+
+// ObserveFailure records a failed request used by adaptive concurrency tuning.
+func (r *WorkerRegistry) ObserveFailure(id, model string) { r.r.ObserveFailure(id, model) }
+
+// TotalCapacity sums the effective concurrency limits of all connected workers.
+func (r *WorkerRegistry) TotalCapacity() int {
+	n := 0
+	for _, w := range r.r.Snapshot() {
+		n += w.EffectiveConcurrency()
+	}
+	return n
+}
+
 func (r *WorkerRegistry) AggregatedModels() []spi.ModelInfo {
 	ws := r.r.Snapshot()
 	ownersMap := make(map[string][]string)
@@ -136,12 +169,31 @@ func (s Scheduler) PickWorker(model string) (spi.WorkerRef, error) {
 	return WorkerRef{w}, nil
 }
 
+type sizedScheduler interface {
+	PickWorkerFor(model string, size spi.RequestSize) (*baseworker.Worker, error)
+}
+
+// PickWorkerFor excludes workers whose model limits cannot fit size when the
+// underlying scheduler supports it.
+func (s Scheduler) PickWorkerFor(model string, size spi.RequestSize) (spi.WorkerRef, error) {
+	ss, ok := s.s.(sizedScheduler)
+	if !ok {
+		return s.PickWorker(model)
+	}
+	w, err := ss.PickWorkerFor(model, size)
+	if err != nil {
+		return nil, err
+	}
+	return WorkerRef{w}, nil
+}
+
 type Metrics struct{ m *baseworker.MetricsRegistry }
 
 func (m Metrics) RecordJobStart(id string) { m.m.RecordJobStart(id) }
 func (m Metrics) RecordJobEnd(id, model string, dur time.Duration, tokensIn, tokensOut, embeddings uint64, success bool, errMsg string) {
 	m.m.RecordJobEnd(id, model, dur, tokensIn, tokensOut, embeddings, success, errMsg)
 }
+func (m Metrics) RecordJobAbandoned(id string) { m.m.RecordJobAbandoned(id) }
 func (m Metrics) SetWorkerStatus(id string, status spi.WorkerStatus) {
 	m.m.SetWorkerStatus(id, baseworker.WorkerStatus(status))
 }
@@ -163,3 +215,8 @@ func NewMetrics(m *baseworker.MetricsRegistry) Metrics { return Metrics{m} }
 func (m Metrics) RecordWorkerTokens(workerID, kind string, n uint64) {
 	m.m.AddWorkerTokens(workerID, kind, n)
 }
+
+// RecordWorkerCost accumulates the computed price and energy of a completed request.
+func (m Metrics) RecordWorkerCost(workerID string, cost, energyWh float64) {
+	m.m.AddWorkerCost(workerID, cost, energyWh)
+}
@@ -32,6 +32,16 @@ func Descriptor() spi.PluginDescriptor {
 				Example:     "0",
 				Description: "Maximum queued chat requests (0 disables)",
 			},
+			{
+				ID:          "worker_queue_size",
+				Flag:        "--llm-worker-queue-size",
+				Env:         "LLM_WORKER_QUEUE_SIZE",
+				YAML:        "plugin_options.llm.worker_queue_size",
+				Type:        spi.ArgInt,
+				Default:     "10",
+				Example:     "0",
+				Description: "Maximum queued requests per worker on /id/{id}/v1 routes (0 disables)",
+			},
 			{
 				ID:          "queue_update_seconds",
 				Flag:        "--llm-queue-update-seconds",
@@ -42,6 +52,126 @@ func Descriptor() spi.PluginDescriptor {
 				Example:     "5",
 				Description: "Interval in seconds for queued status SSE (0 disables)",
 			},
+			{
+				ID:          "cost_aware",
+				Flag:        "--llm-cost-aware",
+				Env:         "LLM_COST_AWARE",
+				YAML:        "plugin_options.llm.cost_aware",
+				Type:        spi.ArgBool,
+				Default:     "false",
+				Example:     "true",
+				Description: "Prefer the cheapest of equally scored workers by declared price, then power draw",
+			},
+			{
+				ID:          "latency_target_ms",
+				Flag:        "--llm-latency-target-ms",
+				Env:         "LLM_LATENCY_TARGET_MS",
+				YAML:        "plugin_options.llm.latency_target_ms",
+				Type:        spi.ArgInt,
+				Default:     "0",
+				Example:     "1500",
+				Description: "Time-to-first-byte above which a cheaper worker is skipped (0 disables)",
+			},
+			{
+				ID:          "cost_budget",
+				Flag:        "--llm-cost-budget",
+				Env:         "LLM_COST_BUDGET",
+				YAML:        "plugin_options.llm.cost_budget",
+				Type:        spi.ArgNumber,
+				Default:     "0",
+				Example:     "5.00",
+				Description: "Maximum computed cost per API key in each budget window (0 disables)",
+			},
+			{
+				ID:          "cost_budget_window",
+				Flag:        "--llm-cost-budget-window",
+				Env:         "LLM_COST_BUDGET_WINDOW",
+				YAML:        "plugin_options.llm.cost_budget_window",
+				Type:        spi.ArgDuration,
+				Default:     "24h",
+				Example:     "720h",
+				Description: "Window after which per-key spend resets",
+			},
+			{
+				ID:          "hedge_percentile",
+				Flag:        "--llm-hedge-percentile",
+				Env:         "LLM_HEDGE_PERCENTILE",
+				YAML:        "plugin_options.llm.hedge_percentile",
+				Type:        spi.ArgNumber,
+				Default:     "0",
+				Example:     "95",
+				Description: "Percentile of recent time-to-first-byte after which a request is duplicated to a second worker (0 disables hedging)",
+			},
+			{
+				ID:          "hedge_budget",
+				Flag:        "--llm-hedge-budget",
+				Env:         "LLM_HEDGE_BUDGET",
+				YAML:        "plugin_options.llm.hedge_budget",
+				Type:        spi.ArgNumber,
+				Default:     "0.1",
+				Example:     "0.05",
+				Description: "Maximum fraction of total worker capacity used by concurrent hedged requests",
+			},
+			{
+				ID:          "hedge_max_prompt_tokens",
+				Flag:        "--llm-hedge-max-prompt-tokens",
+				Env:         "LLM_HEDGE_MAX_PROMPT_TOKENS",
+				YAML:        "plugin_options.llm.hedge_max_prompt_tokens",
+				Type:        spi.ArgInt,
+				Default:     "1024",
+				Example:     "512",
+				Description: "Largest estimated prompt eligible for hedging (0 allows any size)",
+			},
+			{
+				ID:          "adaptive_concurrency",
+				Flag:        "--llm-adaptive-concurrency",
+				Env:         "LLM_ADAPTIVE_CONCURRENCY",
+				YAML:        "plugin_options.llm.adaptive_concurrency",
+				Type:        spi.ArgBool,
+				Default:     "false",
+				Example:     "true",
+				Description: "Tune each worker's effective concurrency from observed latency and failures, up to its declared maximum",
+			},
+			{
+				ID:          "adaptive_latency_tolerance",
+				Flag:        "--llm-adaptive-latency-tolerance",
+				Env:         "LLM_ADAPTIVE_LATENCY_TOLERANCE",
+				YAML:        "plugin_options.llm.adaptive_latency_tolerance",
+				Type:        spi.ArgNumber,
+				Default:     "2",
+				Example:     "1.5",
+				Description: "Multiple of a worker's baseline time-to-first-byte above which adaptive concurrency backs off",
+			},
+			{
+				ID:          "batch_concurrency",
+				Flag:        "--llm-batch-concurrency",
+				Env:         "LLM_BATCH_CONCURRENCY",
+				YAML:        "plugin_options.llm.batch_concurrency",
+				Type:        spi.ArgInt,
+				Default:     "4",
+				Example:     "16",
+				Description: "Maximum requests of one batch in flight at a time",
+			},
+			{
+				ID:          "batch_max_file_bytes",
+				Flag:        "--llm-batch-max-file-bytes",
+				Env:         "LLM_BATCH_MAX_FILE_BYTES",
+				YAML:        "plugin_options.llm.batch_max_file_bytes",
+				Type:        spi.ArgInt,
+				Default:     "104857600",
+				Example:     "10485760",
+				Description: "Largest batch input file accepted by /v1/files (0 means no limit)",
+			},
+			{
+				ID:          "batch_retention",
+				Flag:        "--llm-batch-retention",
+				Env:         "LLM_BATCH_RETENTION",
+				YAML:        "plugin_options.llm.batch_retention",
+				Type:        spi.ArgDuration,
+				Default:     "24h",
+				Example:     "72h",
+				Description: "How long batch files and finished batches are kept in memory",
+			},
 		},
 	}
 	// Append base worker options (shared across worker-style plugins)
@@ -26,6 +26,10 @@ type Plugin struct {
 	srvState spi.ServerState
 	authMW   spi.Middleware
 	srvOpts  spi.Options
+
+	// batches runs /v1/batches; jobs tracks them once the server sets it.
+	batches *openai.Batches
+	jobs    spi.JobTracker
 }
 
 // RegisterRoutes wires the HTTP endpoints.
@@ -56,8 +60,18 @@ func (p *Plugin) RegisterRoutes(r spi.Router) {
 		// Adapt shared options to OpenAI-specific options
 		mpe := opt.Int(p.srvOpts.PluginOptions, p.ID(), "max_parallel_embeddings", 8)
 		qsz := opt.Int(p.srvOpts.PluginOptions, p.ID(), "queue_size", 100)
+		wqsz := opt.Int(p.srvOpts.PluginOptions, p.ID(), "worker_queue_size", 10)
 		qus := opt.Int(p.srvOpts.PluginOptions, p.ID(), "queue_update_seconds", 10)
-		oa := openai.Options{RequestTimeout: p.srvOpts.RequestTimeout, MaxParallelEmbeddings: mpe, QueueSize: qsz, QueueUpdateSeconds: qus}
+		oa := openai.Options{RequestTimeout: p.srvOpts.RequestTimeout, MaxParallelEmbeddings: mpe, QueueSize: qsz, WorkerQueueSize: wqsz, QueueUpdateSeconds: qus}
+		oa.BatchConcurrency = opt.Int(p.srvOpts.PluginOptions, p.ID(), "batch_concurrency", 4)
+		oa.BatchMaxFileBytes = opt.Int64(p.srvOpts.PluginOptions, p.ID(), "batch_max_file_bytes", 100<<20)
+		oa.BatchRetention = opt.Duration(p.srvOpts.PluginOptions, p.ID(), "batch_retention", 24*time.Hour)
+		if budget := opt.Float(p.srvOpts.PluginOptions, p.ID(), "cost_budget", 0); budget > 0 {
+			oa.Budget = openai.NewCostBudget(budget, opt.Duration(p.srvOpts.PluginOptions, p.ID(), "cost_budget_window", 24*time.Hour))
+		}
+		if pct := opt.Float(p.srvOpts.PluginOptions, p.ID(), "hedge_percentile", 0); pct > 0 {
+			oa.Hedge = openai.NewHedgePolicy(pct, opt.Float(p.srvOpts.PluginOptions, p.ID(), "hedge_budget", 0.1), opt.Int(p.srvOpts.PluginOptions, p.ID(), "hedge_max_prompt_tokens", 1024))
+		}
 		// Adapt internal control plane to SPI
 		wr := llmadapt.NewWorkerRegistry(p.reg)
 		sch := llmadapt.NewScheduler(p.sch)
@@ -70,15 +84,37 @@ func (p *Plugin) RegisterRoutes(r spi.Router) {
 			// still set capacity so UI reflects disabled queue
 			p.mxreg.SetSchedulerQueueCapacity(0)
 		}
+		// Per-worker queues for targeted requests; their lengths populate queue_len in worker state
+		var wq *openai.WorkerQueues
+		if wqsz > 0 {
+			wq = openai.NewWorkerQueues(p.mxreg, wqsz)
+			p.reg.OnRemove(wq.Remove)
+		}
+		p.batches = openai.NewBatches(wr, sch, mx, oa, cq)
+		if p.jobs != nil {
+			p.batches.SetTracker(p.jobs)
+		}
 		g.Route("/v1", func(v1 spi.Router) {
 			openai.Mount(v1, wr, sch, mx, oa, cq)
+			openai.MountBatches(v1, p.batches)
 		})
 		g.Route("/id/{id}/v1", func(v1 spi.Router) {
-			openai.MountTargeted(v1, wr, mx, oa, cq)
+			openai.MountTargeted(v1, wr, mx, oa, wq)
 		})
 	})
 }
 
+// SetJobTracker reports batches as jobs of t.
+func (p *Plugin) SetJobTracker(t spi.JobTracker) {
+	p.jobs = t
+	if p.batches != nil {
+		p.batches.SetTracker(t)
+	}
+}
+
+// TransferRelay lets job transfers reach LLM agents over their connection.
+func (p *Plugin) TransferRelay() spi.TransferRelay { return p.reg }
+
 // Scheduler returns the plugin's scheduler.
 func (p *Plugin) Scheduler() spi.Scheduler { return llmadapt.NewScheduler(p.sch) }
 
@@ -135,7 +171,8 @@ func (p *Plugin) RegisterState(reg spi.StateRegistry) {
         var inflight=(w.inflight||0);
         var qlen=(w.queue_len||0);
         var maxc=(w.max_concurrency||1);
-        var busy=Math.min(1, (inflight + qlen) / (maxc || 1));
+        var effc=(w.effective_concurrency||maxc);
+        var busy=Math.min(1, (inflight + qlen) / (effc || 1));
         var name=(w.name || w.id || 'worker');
         var avg=(w.avg_processing_ms||0);
         var avgText=(avg && avg.toFixed)? avg.toFixed(0) : avg;
@@ -189,10 +226,11 @@ func (p *Plugin) RegisterState(reg spi.StateRegistry) {
             '<div class="metric"><div class="metric-label">Tokens Out</div><div class="metric-value">'+outputTokens+'</div></div>'+
           '</div>'+
           '<div class="worker-details">'+
-            '<div class="detail"><div class="detail-label">Inflight</div><div class="detail-value"><strong>'+inflight+'</strong> / '+maxc+'</div></div>'+
+            '<div class="detail"><div class="detail-label">Inflight</div><div class="detail-value"><strong>'+inflight+'</strong> / '+effc+(effc<maxc ? ' (max '+maxc+')' : '')+'</div></div>'+
             '<div class="detail"><div class="detail-label">Processed</div><div class="detail-value"><strong>'+processed+'</strong> total</div></div>'+
             '<div class="detail"><div class="detail-label">Avg Processing</div><div class="detail-value"><strong>'+avgText+'</strong> ms</div></div>'+
             '<div class="detail"><div class="detail-label">Embed Batch</div><div class="detail-value"><strong>'+(w.embedding_batch_size||0)+'</strong></div></div>'+
+            '<div class="detail"><div class="detail-label">Cost</div><div class="detail-value"><strong>'+(w.cost_total||0).toFixed(4)+'</strong> total</div></div>'+
           '</div>'+
           '<div class="worker-links"><a href="#" class="copy-link" data-copy-text="'+workerBaseURL+'">'+workerID+' '+(window.copyIconSVG ? window.copyIconSVG() : '')+'</a><a href="'+workerModelsURL+'">models</a></div>';
         host.appendChild(div);
@@ -211,7 +249,9 @@ func (p *Plugin) RegisterState(reg spi.StateRegistry) {
 }
 
 var _ spi.Plugin = (*Plugin)(nil)
+var _ spi.TransferRelayProvider = (*Plugin)(nil)
 var _ spi.WorkerProvider = (*Plugin)(nil)
+var _ spi.JobTrackerUser = (*Plugin)(nil)
 
 // New constructs a new LLM plugin using the common server options,
 // adapting them to the underlying OpenAI-specific configuration.
@@ -227,6 +267,18 @@ func New(
 	// Read min_score from plugin options (default 0.01) to allow alias matches by default.
 	minScore := opt.Float(srvOpts.PluginOptions, Descriptor().ID, "min_score", 0.01)
 	sch := baseworker.NewScoreSchedulerWithMinScore(reg, NewLLMScorer(), minScore)
+	// Optionally prefer the cheapest eligible worker (declared price, then power
+	// draw) unless its observed time-to-first-byte exceeds the latency target.
+	sch.CostAware = opt.Bool(srvOpts.PluginOptions, Descriptor().ID, "cost_aware", false)
+	sch.LatencyTarget = time.Duration(opt.Int(srvOpts.PluginOptions, Descriptor().ID, "latency_target_ms", 0)) * time.Millisecond
+	// Optionally tune each worker's effective concurrency from observed latency
+	// and failures, bounded by its declared MAX_CONCURRENCY.
+	if opt.Bool(srvOpts.PluginOptions, Descriptor().ID, "adaptive_concurrency", false) {
+		reg.EnableAdaptiveConcurrency(baseworker.AdaptiveConcurrency{
+			Tolerance: opt.Float(srvOpts.PluginOptions, Descriptor().ID, "adaptive_latency_tolerance", baseworker.DefaultLatencyTolerance),
+			OnChange:  mx.SetWorkerEffectiveConcurrency,
+		})
+	}
 	// Start pruning expired workers in the background
 	go func() {
 		tick := srvOpts.AgentHeartbeatInterval
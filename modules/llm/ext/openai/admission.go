@@ -0,0 +1,112 @@
+package openai
+
+import (
+	"encoding/json"
+
+	ctrl "github.com/gaspardpetit/nfrx/sdk/api/control"
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
+)
+
+// promptFields are the request fields that carry prompt text across the chat
+// completions and responses APIs.
+var promptFields = []string{"messages", "input", "prompt", "instructions", "system", "tools"}
+
+// estimatePromptTokens approximates the prompt size of a request using the
+// common ~4 characters per token heuristic over its prompt text. Bodies that
+// are not JSON objects are measured whole.
+func estimatePromptTokens(body []byte) uint64 {
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(body, &req); err != nil {
+		return uint64(len(body)+3) / 4
+	}
+	chars := 0
+	for _, f := range promptFields {
+		if raw, ok := req[f]; ok {
+			chars += textLen(raw)
+		}
+	}
+	return uint64(chars+3) / 4
+}
+
+// textLen sums the length of every string value in raw.
+func textLen(raw json.RawMessage) int {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return len(raw)
+	}
+	var walk func(any) int
+	walk = func(v any) int {
+		switch x := v.(type) {
+		case string:
+			return len(x)
+		case []any:
+			n := 0
+			for _, e := range x {
+				n += walk(e)
+			}
+			return n
+		case map[string]any:
+			n := 0
+			for _, e := range x {
+				n += walk(e)
+			}
+			return n
+		}
+		return 0
+	}
+	return walk(v)
+}
+
+// servingWorkers lists every connected worker serving model, whatever its
+// load, when reg supports it; otherwise only the workers with free capacity.
+func servingWorkers(reg spi.WorkerRegistry, model string) []spi.WorkerRef {
+	if sr, ok := reg.(spi.ServingRegistry); ok {
+		return sr.WorkersServing(model)
+	}
+	return reg.WorkersForLabel(model)
+}
+
+// anyWorkerFits reports whether at least one of workers could serve a request
+// of the given size for model. Workers that do not advertise limits for the
+// model are assumed to fit.
+func anyWorkerFits(workers []spi.WorkerRef, model string, size spi.RequestSize) bool {
+	if len(workers) == 0 {
+		return true
+	}
+	for _, w := range workers {
+		lw, ok := w.(spi.LimitedWorker)
+		if !ok {
+			return true
+		}
+		l, ok := lw.ModelLimits(model)
+		if !ok || l.Fits(size) {
+			return true
+		}
+	}
+	return false
+}
+
+// pickWorker asks sched for a worker able to fit size, falling back to plain
+// model matching for schedulers that do not support sized selection.
+func pickWorker(sched spi.Scheduler, model string, size spi.RequestSize) (spi.WorkerRef, error) {
+	if ss, ok := sched.(spi.SizedScheduler); ok {
+		return ss.PickWorkerFor(model, size)
+	}
+	return sched.PickWorker(model)
+}
+
+// modelServed reports whether a connected worker serves model, directly or
+// through an alias.
+func modelServed(reg spi.WorkerRegistry, model string) bool {
+	if _, ok := reg.AggregatedModel(model); ok {
+		return true
+	}
+	if ak, ok := ctrl.AliasKey(model); ok {
+		for _, m := range reg.AggregatedModels() {
+			if mk, ok2 := ctrl.AliasKey(m.ID); ok2 && mk == ak {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,660 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/gaspardpetit/nfrx/core/logx"
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
+)
+
+const (
+	// batchJobType is the job type under which batches are tracked.
+	batchJobType = "llm.batch"
+	// batchWindow is the only completion window offered, as with OpenAI.
+	batchWindow = "24h"
+	// batchPollInterval is how often a pending batch request checks for idle
+	// capacity.
+	batchPollInterval = 200 * time.Millisecond
+	// maxBatchErrors caps the validation errors reported for an input file.
+	maxBatchErrors = 100
+)
+
+// Batch states, as reported by the OpenAI batches API.
+const (
+	BatchValidating = "validating"
+	BatchFailed     = "failed"
+	BatchInProgress = "in_progress"
+	BatchFinalizing = "finalizing"
+	BatchCompleted  = "completed"
+	BatchExpired    = "expired"
+	BatchCancelling = "cancelling"
+	BatchCancelled  = "cancelled"
+)
+
+// Batch is the OpenAI-compatible view of a batch. JobID is an nfrx extension
+// naming the job that tracks the batch.
+type Batch struct {
+	ID               string             `json:"id"`
+	Object           string             `json:"object"`
+	Endpoint         string             `json:"endpoint"`
+	Errors           *BatchErrors       `json:"errors,omitempty"`
+	InputFileID      string             `json:"input_file_id"`
+	CompletionWindow string             `json:"completion_window"`
+	Status           string             `json:"status"`
+	OutputFileID     string             `json:"output_file_id,omitempty"`
+	ErrorFileID      string             `json:"error_file_id,omitempty"`
+	CreatedAt        int64              `json:"created_at"`
+	InProgressAt     int64              `json:"in_progress_at,omitempty"`
+	ExpiresAt        int64              `json:"expires_at,omitempty"`
+	FinalizingAt     int64              `json:"finalizing_at,omitempty"`
+	CompletedAt      int64              `json:"completed_at,omitempty"`
+	FailedAt         int64              `json:"failed_at,omitempty"`
+	ExpiredAt        int64              `json:"expired_at,omitempty"`
+	CancellingAt     int64              `json:"cancelling_at,omitempty"`
+	CancelledAt      int64              `json:"cancelled_at,omitempty"`
+	RequestCounts    BatchRequestCounts `json:"request_counts"`
+	Metadata         map[string]string  `json:"metadata,omitempty"`
+	JobID            string             `json:"job_id,omitempty"`
+}
+
+// BatchErrors lists the problems that failed a batch during validation.
+type BatchErrors struct {
+	Object string       `json:"object"`
+	Data   []BatchError `json:"data"`
+}
+
+// BatchError is one validation problem; Line is 1-based.
+type BatchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+}
+
+// BatchRequestCounts tracks the requests of a batch.
+type BatchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// batchRequest is one line of an input file.
+type batchRequest struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+
+	model string
+}
+
+// batchOutput is one line of an output or error file.
+type batchOutput struct {
+	ID       string              `json:"id"`
+	CustomID string              `json:"custom_id"`
+	Response *batchResponse      `json:"response"`
+	Error    *batchRequestFailed `json:"error"`
+}
+
+type batchResponse struct {
+	StatusCode int             `json:"status_code"`
+	RequestID  string          `json:"request_id"`
+	Body       json.RawMessage `json:"body"`
+}
+
+type batchRequestFailed struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type batch struct {
+	view     Batch
+	cancel   context.CancelFunc
+	finished time.Time
+}
+
+// Batches runs OpenAI-style batches: JSONL files of chat completion,
+// responses or embedding requests executed through the regular dispatch path.
+// Batch requests only start when no interactive request is queued and a worker
+// serving their model has a free slot, so batches soak up idle capacity
+// without delaying interactive traffic. Batches are kept in memory.
+type Batches struct {
+	files       *FileStore
+	reg         spi.WorkerRegistry
+	sched       spi.Scheduler
+	queue       *CompletionQueue
+	handlers    map[string]http.Handler
+	concurrency int
+	retention   time.Duration
+
+	mu      sync.Mutex
+	batches map[string]*batch
+	tracker spi.JobTracker
+}
+
+// NewBatches returns a batch runner dispatching through the same handlers as
+// the interactive endpoints. queue is the interactive completion queue that
+// batches yield to; it may be nil.
+func NewBatches(reg spi.WorkerRegistry, sched spi.Scheduler, metrics spi.Metrics, opts Options, queue *CompletionQueue) *Batches {
+	concurrency := opts.BatchConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	// Batch requests never wait in the interactive queue; they retry on
+	// their own when the capacity they waited for was taken.
+	return &Batches{
+		files: NewFileStore(opts.BatchMaxFileBytes, opts.BatchRetention),
+		reg:   reg,
+		sched: sched,
+		queue: queue,
+		handlers: map[string]http.Handler{
+			"/v1/chat/completions": ChatCompletionsHandler(reg, sched, metrics, opts, nil),
+			"/v1/responses":        ResponsesHandler(reg, sched, metrics, opts, nil),
+			"/v1/embeddings":       EmbeddingsHandler(reg, sched, metrics, opts.RequestTimeout, opts.MaxParallelEmbeddings),
+		},
+		concurrency: concurrency,
+		retention:   opts.BatchRetention,
+		batches:     make(map[string]*batch),
+	}
+}
+
+// Files returns the store holding batch input and output files.
+func (b *Batches) Files() *FileStore { return b.files }
+
+// SetTracker reports batches started from now on as jobs of t.
+func (b *Batches) SetTracker(t spi.JobTracker) {
+	b.mu.Lock()
+	b.tracker = t
+	b.mu.Unlock()
+}
+
+// HandleCreate handles POST /api/llm/v1/batches.
+func (b *Batches) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		InputFileID      string            `json:"input_file_id"`
+		Endpoint         string            `json:"endpoint"`
+		CompletionWindow string            `json:"completion_window"`
+		Metadata         map[string]string `json:"metadata"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if _, ok := b.handlers[body.Endpoint]; !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_endpoint"})
+		return
+	}
+	if body.CompletionWindow != batchWindow {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_completion_window"})
+		return
+	}
+	input, ok := b.files.Get(body.InputFileID)
+	if !ok || input.Purpose != "batch" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "file_not_found"})
+		return
+	}
+	now := time.Now()
+	bt := &batch{view: Batch{
+		ID:               "batch_" + uuid.NewString(),
+		Object:           "batch",
+		Endpoint:         body.Endpoint,
+		InputFileID:      input.ID,
+		CompletionWindow: batchWindow,
+		Status:           BatchValidating,
+		CreatedAt:        now.Unix(),
+		Metadata:         body.Metadata,
+	}}
+	reqs, problems := parseBatchInput(input.data, body.Endpoint)
+	b.mu.Lock()
+	b.pruneLocked(now)
+	b.batches[bt.view.ID] = bt
+	if len(problems) > 0 {
+		bt.view.Status = BatchFailed
+		bt.view.FailedAt = now.Unix()
+		bt.view.Errors = &BatchErrors{Object: "list", Data: problems}
+		bt.finished = now
+		view := bt.view
+		b.mu.Unlock()
+		logx.Log.Warn().Str("batch_id", view.ID).Int("errors", len(problems)).Msg("batch failed validation")
+		writeJSON(w, http.StatusOK, view)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	bt.cancel = cancel
+	bt.view.Status = BatchInProgress
+	bt.view.InProgressAt = now.Unix()
+	expires := now.Add(24 * time.Hour)
+	bt.view.ExpiresAt = expires.Unix()
+	bt.view.RequestCounts.Total = len(reqs)
+	if b.tracker != nil {
+		id := bt.view.ID
+		bt.view.JobID = b.tracker.Track(batchJobType, map[string]any{
+			"batch_id":      id,
+			"endpoint":      body.Endpoint,
+			"input_file_id": input.ID,
+		}, func() { b.cancelBatch(id) })
+		b.tracker.Progress(bt.view.JobID, bt.progress())
+	}
+	view := bt.view
+	b.mu.Unlock()
+	header := http.Header{}
+	if v := r.Header.Get("Authorization"); v != "" {
+		// Requests are charged to the key that submitted the batch.
+		header.Set("Authorization", v)
+	}
+	logx.Log.Info().Str("batch_id", view.ID).Str("endpoint", view.Endpoint).Int("requests", len(reqs)).Msg("batch started")
+	go b.run(ctx, bt, reqs, header, expires)
+	writeJSON(w, http.StatusOK, view)
+}
+
+// HandleList handles GET /api/llm/v1/batches, newest first, paginated with
+// the limit and after query parameters.
+func (b *Batches) HandleList(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 100 {
+		limit = v
+	}
+	after := r.URL.Query().Get("after")
+	b.mu.Lock()
+	b.pruneLocked(time.Now())
+	all := make([]Batch, 0, len(b.batches))
+	for _, bt := range b.batches {
+		all = append(all, bt.view)
+	}
+	b.mu.Unlock()
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CreatedAt != all[j].CreatedAt {
+			return all[i].CreatedAt > all[j].CreatedAt
+		}
+		return all[i].ID > all[j].ID
+	})
+	if after != "" {
+		for i, v := range all {
+			if v.ID == after {
+				all = all[i+1:]
+				break
+			}
+		}
+	}
+	hasMore := len(all) > limit
+	if hasMore {
+		all = all[:limit]
+	}
+	resp := map[string]any{"object": "list", "data": all, "has_more": hasMore}
+	if len(all) > 0 {
+		resp["first_id"] = all[0].ID
+		resp["last_id"] = all[len(all)-1].ID
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// HandleGet handles GET /api/llm/v1/batches/{batch_id}.
+func (b *Batches) HandleGet(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	bt := b.batches[chi.URLParam(r, "batch_id")]
+	var view Batch
+	if bt != nil {
+		view = bt.view
+	}
+	b.mu.Unlock()
+	if bt == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "batch_not_found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, view)
+}
+
+// HandleCancel handles POST /api/llm/v1/batches/{batch_id}/cancel. Requests
+// in flight are abandoned; the results of finished requests remain available
+// once the batch is cancelled.
+func (b *Batches) HandleCancel(w http.ResponseWriter, r *http.Request) {
+	view, ok, cancelled := b.cancelBatch(chi.URLParam(r, "batch_id"))
+	switch {
+	case !ok:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "batch_not_found"})
+	case !cancelled:
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "invalid_state"})
+	default:
+		writeJSON(w, http.StatusOK, view)
+	}
+}
+
+// cancelBatch moves a running batch to cancelling and stops its requests.
+func (b *Batches) cancelBatch(id string) (Batch, bool, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bt := b.batches[id]
+	if bt == nil {
+		return Batch{}, false, false
+	}
+	switch bt.view.Status {
+	case BatchCancelling:
+		return bt.view, true, true
+	case BatchInProgress:
+	default:
+		return bt.view, true, false
+	}
+	bt.view.Status = BatchCancelling
+	bt.view.CancellingAt = time.Now().Unix()
+	bt.cancel()
+	return bt.view, true, true
+}
+
+func (b *Batches) pruneLocked(now time.Time) {
+	if b.retention <= 0 {
+		return
+	}
+	for id, bt := range b.batches {
+		if !bt.finished.IsZero() && now.Sub(bt.finished) > b.retention {
+			delete(b.batches, id)
+		}
+	}
+}
+
+func (bt *batch) progress() map[string]any {
+	c := bt.view.RequestCounts
+	return map[string]any{"total": c.Total, "completed": c.Completed, "failed": c.Failed}
+}
+
+// run executes the requests of a batch, at most b.concurrency at a time, and
+// writes the output and error files once they are all done or the batch was
+// cancelled or expired.
+func (b *Batches) run(parent context.Context, bt *batch, reqs []batchRequest, header http.Header, expires time.Time) {
+	ctx, stop := context.WithDeadline(parent, expires)
+	defer stop()
+	var (
+		mu       sync.Mutex
+		out, bad bytes.Buffer
+		done     = make([]bool, len(reqs))
+		wg       sync.WaitGroup
+		slots    = make(chan struct{}, b.concurrency)
+	)
+	writeLine := func(buf *bytes.Buffer, line batchOutput) {
+		data, _ := json.Marshal(line)
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+dispatch:
+	for i := range reqs {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case slots <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			line, ok := b.execute(ctx, bt.view.Endpoint, reqs[i], header)
+			if !ok {
+				return
+			}
+			mu.Lock()
+			done[i] = true
+			succeeded := line.Error == nil && line.Response.StatusCode < http.StatusBadRequest
+			if succeeded {
+				writeLine(&out, line)
+			} else {
+				writeLine(&bad, line)
+			}
+			mu.Unlock()
+			b.mu.Lock()
+			if succeeded {
+				bt.view.RequestCounts.Completed++
+			} else {
+				bt.view.RequestCounts.Failed++
+			}
+			if b.tracker != nil && bt.view.JobID != "" {
+				b.tracker.Progress(bt.view.JobID, bt.progress())
+			}
+			b.mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	expired := errors.Is(ctx.Err(), context.DeadlineExceeded)
+	if expired {
+		for i, req := range reqs {
+			if !done[i] {
+				writeLine(&bad, batchOutput{ID: "batch_req_" + uuid.NewString(), CustomID: req.CustomID, Error: &batchRequestFailed{
+					Code:    "batch_expired",
+					Message: "This request could not be executed before the completion window expired.",
+				}})
+			}
+		}
+	}
+
+	b.mu.Lock()
+	if bt.view.Status == BatchInProgress {
+		bt.view.Status = BatchFinalizing
+	}
+	bt.view.FinalizingAt = time.Now().Unix()
+	b.mu.Unlock()
+	var outID, errID string
+	if out.Len() > 0 {
+		outID = b.files.Add(bt.view.ID+"_output.jsonl", "batch_output", out.Bytes()).ID
+	}
+	if bad.Len() > 0 {
+		errID = b.files.Add(bt.view.ID+"_error.jsonl", "batch_output", bad.Bytes()).ID
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	bt.view.OutputFileID, bt.view.ErrorFileID = outID, errID
+	jobStatus, errCode, errMsg := "completed", "", ""
+	switch {
+	case expired:
+		bt.view.Status = BatchExpired
+		bt.view.ExpiredAt = now.Unix()
+		jobStatus, errCode, errMsg = "failed", "batch_expired", "the batch did not finish within its completion window"
+	case parent.Err() != nil:
+		bt.view.Status = BatchCancelled
+		bt.view.CancelledAt = now.Unix()
+		jobStatus = "canceled"
+	default:
+		bt.view.Status = BatchCompleted
+		bt.view.CompletedAt = now.Unix()
+	}
+	bt.finished = now
+	if b.tracker != nil && bt.view.JobID != "" {
+		b.tracker.Finish(bt.view.JobID, jobStatus, errCode, errMsg)
+	}
+	view := bt.view
+	b.mu.Unlock()
+	logx.Log.Info().Str("batch_id", view.ID).Str("status", view.Status).Int("completed", view.RequestCounts.Completed).Int("failed", view.RequestCounts.Failed).Msg("batch finished")
+}
+
+// execute runs one batch request once capacity is idle and reports false if
+// the batch was cancelled or expired first. A request for a model that no
+// connected worker serves fails with model_not_found instead of holding a
+// slot until the batch expires.
+func (b *Batches) execute(ctx context.Context, endpoint string, req batchRequest, header http.Header) (batchOutput, bool) {
+	h := b.handlers[endpoint]
+	for {
+		switch err := b.waitIdle(ctx, req.model); {
+		case errors.Is(err, errModelNotServed):
+			return batchOutput{ID: "batch_req_" + uuid.NewString(), CustomID: req.CustomID, Error: &batchRequestFailed{
+				Code:    "model_not_found",
+				Message: fmt.Sprintf("No connected worker serves the model %q.", req.model),
+			}}, true
+		case err != nil:
+			return batchOutput{}, false
+		}
+		reqID := "req_" + uuid.NewString()
+		hr, err := http.NewRequestWithContext(ctx, http.MethodPost, "/api/llm"+endpoint, bytes.NewReader(req.Body))
+		if err != nil {
+			return batchOutput{}, false
+		}
+		hr.Header = header.Clone()
+		hr.Header.Set("Content-Type", "application/json")
+		hr.Header.Set("X-Request-Id", reqID)
+		rec := &batchRecorder{header: http.Header{}}
+		h.ServeHTTP(rec, hr)
+		if ctx.Err() != nil {
+			return batchOutput{}, false
+		}
+		if rec.missedCapacity() {
+			// An interactive request took the slot first; wait again.
+			continue
+		}
+		body := rec.body.Bytes()
+		if !json.Valid(body) {
+			body, _ = json.Marshal(map[string]string{"error": strings.TrimSpace(rec.body.String())})
+		}
+		return batchOutput{
+			ID:       "batch_req_" + uuid.NewString(),
+			CustomID: req.CustomID,
+			Response: &batchResponse{StatusCode: rec.statusCode(), RequestID: reqID, Body: body},
+		}, true
+	}
+}
+
+// errModelNotServed reports a batch request for a model no connected worker
+// serves.
+var errModelNotServed = errors.New("model not served")
+
+// waitIdle blocks until no interactive request is queued and a worker serving
+// model has a free slot. It returns errModelNotServed as soon as no connected
+// worker serves model, and ctx.Err() when ctx ends first.
+func (b *Batches) waitIdle(ctx context.Context, model string) error {
+	idle := func() (bool, error) {
+		if !modelServed(b.reg, model) {
+			return false, errModelNotServed
+		}
+		if b.queue != nil && b.queue.Len() > 0 {
+			return false, nil
+		}
+		_, err := b.sched.PickWorker(model)
+		return err == nil, nil
+	}
+	if ok, err := idle(); ok || err != nil {
+		return err
+	}
+	ticker := time.NewTicker(batchPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if ok, err := idle(); ok || err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parseBatchInput reads the requests of a JSONL input file. Streaming is
+// turned off since batch results are collected whole.
+func parseBatchInput(data []byte, endpoint string) ([]batchRequest, []BatchError) {
+	var (
+		reqs     []batchRequest
+		problems []BatchError
+		seen     = make(map[string]bool)
+	)
+	fail := func(line int, code, msg string) {
+		if len(problems) < maxBatchErrors {
+			problems = append(problems, BatchError{Code: code, Message: msg, Line: line})
+		}
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64<<10), len(data)+1)
+	for n := 1; sc.Scan(); n++ {
+		raw := bytes.TrimSpace(sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var req batchRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			fail(n, "invalid_json_line", "line is not a JSON object")
+			continue
+		}
+		var body map[string]any
+		switch {
+		case req.CustomID == "":
+			fail(n, "missing_custom_id", "custom_id is required")
+			continue
+		case seen[req.CustomID]:
+			fail(n, "duplicate_custom_id", fmt.Sprintf("custom_id %q is used more than once", req.CustomID))
+			continue
+		case req.Method != http.MethodPost:
+			fail(n, "invalid_method", "method must be POST")
+			continue
+		case req.URL != endpoint:
+			fail(n, "mismatched_endpoint", fmt.Sprintf("url must be %s", endpoint))
+			continue
+		case json.Unmarshal(req.Body, &body) != nil || body == nil:
+			fail(n, "invalid_body", "body must be a JSON object")
+			continue
+		}
+		seen[req.CustomID] = true
+		req.model, _ = body["model"].(string)
+		if _, ok := body["stream"]; ok {
+			body["stream"] = false
+			req.Body, _ = json.Marshal(body)
+		}
+		reqs = append(reqs, req)
+	}
+	if err := sc.Err(); err != nil {
+		fail(0, "invalid_file", err.Error())
+	}
+	if len(reqs) == 0 && len(problems) == 0 {
+		fail(0, "empty_file", "the input file contains no requests")
+	}
+	return reqs, problems
+}
+
+// batchRecorder captures the response of a batch request.
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *batchRecorder) Header() http.Header { return r.header }
+
+func (r *batchRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *batchRecorder) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(p)
+}
+
+func (r *batchRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+// missedCapacity reports whether the dispatcher found no worker free, which
+// the handlers signal with worker_busy, or with "no worker" when the model's
+// workers are busy or gone. The request then waits again, and fails with
+// model_not_found if the model is no longer served.
+func (r *batchRecorder) missedCapacity() bool {
+	body := strings.TrimSpace(r.body.String())
+	switch r.status {
+	case http.StatusServiceUnavailable:
+		return strings.Contains(body, "worker_busy")
+	case http.StatusNotFound:
+		return body == "no worker"
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package openai
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseBatchInputValidatesLines(t *testing.T) {
+	input := strings.Join([]string{
+		`{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"m","stream":true}}`,
+		``,
+		`not json`,
+		`{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"m"}}`,
+		`{"custom_id":"b","method":"GET","url":"/v1/chat/completions","body":{}}`,
+		`{"custom_id":"c","method":"POST","url":"/v1/embeddings","body":{}}`,
+		`{"custom_id":"d","method":"POST","url":"/v1/chat/completions","body":[1]}`,
+		`{"method":"POST","url":"/v1/chat/completions","body":{}}`,
+	}, "\n")
+	reqs, problems := parseBatchInput([]byte(input), "/v1/chat/completions")
+	if len(reqs) != 1 || reqs[0].CustomID != "a" || reqs[0].model != "m" {
+		t.Fatalf("unexpected requests %+v", reqs)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(reqs[0].Body, &body); err != nil || body["stream"] != false {
+		t.Fatalf("stream not disabled: %s", reqs[0].Body)
+	}
+	want := []BatchError{
+		{Code: "invalid_json_line", Line: 3},
+		{Code: "duplicate_custom_id", Line: 4},
+		{Code: "invalid_method", Line: 5},
+		{Code: "mismatched_endpoint", Line: 6},
+		{Code: "invalid_body", Line: 7},
+		{Code: "missing_custom_id", Line: 8},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got problems %+v", problems)
+	}
+	for i, p := range problems {
+		if p.Code != want[i].Code || p.Line != want[i].Line {
+			t.Fatalf("problem %d = %+v, want %+v", i, p, want[i])
+		}
+	}
+
+	if _, problems := parseBatchInput([]byte("\n\n"), "/v1/embeddings"); len(problems) != 1 || problems[0].Code != "empty_file" {
+		t.Fatalf("expected empty_file, got %+v", problems)
+	}
+}
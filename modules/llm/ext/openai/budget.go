@@ -0,0 +1,136 @@
+package openai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
+	baseauth "github.com/gaspardpetit/nfrx/sdk/base/auth"
+)
+
+// CostBudget tracks spend per API key over a fixed window and rejects requests
+// whose estimated cost would push a key over its limit. Admitted requests hold
+// their estimate as a reservation until they settle, so concurrent requests
+// cannot all pass against the same remaining budget.
+type CostBudget struct {
+	mu     sync.Mutex
+	limit  float64
+	window time.Duration
+	spend  map[string]*keySpend
+	now    func() time.Time
+}
+
+type keySpend struct {
+	start time.Time
+	total float64
+}
+
+// Reservation is the estimated cost held against a key's budget by an
+// admitted request.
+type Reservation struct {
+	b       *CostBudget
+	key     string
+	ks      *keySpend
+	amount  float64
+	settled bool
+}
+
+// NewCostBudget returns a budget allowing limit spend per key in each window.
+// A non-positive window never resets spend.
+func NewCostBudget(limit float64, window time.Duration) *CostBudget {
+	return &CostBudget{limit: limit, window: window, spend: make(map[string]*keySpend), now: time.Now}
+}
+
+// Reserve holds estimate against key's budget if it fits in the remaining
+// spend. The caller must Settle the returned reservation once the request ends.
+func (b *CostBudget) Reserve(key string, estimate float64) (*Reservation, bool) {
+	if b == nil {
+		return nil, true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ks := b.currentLocked(key)
+	if b.limit > 0 && (ks.total >= b.limit || ks.total+estimate > b.limit) {
+		return nil, false
+	}
+	estimate = max(estimate, 0)
+	ks.total += estimate
+	return &Reservation{b: b, key: key, ks: ks, amount: estimate}, true
+}
+
+// Settle replaces the reserved estimate with the actual cost of the request;
+// a zero cost refunds the reservation. Only the first call has an effect. When
+// the window rolled over since the reservation, the cost is charged to the
+// current window instead.
+func (r *Reservation) Settle(cost float64) {
+	if r == nil {
+		return
+	}
+	b := r.b
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if r.settled {
+		return
+	}
+	r.settled = true
+	cost = max(cost, 0)
+	if ks := b.currentLocked(r.key); ks != r.ks {
+		ks.total += cost
+		return
+	}
+	r.ks.total += cost - r.amount
+}
+
+// Spent returns the spend recorded for key in the current window.
+func (b *CostBudget) Spent(key string) float64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentLocked(key).total
+}
+
+func (b *CostBudget) currentLocked(key string) *keySpend {
+	now := b.now()
+	ks := b.spend[key]
+	if ks == nil || (b.window > 0 && now.Sub(ks.start) >= b.window) {
+		ks = &keySpend{start: now}
+		b.spend[key] = ks
+	}
+	return ks
+}
+
+// budgetKey identifies the caller for budgeting and audit logs without
+// exposing the credential: a short hash of the bearer token, or "anonymous".
+func budgetKey(r *http.Request) string {
+	tok := baseauth.ExtractBearer(r)
+	if tok == "" {
+		return "anonymous"
+	}
+	sum := sha256.Sum256([]byte(tok))
+	return "key-" + hex.EncodeToString(sum[:8])
+}
+
+// cheapestPricing returns the lowest declared pricing among workers, or zero
+// pricing when none of them declare one.
+func cheapestPricing(workers []spi.WorkerRef) spi.WorkerPricing {
+	var (
+		best  spi.WorkerPricing
+		found bool
+	)
+	for _, w := range workers {
+		pw, ok := w.(spi.PricedWorker)
+		if !ok {
+			return spi.WorkerPricing{}
+		}
+		p := pw.Pricing()
+		if !found || p.InputPer1K+p.OutputPer1K < best.InputPer1K+best.OutputPer1K {
+			best, found = p, true
+		}
+	}
+	return best
+}
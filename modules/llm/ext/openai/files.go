@@ -0,0 +1,159 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/gaspardpetit/nfrx/core/logx"
+)
+
+// File is an entry of the OpenAI-compatible files API: a batch input file
+// uploaded by a client, or a batch output or error file.
+type File struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Bytes     int    `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+
+	data    []byte
+	created time.Time
+}
+
+// FileStore keeps files in memory for the retention period after they were
+// created. Nothing is written to disk, so files do not survive a restart.
+type FileStore struct {
+	mu        sync.Mutex
+	files     map[string]*File
+	maxBytes  int64
+	retention time.Duration
+}
+
+// NewFileStore returns a store accepting uploads up to maxBytes (0 means no
+// limit) and dropping files retention after their creation (0 keeps them).
+func NewFileStore(maxBytes int64, retention time.Duration) *FileStore {
+	return &FileStore{files: make(map[string]*File), maxBytes: maxBytes, retention: retention}
+}
+
+// Add stores data as a new file.
+func (s *FileStore) Add(filename, purpose string, data []byte) *File {
+	now := time.Now()
+	f := &File{ID: "file-" + uuid.NewString(), Object: "file", Bytes: len(data), CreatedAt: now.Unix(), Filename: filename, Purpose: purpose, data: data, created: now}
+	s.mu.Lock()
+	s.pruneLocked(now)
+	s.files[f.ID] = f
+	s.mu.Unlock()
+	return f
+}
+
+// Get returns a file that has not expired.
+func (s *FileStore) Get(id string) (*File, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(time.Now())
+	f, ok := s.files[id]
+	return f, ok
+}
+
+func (s *FileStore) pruneLocked(now time.Time) {
+	if s.retention <= 0 {
+		return
+	}
+	for id, f := range s.files {
+		if now.Sub(f.created) > s.retention {
+			delete(s.files, id)
+		}
+	}
+}
+
+// HandleUpload handles POST /api/llm/v1/files. It expects a multipart form
+// with a "file" part and a "purpose" field; only the "batch" purpose is
+// accepted.
+func (s *FileStore) HandleUpload(w http.ResponseWriter, r *http.Request) {
+	if s.maxBytes > 0 {
+		// Leave room for the multipart envelope around the file.
+		r.Body = http.MaxBytesReader(w, r.Body, s.maxBytes+1<<20)
+	}
+	part, header, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "file_too_large"})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing_file"})
+		return
+	}
+	defer func() { _ = part.Close() }()
+	if purpose := r.FormValue("purpose"); purpose != "batch" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_purpose"})
+		return
+	}
+	data, err := io.ReadAll(part)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_file"})
+		return
+	}
+	if s.maxBytes > 0 && int64(len(data)) > s.maxBytes {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "file_too_large"})
+		return
+	}
+	f := s.Add(header.Filename, "batch", data)
+	logx.Log.Info().Str("file_id", f.ID).Int("bytes", f.Bytes).Msg("batch file uploaded")
+	writeJSON(w, http.StatusOK, f)
+}
+
+// HandleList handles GET /api/llm/v1/files, newest first.
+func (s *FileStore) HandleList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.pruneLocked(time.Now())
+	data := make([]*File, 0, len(s.files))
+	for _, f := range s.files {
+		if p := r.URL.Query().Get("purpose"); p == "" || p == f.Purpose {
+			data = append(data, f)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(data, func(i, j int) bool { return data[i].created.After(data[j].created) })
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": data})
+}
+
+// HandleGet handles GET /api/llm/v1/files/{file_id}.
+func (s *FileStore) HandleGet(w http.ResponseWriter, r *http.Request) {
+	f, ok := s.Get(chi.URLParam(r, "file_id"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "file_not_found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, f)
+}
+
+// HandleContent handles GET /api/llm/v1/files/{file_id}/content.
+func (s *FileStore) HandleContent(w http.ResponseWriter, r *http.Request) {
+	f, ok := s.Get(chi.URLParam(r, "file_id"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "file_not_found"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(f.data)))
+	_, _ = w.Write(f.data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logx.Log.Error().Err(err).Msg("encode response")
+	}
+}
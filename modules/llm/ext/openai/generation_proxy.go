@@ -17,6 +17,28 @@ import (
 	basemetrics "github.com/gaspardpetit/nfrx/sdk/base/metrics"
 )
 
+// latencyObserver is optionally implemented by registries that track worker latency.
+type latencyObserver interface {
+	ObserveLatency(id, model string, d time.Duration)
+}
+
+// failureObserver is optionally implemented by registries that adapt worker
+// concurrency to failures.
+type failureObserver interface {
+	ObserveFailure(id, model string)
+}
+
+// costRecorder is optionally implemented by metrics sinks that track request cost.
+type costRecorder interface {
+	RecordWorkerCost(workerID string, cost, energyWh float64)
+}
+
+// jobAbandoner is optionally implemented by metrics sinks that can end a job
+// without counting it as completed or failed.
+type jobAbandoner interface {
+	RecordJobAbandoned(workerID string)
+}
+
 type generationQueueStatusWriter func(w http.ResponseWriter, flusher http.Flusher, reqID, model string, pos int) bool
 
 type generationProxySpec struct {
@@ -41,13 +63,49 @@ func generationProxyHandler(reg spi.WorkerRegistry, sched spi.Scheduler, metrics
 			return
 		}
 		var meta struct {
-			Model  string `json:"model"`
-			Stream bool   `json:"stream"`
+			Model               string `json:"model"`
+			Stream              bool   `json:"stream"`
+			MaxTokens           int64  `json:"max_tokens"`
+			MaxCompletionTokens int64  `json:"max_completion_tokens"`
+			MaxOutputTokens     int64  `json:"max_output_tokens"`
 		}
 		_ = json.Unmarshal(body, &meta)
 
 		reqID := uuid.NewString()
 		logID := chiMiddleware.GetReqID(r.Context())
+		key := budgetKey(r)
+
+		maxOut := max(meta.MaxTokens, meta.MaxCompletionTokens, meta.MaxOutputTokens, 0)
+		promptTokens := estimatePromptTokens(body)
+		size := spi.RequestSize{PromptTokens: int(promptTokens), MaxOutputTokens: int(maxOut)}
+		if !anyWorkerFits(servingWorkers(reg, meta.Model), meta.Model, size) {
+			logx.Log.Warn().Str("request_id", logID).Str("model", meta.Model).Int("prompt_tokens_estimate", size.PromptTokens).Int("max_output_tokens", size.MaxOutputTokens).Msg("request exceeds model limits")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"error":                  "context_length_exceeded",
+				"message":                "no worker serving this model can fit the request",
+				"prompt_tokens_estimate": size.PromptTokens,
+				"max_output_tokens":      size.MaxOutputTokens,
+			})
+			return
+		}
+
+		var reservation *Reservation
+		if opts.Budget != nil {
+			estimate := cheapestPricing(servingWorkers(reg, meta.Model)).Cost(promptTokens, uint64(maxOut))
+			var ok bool
+			if reservation, ok = opts.Budget.Reserve(key, estimate); !ok {
+				logx.Log.Warn().Str("request_id", logID).Str("key", key).Str("model", meta.Model).Float64("estimate", estimate).Float64("spent", opts.Budget.Spent(key)).Msg("budget exceeded")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"error":"budget_exceeded"}`))
+				return
+			}
+			// Refunds the reservation when the request never reaches a worker;
+			// a dispatched request settles it with its actual cost first.
+			defer reservation.Settle(0)
+		}
 
 		headers := map[string]string{}
 		headers["Content-Type"] = r.Header.Get("Content-Type")
@@ -83,6 +141,8 @@ func generationProxyHandler(reg spi.WorkerRegistry, sched spi.Scheduler, metrics
 		var errorBody []byte
 		var idle *time.Timer
 		var timeoutCh <-chan time.Time
+		var dispatchedAt time.Time
+		firstByte := false
 
 		writeQueueStatus := func(pos int) {
 			if !meta.Stream || flusher == nil || spec.queueStatusWriter == nil {
@@ -93,8 +153,12 @@ func generationProxyHandler(reg spi.WorkerRegistry, sched spi.Scheduler, metrics
 			}
 		}
 
+		proxyRequest := func(id string) ctrl.HTTPProxyRequestMessage {
+			return ctrl.HTTPProxyRequestMessage{Type: "http_proxy_request", RequestID: id, Method: http.MethodPost, Path: spec.endpointPath, Headers: headers, Stream: meta.Stream, Body: body}
+		}
+
 		tryDispatch := func() (dispatched bool, worker spi.WorkerRef, ch chan interface{}) {
-			wk, err := sched.PickWorker(meta.Model)
+			wk, err := pickWorker(sched, meta.Model, size)
 			if err != nil {
 				return false, nil, nil
 			}
@@ -107,7 +171,7 @@ func generationProxyHandler(reg spi.WorkerRegistry, sched spi.Scheduler, metrics
 			ch = make(chan interface{}, 16)
 			wk.AddJob(reqID, ch)
 			sent := false
-			msg := ctrl.HTTPProxyRequestMessage{Type: "http_proxy_request", RequestID: reqID, Method: http.MethodPost, Path: spec.endpointPath, Headers: headers, Stream: meta.Stream, Body: body}
+			msg := proxyRequest(reqID)
 			select {
 			case wk.SendChan() <- msg:
 				sent = true
@@ -121,23 +185,12 @@ func generationProxyHandler(reg spi.WorkerRegistry, sched spi.Scheduler, metrics
 			metrics.RecordJobStart(wk.ID())
 			metrics.SetWorkerStatus(wk.ID(), spi.StatusWorking)
 			reg.IncInFlight(wk.ID())
+			dispatchedAt = time.Now()
 			logx.Log.Info().Str("request_id", logID).Str("worker_id", wk.ID()).Str("worker_name", wk.Name()).Str("model", meta.Model).Bool("stream", meta.Stream).Str("path", spec.endpointPath).Msg("dispatch")
 			return true, wk, ch
 		}
 
-		modelSupported := func(model string) bool {
-			if _, ok := reg.AggregatedModel(model); ok {
-				return true
-			}
-			if ak, ok := ctrl.AliasKey(model); ok {
-				for _, m := range reg.AggregatedModels() {
-					if mk, ok2 := ctrl.AliasKey(m.ID); ok2 && mk == ak {
-						return true
-					}
-				}
-			}
-			return false
-		}
+		modelSupported := func(model string) bool { return modelServed(reg, model) }
 
 		dispatched, worker, ch := tryDispatch()
 		if !dispatched {
@@ -155,7 +208,7 @@ func generationProxyHandler(reg spi.WorkerRegistry, sched spi.Scheduler, metrics
 				http.Error(w, "no worker", http.StatusNotFound)
 				return
 			}
-			if pos, ok := queue.Enter(reqID, meta.Model); !ok {
+			if pos, ok := queue.Enter(reqID, meta.Model, size); !ok {
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusServiceUnavailable)
 				_, _ = w.Write([]byte(`{"error":"worker_busy"}`))
@@ -183,11 +236,11 @@ func generationProxyHandler(reg spi.WorkerRegistry, sched spi.Scheduler, metrics
 						http.Error(w, "no worker", http.StatusNotFound)
 						return
 					}
-					if queue.IsFirstDispatchable(reqID, func(model string) bool {
+					if queue.IsFirstDispatchable(reqID, func(model string, size spi.RequestSize) bool {
 						if !modelSupported(model) {
 							return false
 						}
-						_, err := sched.PickWorker(model)
+						_, err := pickWorker(sched, model, size)
 						return err == nil
 					}) {
 						if d, wk, c := tryDispatch(); d {
@@ -210,9 +263,61 @@ func generationProxyHandler(reg spi.WorkerRegistry, sched spi.Scheduler, metrics
 		}
 
 	PROXY:
+		// jobID identifies the request on the serving worker; it changes when a
+		// hedged duplicate wins the race.
+		jobID := reqID
+		var (
+			hedge        spi.WorkerRef
+			hedgeID      string
+			hedgeCh      chan interface{}
+			hedgeAt      time.Time
+			hedgeTimerCh <-chan time.Time
+		)
+		// abandon releases a worker that lost the hedge race. Workers whose
+		// channel was closed by a disconnect are not sent a cancel.
+		abandon := func(wk spi.WorkerRef, id string, connected bool) {
+			if connected {
+				select {
+				case wk.SendChan() <- ctrl.HTTPProxyCancelMessage{Type: "http_proxy_cancel", RequestID: id}:
+				default:
+				}
+			}
+			wk.RemoveJob(id)
+			// Losing a hedge race is not a worker failure.
+			if ja, ok := metrics.(jobAbandoner); ok {
+				ja.RecordJobAbandoned(wk.ID())
+			} else {
+				metrics.RecordJobEnd(wk.ID(), meta.Model, time.Since(start), 0, 0, 0, true, "")
+			}
+			metrics.SetWorkerStatus(wk.ID(), spi.StatusIdle)
+			reg.DecInFlight(wk.ID())
+		}
+		dropHedge := func(connected bool) {
+			if hedge == nil {
+				return
+			}
+			abandon(hedge, hedgeID, connected)
+			opts.Hedge.Release()
+			hedge, hedgeCh = nil, nil
+		}
+		promoteHedge := func(connected bool) {
+			abandon(worker, jobID, connected)
+			opts.Hedge.Release()
+			logx.Log.Info().Str("request_id", logID).Str("worker_id", hedge.ID()).Str("worker_name", hedge.Name()).Str("loser_id", worker.ID()).Str("model", meta.Model).Msg("hedge won")
+			worker, jobID, ch, dispatchedAt = hedge, hedgeID, hedgeCh, hedgeAt
+			hedge, hedgeCh = nil, nil
+		}
+		defer dropHedge(true)
+		if _, ok := reg.(capacityRegistry); ok {
+			if d, ok := opts.Hedge.Delay(size.PromptTokens); ok {
+				hedgeTimer := time.NewTimer(d)
+				defer hedgeTimer.Stop()
+				hedgeTimerCh = hedgeTimer.C
+			}
+		}
 		defer func() {
 			if worker != nil {
-				worker.RemoveJob(reqID)
+				worker.RemoveJob(jobID)
 				dur := time.Since(start)
 				metrics.RecordJobEnd(worker.ID(), meta.Model, dur, tokensIn, tokensOut, 0, success, errMsg)
 				metrics.SetWorkerStatus(worker.ID(), spi.StatusIdle)
@@ -231,6 +336,24 @@ func generationProxyHandler(reg spi.WorkerRegistry, sched spi.Scheduler, metrics
 					basemetrics.AddSize("llm", "worker", spec.operationName, meta.Model, "tokens_out", tokensOut)
 					basemetrics.AddSize("llm", "worker", spec.operationName, meta.Model, "tokens_total", tokensIn+tokensOut)
 				}
+				var cost, energyWh float64
+				if pw, ok := worker.(spi.PricedWorker); ok {
+					p := pw.Pricing()
+					cost = p.Cost(tokensIn, tokensOut)
+					energyWh = p.PowerWatts * time.Since(dispatchedAt).Hours()
+				}
+				basemetrics.AddCost("llm", "worker", spec.operationName, meta.Model, "price", cost)
+				basemetrics.AddCost("llm", "worker", spec.operationName, meta.Model, "energy_wh", energyWh)
+				if cr, ok := metrics.(costRecorder); ok {
+					cr.RecordWorkerCost(worker.ID(), cost, energyWh)
+				}
+				if errMsg != "" || upstreamStatus >= http.StatusInternalServerError {
+					if fo, ok := reg.(failureObserver); ok {
+						fo.ObserveFailure(worker.ID(), meta.Model)
+					}
+				}
+				reservation.Settle(cost)
+				logx.Log.Info().Str("event", "audit").Str("request_id", logID).Str("key", key).Str("worker_id", worker.ID()).Str("worker_name", worker.Name()).Str("model", meta.Model).Str("path", spec.endpointPath).Bool("success", success).Uint64("tokens_in", tokensIn).Uint64("tokens_out", tokensOut).Float64("cost", cost).Float64("energy_wh", energyWh).Msg("usage")
 				reg.DecInFlight(worker.ID())
 			}
 		}()
@@ -242,10 +365,14 @@ func generationProxyHandler(reg spi.WorkerRegistry, sched spi.Scheduler, metrics
 		}
 
 		for {
+			var (
+				msg any
+				ok  bool
+			)
 			select {
 			case <-ctx.Done():
 				select {
-				case worker.SendChan() <- ctrl.HTTPProxyCancelMessage{Type: "http_proxy_cancel", RequestID: reqID}:
+				case worker.SendChan() <- ctrl.HTTPProxyCancelMessage{Type: "http_proxy_cancel", RequestID: jobID}:
 				default:
 				}
 				return
@@ -255,7 +382,7 @@ func generationProxyHandler(reg spi.WorkerRegistry, sched spi.Scheduler, metrics
 				if since > opts.RequestTimeout {
 					errMsg = "timeout"
 					select {
-					case worker.SendChan() <- ctrl.HTTPProxyCancelMessage{Type: "http_proxy_cancel", RequestID: reqID}:
+					case worker.SendChan() <- ctrl.HTTPProxyCancelMessage{Type: "http_proxy_cancel", RequestID: jobID}:
 					default:
 					}
 					if !headersSent {
@@ -269,99 +396,137 @@ func generationProxyHandler(reg spi.WorkerRegistry, sched spi.Scheduler, metrics
 					idle.Reset(opts.RequestTimeout - since)
 					timeoutCh = idle.C
 				}
-			case msg, ok := <-ch:
+				continue
+			case <-hedgeTimerCh:
+				hedgeTimerCh = nil
+				if !opts.Hedge.Acquire(reg.(capacityRegistry).TotalCapacity()) {
+					continue
+				}
+				hw := hedgeWorker(reg, meta.Model, size, worker.ID())
+				if hw == nil {
+					opts.Hedge.Release()
+					continue
+				}
+				id := uuid.NewString()
+				hc := make(chan interface{}, 16)
+				hw.AddJob(id, hc)
+				select {
+				case hw.SendChan() <- proxyRequest(id):
+				default:
+					hw.RemoveJob(id)
+					opts.Hedge.Release()
+					continue
+				}
+				metrics.RecordJobStart(hw.ID())
+				metrics.SetWorkerStatus(hw.ID(), spi.StatusWorking)
+				reg.IncInFlight(hw.ID())
+				hedge, hedgeID, hedgeCh, hedgeAt = hw, id, hc, time.Now()
+				logx.Log.Info().Str("request_id", logID).Str("worker_id", hw.ID()).Str("worker_name", hw.Name()).Str("primary_id", worker.ID()).Str("model", meta.Model).Msg("hedge dispatched")
+				continue
+			case msg, ok = <-hedgeCh:
+				hedgeTimerCh = nil
 				if !ok {
-					if !headersSent {
-						w.Header().Set("Content-Type", "application/json")
-						w.WriteHeader(http.StatusBadGateway)
-						if _, err := w.Write([]byte(`{"error":"upstream_error"}`)); err != nil {
-							logx.Log.Error().Err(err).Msg("write upstream error")
-						}
+					dropHedge(false)
+					continue
+				}
+				promoteHedge(true)
+			case msg, ok = <-ch:
+				hedgeTimerCh = nil
+				if hedge != nil {
+					if !ok {
+						promoteHedge(false)
+						continue
 					}
-					errMsg = "closed"
-					return
+					dropHedge(true)
 				}
-				if idle != nil {
-					if !idle.Stop() {
-						<-timeoutCh
+			}
+			if !ok {
+				if !headersSent {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusBadGateway)
+					if _, err := w.Write([]byte(`{"error":"upstream_error"}`)); err != nil {
+						logx.Log.Error().Err(err).Msg("write upstream error")
 					}
-					idle.Reset(opts.RequestTimeout)
-					timeoutCh = idle.C
 				}
-				switch m := msg.(type) {
-				case ctrl.HTTPProxyResponseHeadersMessage:
-					priorHeadersSent := headersSent
-					upstreamStatus = m.Status
-					headersSent = true
-					for k, v := range m.Headers {
-						if strings.EqualFold(k, "Transfer-Encoding") || strings.EqualFold(k, "Connection") {
-							continue
-						}
-						w.Header().Set(k, v)
+				errMsg = "closed"
+				return
+			}
+			if idle != nil {
+				if !idle.Stop() {
+					<-timeoutCh
+				}
+				idle.Reset(opts.RequestTimeout)
+				timeoutCh = idle.C
+			}
+			switch m := msg.(type) {
+			case ctrl.HTTPProxyResponseHeadersMessage:
+				if !firstByte {
+					firstByte = true
+					ttfb := time.Since(dispatchedAt)
+					if lo, ok := reg.(latencyObserver); ok {
+						lo.ObserveLatency(worker.ID(), meta.Model, ttfb)
 					}
-					if strings.EqualFold(w.Header().Get("Content-Type"), "text/event-stream") {
-						w.Header().Set("Cache-Control", "no-store")
+					opts.Hedge.Observe(ttfb)
+				}
+				priorHeadersSent := headersSent
+				upstreamStatus = m.Status
+				headersSent = true
+				for k, v := range m.Headers {
+					if strings.EqualFold(k, "Transfer-Encoding") || strings.EqualFold(k, "Connection") {
+						continue
 					}
-					if !priorHeadersSent {
-						w.WriteHeader(m.Status)
+					w.Header().Set(k, v)
+				}
+				if strings.EqualFold(w.Header().Get("Content-Type"), "text/event-stream") {
+					w.Header().Set("Cache-Control", "no-store")
+				}
+				if !priorHeadersSent {
+					w.WriteHeader(m.Status)
+				}
+				if m.Status >= http.StatusBadRequest {
+					lvl := logx.Log.Warn()
+					if m.Status >= http.StatusInternalServerError || m.Status == http.StatusUnauthorized || m.Status == http.StatusForbidden {
+						lvl = logx.Log.Error()
 					}
-					if m.Status >= http.StatusBadRequest {
-						lvl := logx.Log.Warn()
-						if m.Status >= http.StatusInternalServerError || m.Status == http.StatusUnauthorized || m.Status == http.StatusForbidden {
-							lvl = logx.Log.Error()
+					lvl.Str("request_id", logID).Str("worker_id", worker.ID()).Str("worker_name", worker.Name()).Str("model", meta.Model).Int("status", m.Status).Str("path", spec.endpointPath).Msg("upstream response")
+				}
+				if flusher != nil {
+					flusher.Flush()
+				}
+			case ctrl.HTTPProxyResponseChunkMessage:
+				if len(m.Data) > 0 {
+					if upstreamStatus >= http.StatusBadRequest {
+						errorBytes += len(m.Data)
+						if debugErrorBody {
+							errorBody = append(errorBody, m.Data...)
 						}
-						lvl.Str("request_id", logID).Str("worker_id", worker.ID()).Str("worker_name", worker.Name()).Str("model", meta.Model).Int("status", m.Status).Str("path", spec.endpointPath).Msg("upstream response")
 					}
-					if flusher != nil {
-						flusher.Flush()
+					if _, err := w.Write(m.Data); err != nil {
+						logx.Log.Error().Err(err).Msg("write chunk")
+					} else {
+						bytesSent = true
+						if flusher != nil {
+							flusher.Flush()
+						}
 					}
-				case ctrl.HTTPProxyResponseChunkMessage:
-					if len(m.Data) > 0 {
-						if upstreamStatus >= http.StatusBadRequest {
-							errorBytes += len(m.Data)
-							if debugErrorBody {
-								errorBody = append(errorBody, m.Data...)
-							}
+				}
+				if meta.Stream {
+					sseBuf += string(m.Data)
+					for {
+						idx := strings.Index(sseBuf, "\n")
+						if idx == -1 {
+							break
 						}
-						if _, err := w.Write(m.Data); err != nil {
-							logx.Log.Error().Err(err).Msg("write chunk")
-						} else {
-							bytesSent = true
-							if flusher != nil {
-								flusher.Flush()
-							}
+						line := strings.TrimRight(sseBuf[:idx], "\r")
+						sseBuf = sseBuf[idx+1:]
+						if !strings.HasPrefix(line, "data:") {
+							continue
 						}
-					}
-					if meta.Stream {
-						sseBuf += string(m.Data)
-						for {
-							idx := strings.Index(sseBuf, "\n")
-							if idx == -1 {
-								break
-							}
-							line := strings.TrimRight(sseBuf[:idx], "\r")
-							sseBuf = sseBuf[idx+1:]
-							if !strings.HasPrefix(line, "data:") {
-								continue
-							}
-							payload := strings.TrimSpace(line[5:])
-							if payload == "" || payload == "[DONE]" {
-								continue
-							}
-							in, out := extractUsageFromJSON([]byte(payload))
-							if in > 0 {
-								tokensIn = in
-							}
-							if out > 0 {
-								tokensOut = out
-							}
+						payload := strings.TrimSpace(line[5:])
+						if payload == "" || payload == "[DONE]" {
+							continue
 						}
-					} else {
-						bodyBuf = append(bodyBuf, m.Data...)
-					}
-				case ctrl.HTTPProxyResponseEndMessage:
-					if !meta.Stream {
-						in, out := extractUsageFromJSON(bodyBuf)
+						in, out := extractUsageFromJSON([]byte(payload))
 						if in > 0 {
 							tokensIn = in
 						}
@@ -369,32 +534,44 @@ func generationProxyHandler(reg spi.WorkerRegistry, sched spi.Scheduler, metrics
 							tokensOut = out
 						}
 					}
-					if m.Error != nil && !bytesSent {
-						if !headersSent {
-							w.Header().Set("Content-Type", "application/json")
-							w.WriteHeader(http.StatusBadGateway)
-						}
-						if _, err := w.Write([]byte(`{"error":"upstream_error"}`)); err != nil {
-							logx.Log.Error().Err(err).Msg("write upstream error")
-						}
-						errMsg = m.Error.Message
-						logx.Log.Error().Str("request_id", logID).Str("worker_id", worker.ID()).Str("worker_name", worker.Name()).Str("model", meta.Model).Str("error_code", m.Error.Code).Str("error", m.Error.Message).Str("path", spec.endpointPath).Msg("upstream error")
-					} else {
-						success = true
+				} else {
+					bodyBuf = append(bodyBuf, m.Data...)
+				}
+			case ctrl.HTTPProxyResponseEndMessage:
+				if !meta.Stream {
+					in, out := extractUsageFromJSON(bodyBuf)
+					if in > 0 {
+						tokensIn = in
 					}
-					if upstreamStatus >= http.StatusBadRequest && errorBytes > 0 {
-						lvl := logx.Log.Warn()
-						if upstreamStatus >= http.StatusInternalServerError || upstreamStatus == http.StatusUnauthorized || upstreamStatus == http.StatusForbidden {
-							lvl = logx.Log.Error()
-						}
-						lvl.Str("request_id", logID).Str("worker_id", worker.ID()).Str("worker_name", worker.Name()).Str("model", meta.Model).Int("status", upstreamStatus).Str("path", spec.endpointPath).Int("body_bytes", errorBytes).Msg("upstream response body observed")
-						if debugErrorBody {
-							logx.Log.Debug().Str("request_id", logID).Str("worker_id", worker.ID()).Str("worker_name", worker.Name()).Str("model", meta.Model).Int("status", upstreamStatus).Str("path", spec.endpointPath).Bytes("body", errorBody).Msg("upstream response body detail")
-						}
+					if out > 0 {
+						tokensOut = out
+					}
+				}
+				if m.Error != nil && !bytesSent {
+					if !headersSent {
+						w.Header().Set("Content-Type", "application/json")
+						w.WriteHeader(http.StatusBadGateway)
+					}
+					if _, err := w.Write([]byte(`{"error":"upstream_error"}`)); err != nil {
+						logx.Log.Error().Err(err).Msg("write upstream error")
+					}
+					errMsg = m.Error.Message
+					logx.Log.Error().Str("request_id", logID).Str("worker_id", worker.ID()).Str("worker_name", worker.Name()).Str("model", meta.Model).Str("error_code", m.Error.Code).Str("error", m.Error.Message).Str("path", spec.endpointPath).Msg("upstream error")
+				} else {
+					success = true
+				}
+				if upstreamStatus >= http.StatusBadRequest && errorBytes > 0 {
+					lvl := logx.Log.Warn()
+					if upstreamStatus >= http.StatusInternalServerError || upstreamStatus == http.StatusUnauthorized || upstreamStatus == http.StatusForbidden {
+						lvl = logx.Log.Error()
+					}
+					lvl.Str("request_id", logID).Str("worker_id", worker.ID()).Str("worker_name", worker.Name()).Str("model", meta.Model).Int("status", upstreamStatus).Str("path", spec.endpointPath).Int("body_bytes", errorBytes).Msg("upstream response body observed")
+					if debugErrorBody {
+						logx.Log.Debug().Str("request_id", logID).Str("worker_id", worker.ID()).Str("worker_name", worker.Name()).Str("model", meta.Model).Int("status", upstreamStatus).Str("path", spec.endpointPath).Bytes("body", errorBody).Msg("upstream response body detail")
 					}
-					logx.Log.Info().Str("request_id", logID).Str("worker_id", worker.ID()).Str("worker_name", worker.Name()).Str("model", meta.Model).Bool("stream", meta.Stream).Str("path", spec.endpointPath).Dur("duration", time.Since(start)).Msg("complete")
-					return
 				}
+				logx.Log.Info().Str("request_id", logID).Str("worker_id", worker.ID()).Str("worker_name", worker.Name()).Str("model", meta.Model).Bool("stream", meta.Stream).Str("path", spec.endpointPath).Dur("duration", time.Since(start)).Msg("complete")
+				return
 			}
 		}
 	}
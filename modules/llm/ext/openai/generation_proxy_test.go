@@ -5,6 +5,10 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
+	baseworker "github.com/gaspardpetit/nfrx/sdk/base/worker"
 )
 
 type testFlushRecorder struct {
@@ -45,13 +49,13 @@ func TestQueueStatusWriterUsesDedicatedEvent(t *testing.T) {
 
 func TestCompletionQueueFirstDispatchableSkipsBlockedEntries(t *testing.T) {
 	q := NewCompletionQueue(nil, 4)
-	if _, ok := q.Enter("req-a", "model-a"); !ok {
+	if _, ok := q.Enter("req-a", "model-a", spi.RequestSize{}); !ok {
 		t.Fatalf("expected req-a to enter queue")
 	}
-	if _, ok := q.Enter("req-b", "model-b"); !ok {
+	if _, ok := q.Enter("req-b", "model-b", spi.RequestSize{}); !ok {
 		t.Fatalf("expected req-b to enter queue")
 	}
-	canDispatch := func(model string) bool {
+	canDispatch := func(model string, _ spi.RequestSize) bool {
 		return model == "model-b"
 	}
 	if q.IsFirstDispatchable("req-a", canDispatch) {
@@ -61,3 +65,151 @@ func TestCompletionQueueFirstDispatchableSkipsBlockedEntries(t *testing.T) {
 		t.Fatalf("req-b should be first dispatchable entry")
 	}
 }
+
+func TestCompletionQueueFirstDispatchableSkipsOversizedEntries(t *testing.T) {
+	q := NewCompletionQueue(nil, 4)
+	if _, ok := q.Enter("req-big", "m", spi.RequestSize{PromptTokens: 8000}); !ok {
+		t.Fatalf("expected req-big to enter queue")
+	}
+	if _, ok := q.Enter("req-small", "m", spi.RequestSize{PromptTokens: 100}); !ok {
+		t.Fatalf("expected req-small to enter queue")
+	}
+	limits := spi.ModelLimits{ContextLength: 4096}
+	canDispatch := func(_ string, size spi.RequestSize) bool { return limits.Fits(size) }
+	if q.IsFirstDispatchable("req-big", canDispatch) {
+		t.Fatalf("req-big does not fit any free worker")
+	}
+	if !q.IsFirstDispatchable("req-small", canDispatch) {
+		t.Fatalf("req-small must not wait behind req-big")
+	}
+}
+
+func TestWorkerQueuesReportPerWorkerQueueLen(t *testing.T) {
+	mx := baseworker.NewMetricsRegistry("v", "", "", func() string { return "" })
+	mx.UpsertWorker("w1", "w1", "1", "", "", 1, 0, []string{"m"})
+	mx.UpsertWorker("w2", "w2", "1", "", "", 1, 0, []string{"m"})
+	wq := NewWorkerQueues(mx, 1)
+	q1 := wq.For("w1")
+	if wq.For("w1") != q1 {
+		t.Fatalf("expected the same queue for repeated lookups")
+	}
+	if pos, ok := q1.Enter("req-a", "m", spi.RequestSize{}); !ok || pos != 1 {
+		t.Fatalf("expected req-a at position 1, got %d ok=%v", pos, ok)
+	}
+	if _, ok := q1.Enter("req-b", "m", spi.RequestSize{}); ok {
+		t.Fatalf("expected per-worker capacity to reject req-b")
+	}
+	if _, ok := wq.For("w2").Enter("req-c", "m", spi.RequestSize{}); !ok {
+		t.Fatalf("expected w2 queue to be independent of w1")
+	}
+	snap := mx.Snapshot()
+	lens := map[string]int{}
+	for _, w := range snap.Workers {
+		lens[w.ID] = w.QueueLen
+	}
+	if lens["w1"] != 1 || lens["w2"] != 1 {
+		t.Fatalf("unexpected worker queue lengths %v", lens)
+	}
+	if snap.Server.SchedulerQueueLen != 0 || snap.Server.SchedulerQueueCapacity != 0 {
+		t.Fatalf("worker queues must not affect the scheduler queue: %+v", snap.Server)
+	}
+	q1.Leave("req-a")
+	if got := mx.Snapshot().Workers[0].QueueLen; got != 0 {
+		t.Fatalf("expected w1 queue_len 0 after leave, got %d", got)
+	}
+}
+
+func TestWorkerQueuesDropQueueOfRemovedWorker(t *testing.T) {
+	mx := baseworker.NewMetricsRegistry("v", "", "", func() string { return "" })
+	reg := baseworker.NewRegistry()
+	wq := NewWorkerQueues(mx, 2)
+	reg.OnRemove(wq.Remove)
+	reg.Add(&baseworker.Worker{ID: "w1", Send: make(chan interface{}, 1), Jobs: map[string]chan interface{}{}})
+	mx.UpsertWorker("w1", "w1", "1", "", "", 1, 0, []string{"m"})
+	stale := wq.For("w1")
+	if _, ok := stale.Enter("req-a", "m", spi.RequestSize{}); !ok {
+		t.Fatalf("expected req-a to be queued")
+	}
+	reg.Remove("w1")
+	if wq.For("w1") == stale {
+		t.Fatalf("expected the queue of a removed worker to be dropped")
+	}
+	// A worker reconnecting under the same ID must not see the stale queue.
+	if _, ok := wq.For("w1").Enter("req-b", "m", spi.RequestSize{}); !ok {
+		t.Fatalf("expected req-b to be queued")
+	}
+	stale.Leave("req-a")
+	if got := mx.Snapshot().Workers[0].QueueLen; got != 1 {
+		t.Fatalf("expected queue_len 1 from the new queue, got %d", got)
+	}
+}
+
+func TestCostBudgetRejectsOverLimitAndResetsPerWindow(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewCostBudget(1.0, time.Hour)
+	b.now = func() time.Time { return now }
+	first, ok := b.Reserve("k", 0.5)
+	if !ok {
+		t.Fatalf("expected first request to be allowed")
+	}
+	if _, ok := b.Reserve("k", 0.6); ok {
+		t.Fatalf("expected a concurrent request to count the outstanding reservation")
+	}
+	first.Settle(0.9)
+	first.Settle(0)
+	if b.Spent("k") != 0.9 {
+		t.Fatalf("expected spend to settle at the actual cost, spent %v", b.Spent("k"))
+	}
+	if _, ok := b.Reserve("k", 0.5); ok {
+		t.Fatalf("expected request over budget to be rejected")
+	}
+	if _, ok := b.Reserve("other", 0.5); !ok {
+		t.Fatalf("expected budgets to be tracked per key")
+	}
+	now = now.Add(time.Hour)
+	if _, ok := b.Reserve("k", 0.5); !ok || b.Spent("k") != 0.5 {
+		t.Fatalf("expected spend to reset after the window, spent %v", b.Spent("k"))
+	}
+}
+
+func TestCostBudgetRefundsUnsettledReservations(t *testing.T) {
+	b := NewCostBudget(1.0, 0)
+	r, ok := b.Reserve("k", 0.8)
+	if !ok {
+		t.Fatalf("expected request to be allowed")
+	}
+	r.Settle(0)
+	if b.Spent("k") != 0 {
+		t.Fatalf("expected refund, spent %v", b.Spent("k"))
+	}
+	if _, ok := b.Reserve("k", 0.8); !ok {
+		t.Fatalf("expected refunded budget to be available again")
+	}
+}
+
+func TestHedgePolicyDelayAndBudget(t *testing.T) {
+	h := NewHedgePolicy(90, 0.2, 100)
+	if _, ok := h.Delay(10); ok {
+		t.Fatalf("expected no hedging before enough samples")
+	}
+	for i := 1; i <= 20; i++ {
+		h.Observe(time.Duration(i) * time.Millisecond)
+	}
+	d, ok := h.Delay(10)
+	if !ok || d != 18*time.Millisecond {
+		t.Fatalf("unexpected p90 delay %v ok=%v", d, ok)
+	}
+	if _, ok := h.Delay(101); ok {
+		t.Fatalf("expected large prompts to be ineligible")
+	}
+	if !h.Acquire(10) || !h.Acquire(10) {
+		t.Fatalf("expected two hedges within 20%% of capacity 10")
+	}
+	if h.Acquire(10) {
+		t.Fatalf("expected hedge budget to be exhausted")
+	}
+	h.Release()
+	if !h.Acquire(10) {
+		t.Fatalf("expected a released slot to be reusable")
+	}
+}
@@ -0,0 +1,119 @@
+package openai
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
+)
+
+const (
+	// hedgeSampleWindow is the number of recent time-to-first-byte samples kept.
+	hedgeSampleWindow = 200
+	// hedgeMinSamples is the number of samples required before hedging starts.
+	hedgeMinSamples = 20
+)
+
+// capacityRegistry is optionally implemented by registries that can report the
+// total concurrency offered by connected workers.
+type capacityRegistry interface {
+	TotalCapacity() int
+}
+
+// HedgePolicy decides when a slow request is duplicated to a second worker.
+// The hedge delay is a percentile of recent time-to-first-byte samples, and the
+// number of concurrent hedges is capped at a fraction of total capacity.
+type HedgePolicy struct {
+	mu              sync.Mutex
+	percentile      float64
+	budget          float64
+	maxPromptTokens int
+	samples         []time.Duration
+	next            int
+	active          int
+}
+
+// NewHedgePolicy returns a policy hedging after the given percentile (0-100) of
+// recent time-to-first-byte, using at most budget (0-1) of total capacity for
+// hedges, for prompts up to maxPromptTokens (0 means any size).
+func NewHedgePolicy(percentile, budget float64, maxPromptTokens int) *HedgePolicy {
+	return &HedgePolicy{percentile: percentile, budget: budget, maxPromptTokens: maxPromptTokens}
+}
+
+// Observe records a time-to-first-byte sample.
+func (h *HedgePolicy) Observe(d time.Duration) {
+	if h == nil || d <= 0 {
+		return
+	}
+	h.mu.Lock()
+	if len(h.samples) < hedgeSampleWindow {
+		h.samples = append(h.samples, d)
+	} else {
+		h.samples[h.next] = d
+		h.next = (h.next + 1) % hedgeSampleWindow
+	}
+	h.mu.Unlock()
+}
+
+// Delay returns how long to wait for a first byte before hedging a request
+// with the given prompt size. It returns false when the request is not
+// eligible or not enough samples have been observed.
+func (h *HedgePolicy) Delay(promptTokens int) (time.Duration, bool) {
+	if h == nil || h.percentile <= 0 {
+		return 0, false
+	}
+	if h.maxPromptTokens > 0 && promptTokens > h.maxPromptTokens {
+		return 0, false
+	}
+	h.mu.Lock()
+	sorted := append([]time.Duration(nil), h.samples...)
+	h.mu.Unlock()
+	if len(sorted) < hedgeMinSamples {
+		return 0, false
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(float64(len(sorted)-1) * min(h.percentile, 100) / 100)
+	return sorted[idx], true
+}
+
+// Acquire reserves a hedge slot if fewer than budget*capacity hedges are active.
+func (h *HedgePolicy) Acquire(capacity int) bool {
+	if h == nil {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if float64(h.active+1) > h.budget*float64(capacity) {
+		return false
+	}
+	h.active++
+	return true
+}
+
+// Release frees a slot reserved by Acquire.
+func (h *HedgePolicy) Release() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	if h.active > 0 {
+		h.active--
+	}
+	h.mu.Unlock()
+}
+
+// hedgeWorker picks the least busy worker other than primary that serves model
+// and fits size.
+func hedgeWorker(reg spi.WorkerRegistry, model string, size spi.RequestSize, primary string) spi.WorkerRef {
+	var best spi.WorkerRef
+	for _, w := range reg.WorkersForLabel(model) {
+		if w.ID() == primary || !anyWorkerFits([]spi.WorkerRef{w}, model, size) {
+			continue
+		}
+		if best == nil || w.InFlight() < best.InFlight() {
+			best = w
+		}
+	}
+	return best
+}
@@ -1,6 +1,8 @@
 package openai
 
 import (
+	"net/http"
+
 	"github.com/gaspardpetit/nfrx/sdk/api/spi"
 	baseworker "github.com/gaspardpetit/nfrx/sdk/base/worker"
 )
@@ -22,10 +24,24 @@ func Mount(v1 spi.Router, reg spi.WorkerRegistry, sched spi.Scheduler, metrics s
 	}
 }
 
+// MountBatches wires the OpenAI-compatible files and batches endpoints.
+func MountBatches(v1 spi.Router, b *Batches) {
+	files := b.Files()
+	v1.Post("/files", http.HandlerFunc(files.HandleUpload))
+	v1.Get("/files", http.HandlerFunc(files.HandleList))
+	v1.Get("/files/{file_id}", http.HandlerFunc(files.HandleGet))
+	v1.Get("/files/{file_id}/content", http.HandlerFunc(files.HandleContent))
+	v1.Post("/batches", http.HandlerFunc(b.HandleCreate))
+	v1.Get("/batches", http.HandlerFunc(b.HandleList))
+	v1.Get("/batches/{batch_id}", http.HandlerFunc(b.HandleGet))
+	v1.Post("/batches/{batch_id}/cancel", http.HandlerFunc(b.HandleCancel))
+}
+
 // MountTargeted wires worker-targeted OpenAI-compatible endpoints under /id/{id}/v1.
-func MountTargeted(v1 spi.Router, reg spi.WorkerRegistry, metrics spi.Metrics, opts Options, queue *CompletionQueue) {
-	v1.Post("/chat/completions", TargetedChatCompletionsHandler(reg, metrics, opts, queue))
-	v1.Post("/responses", TargetedResponsesHandler(reg, metrics, opts, queue))
+// Generative requests wait in the targeted worker's own queue when it is busy.
+func MountTargeted(v1 spi.Router, reg spi.WorkerRegistry, metrics spi.Metrics, opts Options, queues *WorkerQueues) {
+	v1.Post("/chat/completions", TargetedChatCompletionsHandler(reg, metrics, opts, queues))
+	v1.Post("/responses", TargetedResponsesHandler(reg, metrics, opts, queues))
 	v1.Post("/embeddings", TargetedEmbeddingsHandler(reg, metrics, opts.RequestTimeout, opts.MaxParallelEmbeddings))
 	v1.Get("/models", TargetedListModelsHandler(reg))
 	v1.Get("/models/{model}", TargetedGetModelHandler(reg))
@@ -8,6 +8,18 @@ type Options struct {
 	MaxParallelEmbeddings int
 	// QueueSize caps the number of queued chat completion requests (0 disables queuing).
 	QueueSize int
+	// WorkerQueueSize caps the number of queued requests per worker on targeted routes (0 disables queuing).
+	WorkerQueueSize int
 	// QueueUpdateSeconds controls how often to emit queued status SSE lines (0 disables updates).
 	QueueUpdateSeconds int
+	// Budget optionally caps computed request cost per API key (nil disables budgeting).
+	Budget *CostBudget
+	// Hedge optionally duplicates slow requests to a second worker (nil disables hedging).
+	Hedge *HedgePolicy
+	// BatchConcurrency caps the requests of one batch in flight at a time.
+	BatchConcurrency int
+	// BatchMaxFileBytes caps the size of uploaded batch files (0 means no limit).
+	BatchMaxFileBytes int64
+	// BatchRetention is how long files and finished batches are kept (0 keeps them).
+	BatchRetention time.Duration
 }
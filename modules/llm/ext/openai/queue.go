@@ -3,6 +3,7 @@ package openai
 import (
 	"sync"
 
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
 	baseworker "github.com/gaspardpetit/nfrx/sdk/base/worker"
 )
 
@@ -13,11 +14,15 @@ type CompletionQueue struct {
 	items []queuedRequest
 	cap   int
 	mx    *baseworker.MetricsRegistry
+	// workerID scopes the queue to a single worker; its length is then reported
+	// as that worker's queue_len instead of the scheduler queue length.
+	workerID string
 }
 
 type queuedRequest struct {
 	id    string
 	model string
+	size  spi.RequestSize
 }
 
 func NewCompletionQueue(mx *baseworker.MetricsRegistry, capacity int) *CompletionQueue {
@@ -29,15 +34,27 @@ func NewCompletionQueue(mx *baseworker.MetricsRegistry, capacity int) *Completio
 func (q *CompletionQueue) SetCapacity(n int) {
 	q.mu.Lock()
 	q.cap = n
-	if q.mx != nil {
+	if q.mx != nil && q.workerID == "" {
 		q.mx.SetSchedulerQueueCapacity(n)
-		q.mx.SetSchedulerQueueLen(len(q.items))
 	}
+	q.reportLenLocked()
 	q.mu.Unlock()
 }
 
-// Enter enqueues id if capacity allows; returns 1-based position and ok.
-func (q *CompletionQueue) Enter(id, model string) (int, bool) {
+func (q *CompletionQueue) reportLenLocked() {
+	if q.mx == nil {
+		return
+	}
+	if q.workerID != "" {
+		q.mx.SetWorkerQueueLen(q.workerID, len(q.items))
+		return
+	}
+	q.mx.SetSchedulerQueueLen(len(q.items))
+}
+
+// Enter enqueues id, a request of the given size for model, if capacity
+// allows; returns 1-based position and ok.
+func (q *CompletionQueue) Enter(id, model string, size spi.RequestSize) (int, bool) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	if q.cap <= 0 {
@@ -46,10 +63,8 @@ func (q *CompletionQueue) Enter(id, model string) (int, bool) {
 	if len(q.items) >= q.cap {
 		return 0, false
 	}
-	q.items = append(q.items, queuedRequest{id: id, model: model})
-	if q.mx != nil {
-		q.mx.SetSchedulerQueueLen(len(q.items))
-	}
+	q.items = append(q.items, queuedRequest{id: id, model: model, size: size})
+	q.reportLenLocked()
 	return len(q.items), true
 }
 
@@ -60,9 +75,7 @@ func (q *CompletionQueue) Leave(id string) {
 	for i, v := range q.items {
 		if v.id == id {
 			q.items = append(q.items[:i], q.items[i+1:]...)
-			if q.mx != nil {
-				q.mx.SetSchedulerQueueLen(len(q.items))
-			}
+			q.reportLenLocked()
 			return
 		}
 	}
@@ -82,11 +95,11 @@ func (q *CompletionQueue) Position(id string) int {
 
 // IsFirstDispatchable reports whether id is the first queue entry that satisfies canDispatch.
 // Entries earlier in the queue that are not currently dispatchable do not block later compatible entries.
-func (q *CompletionQueue) IsFirstDispatchable(id string, canDispatch func(model string) bool) bool {
+func (q *CompletionQueue) IsFirstDispatchable(id string, canDispatch func(model string, size spi.RequestSize) bool) bool {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	for _, item := range q.items {
-		if !canDispatch(item.model) {
+		if !canDispatch(item.model, item.size) {
 			continue
 		}
 		return item.id == id
@@ -100,3 +113,52 @@ func (q *CompletionQueue) Len() int {
 	defer q.mu.Unlock()
 	return len(q.items)
 }
+
+// WorkerQueues holds one bounded FIFO queue per worker for requests targeted
+// at a specific worker under /id/{id}/v1. Each queue reports its length as the
+// worker's queue_len in the state snapshot.
+type WorkerQueues struct {
+	mu     sync.Mutex
+	queues map[string]*CompletionQueue
+	cap    int
+	mx     *baseworker.MetricsRegistry
+}
+
+func NewWorkerQueues(mx *baseworker.MetricsRegistry, capacity int) *WorkerQueues {
+	return &WorkerQueues{queues: make(map[string]*CompletionQueue), cap: capacity, mx: mx}
+}
+
+// For returns the queue for worker id, creating it on first use.
+// A nil WorkerQueues yields a nil queue, which disables targeted queueing.
+func (wq *WorkerQueues) For(id string) *CompletionQueue {
+	if wq == nil {
+		return nil
+	}
+	wq.mu.Lock()
+	defer wq.mu.Unlock()
+	q := wq.queues[id]
+	if q == nil {
+		q = &CompletionQueue{mx: wq.mx, workerID: id}
+		q.SetCapacity(wq.cap)
+		wq.queues[id] = q
+	}
+	return q
+}
+
+// Remove drops the queue of worker id once the worker left the registry.
+// Requests still waiting in it no longer report their length for id, which
+// would otherwise land on a worker reconnecting under the same ID.
+func (wq *WorkerQueues) Remove(id string) {
+	if wq == nil {
+		return
+	}
+	wq.mu.Lock()
+	q := wq.queues[id]
+	delete(wq.queues, id)
+	wq.mu.Unlock()
+	if q != nil {
+		q.mu.Lock()
+		q.mx = nil
+		q.mu.Unlock()
+	}
+}
@@ -27,6 +27,28 @@ func (r targetedRegistry) WorkersForLabel(label string) []spi.WorkerRef {
 	return out
 }
 
+func (r targetedRegistry) WorkersServing(label string) []spi.WorkerRef {
+	var out []spi.WorkerRef
+	for _, w := range servingWorkers(r.base, label) {
+		if w.ID() == r.targetID {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+func (r targetedRegistry) ObserveLatency(id, model string, d time.Duration) {
+	if lo, ok := r.base.(latencyObserver); ok {
+		lo.ObserveLatency(id, model, d)
+	}
+}
+
+func (r targetedRegistry) ObserveFailure(id, model string) {
+	if fo, ok := r.base.(failureObserver); ok {
+		fo.ObserveFailure(id, model)
+	}
+}
+
 func (r targetedRegistry) IncInFlight(id string) { r.base.IncInFlight(id) }
 func (r targetedRegistry) DecInFlight(id string) { r.base.DecInFlight(id) }
 func (r targetedRegistry) AggregatedModels() []spi.ModelInfo {
@@ -56,12 +78,21 @@ type targetedScheduler struct {
 }
 
 func (s targetedScheduler) PickWorker(model string) (spi.WorkerRef, error) {
-	workers := s.reg.WorkersForLabel(model)
-	if len(workers) == 0 {
+	return s.PickWorkerFor(model, spi.RequestSize{})
+}
+
+func (s targetedScheduler) PickWorkerFor(model string, size spi.RequestSize) (spi.WorkerRef, error) {
+	var fitting []spi.WorkerRef
+	for _, w := range s.reg.WorkersForLabel(model) {
+		if anyWorkerFits([]spi.WorkerRef{w}, model, size) {
+			fitting = append(fitting, w)
+		}
+	}
+	if len(fitting) == 0 {
 		return nil, errors.New("no worker")
 	}
-	best := workers[0]
-	for _, w := range workers[1:] {
+	best := fitting[0]
+	for _, w := range fitting[1:] {
 		if w.InFlight() < best.InFlight() {
 			best = w
 		}
@@ -75,6 +106,12 @@ func targetFromRequest(reg spi.WorkerRegistry, r *http.Request) (spi.WorkerRegis
 	return tr, targetedScheduler{reg: tr, targetID: id}, id
 }
 
+// targetedOptions bounds queueing for targeted requests by the per-worker queue size.
+func targetedOptions(opts Options) Options {
+	opts.QueueSize = opts.WorkerQueueSize
+	return opts
+}
+
 func TargetedListModelsHandler(reg spi.WorkerRegistry) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tr, _, id := targetFromRequest(reg, r)
@@ -111,25 +148,25 @@ func TargetedGetModelHandler(reg spi.WorkerRegistry) http.HandlerFunc {
 	}
 }
 
-func TargetedChatCompletionsHandler(reg spi.WorkerRegistry, metrics spi.Metrics, opts Options, queue *CompletionQueue) http.HandlerFunc {
+func TargetedChatCompletionsHandler(reg spi.WorkerRegistry, metrics spi.Metrics, opts Options, queues *WorkerQueues) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tr, ts, id := targetFromRequest(reg, r)
 		if !tr.HasWorker(id) {
 			http.Error(w, "no worker", http.StatusNotFound)
 			return
 		}
-		ChatCompletionsHandler(tr, ts, metrics, opts, queue).ServeHTTP(w, r)
+		ChatCompletionsHandler(tr, ts, metrics, targetedOptions(opts), queues.For(id)).ServeHTTP(w, r)
 	}
 }
 
-func TargetedResponsesHandler(reg spi.WorkerRegistry, metrics spi.Metrics, opts Options, queue *CompletionQueue) http.HandlerFunc {
+func TargetedResponsesHandler(reg spi.WorkerRegistry, metrics spi.Metrics, opts Options, queues *WorkerQueues) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tr, ts, id := targetFromRequest(reg, r)
 		if !tr.HasWorker(id) {
 			http.Error(w, "no worker", http.StatusNotFound)
 			return
 		}
-		ResponsesHandler(tr, ts, metrics, opts, queue).ServeHTTP(w, r)
+		ResponsesHandler(tr, ts, metrics, targetedOptions(opts), queues.For(id)).ServeHTTP(w, r)
 	}
 }
 
@@ -32,12 +32,13 @@ func Run(ctx context.Context, cfg aconfig.MCPConfig) error {
 
 	return agent.RunWithReconnect(ctx, cfg.Reconnect, func(runCtx context.Context) error {
 		// Send client key as Authorization bearer header when present (for proxy auth)
-		var dialOpts *websocket.DialOptions
+		// Offer permessage-deflate; servers that do not support it ignore the offer.
+		dialOpts := &websocket.DialOptions{CompressionMode: websocket.CompressionNoContextTakeover}
 		hasAuth := false
 		if cfg.ClientKey != "" {
 			hdr := make(http.Header)
 			hdr.Set("Authorization", "Bearer "+cfg.ClientKey)
-			dialOpts = &websocket.DialOptions{HTTPHeader: hdr}
+			dialOpts.HTTPHeader = hdr
 			hasAuth = true
 		}
 		logx.Log.Info().Str("server", cfg.ServerURL).Bool("auth_header", hasAuth).Msg("dialing server")
@@ -16,6 +16,17 @@ type RegisterMessage struct {
 	// AgentConfig carries optional, extension-specific config values.
 	// Prefer snake_case keys; values should be JSON-encodable strings.
 	AgentConfig map[string]string `json:"agent_config,omitempty"`
+	// ChunkEncodings lists the encodings the agent can apply to response
+	// chunks, preferred first.
+	ChunkEncodings []string `json:"chunk_encodings,omitempty"`
+}
+
+// RegisterAckMessage is the server's first message to a registered agent.
+// ChunkEncoding is the encoding the agent may apply to the data of its
+// http_proxy_response_chunk messages; empty means none.
+type RegisterAckMessage struct {
+	Type          string `json:"type"`
+	ChunkEncoding string `json:"chunk_encoding,omitempty"`
 }
 
 type StatusUpdateMessage struct {
@@ -78,6 +89,23 @@ type HTTPProxyRequestMessage struct {
 	Headers   map[string]string `json:"headers,omitempty"`
 	Stream    bool              `json:"stream,omitempty"`
 	Body      []byte            `json:"body,omitempty"`
+	// BodyStream announces that the body follows in http_proxy_request_chunk
+	// messages, ended by an http_proxy_request_end message, instead of Body.
+	BodyStream bool `json:"body_stream,omitempty"`
+}
+
+type HTTPProxyRequestChunkMessage struct {
+	Type      string `json:"type"`
+	RequestID string `json:"request_id"`
+	Data      []byte `json:"data"`
+}
+
+// HTTPProxyRequestEndMessage ends a streamed request body; Error aborts the
+// request instead.
+type HTTPProxyRequestEndMessage struct {
+	Type      string          `json:"type"`
+	RequestID string          `json:"request_id"`
+	Error     *HTTPProxyError `json:"error,omitempty"`
 }
 
 type HTTPProxyResponseHeadersMessage struct {
@@ -91,6 +119,9 @@ type HTTPProxyResponseChunkMessage struct {
 	Type      string `json:"type"`
 	RequestID string `json:"request_id"`
 	Data      []byte `json:"data"`
+	// Encoding is set when Data is compressed with the encoding agreed at
+	// registration. The server decodes chunks before handing them on.
+	Encoding string `json:"encoding,omitempty"`
 }
 
 type HTTPProxyError struct {
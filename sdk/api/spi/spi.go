@@ -1,6 +1,10 @@
 package spi
 
-import "time"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 type WorkerRef interface {
 	ID() string
@@ -13,6 +17,54 @@ type WorkerRef interface {
 	InFlight() int
 }
 
+// WorkerPricing is the cost and power metadata a worker declares in its agent
+// config. Prices are expressed per 1k tokens in the operator's currency.
+type WorkerPricing struct {
+	InputPer1K  float64
+	OutputPer1K float64
+	PowerWatts  float64
+}
+
+// Cost returns the price of a request that consumed the given token counts.
+func (p WorkerPricing) Cost(tokensIn, tokensOut uint64) float64 {
+	return float64(tokensIn)/1000*p.InputPer1K + float64(tokensOut)/1000*p.OutputPer1K
+}
+
+// PricedWorker is optionally implemented by WorkerRef values that declare pricing.
+type PricedWorker interface {
+	Pricing() WorkerPricing
+}
+
+// ModelLimits is the token capacity a worker advertises for one model.
+// Zero values mean the limit is unknown.
+type ModelLimits struct {
+	ContextLength   int `json:"context_length,omitempty"`
+	MaxOutputTokens int `json:"max_output_tokens,omitempty"`
+}
+
+// RequestSize is the estimated token footprint of a request.
+type RequestSize struct {
+	PromptTokens    int
+	MaxOutputTokens int
+}
+
+// Fits reports whether a request of the given size can be served within l.
+func (l ModelLimits) Fits(sz RequestSize) bool {
+	if l.ContextLength > 0 && sz.PromptTokens+sz.MaxOutputTokens > l.ContextLength {
+		return false
+	}
+	if l.MaxOutputTokens > 0 && sz.MaxOutputTokens > l.MaxOutputTokens {
+		return false
+	}
+	return true
+}
+
+// LimitedWorker is optionally implemented by WorkerRef values that advertise
+// per-model limits.
+type LimitedWorker interface {
+	ModelLimits(model string) (ModelLimits, bool)
+}
+
 type ModelInfo struct {
 	ID      string
 	Created int64
@@ -29,10 +81,23 @@ type WorkerRegistry interface {
 	WorkerModels(id string) []ModelInfo
 }
 
+// ServingRegistry is optionally implemented by registries that can list every
+// connected worker serving a label, whatever its current load.
+// WorkersForLabel only returns workers with free capacity.
+type ServingRegistry interface {
+	WorkersServing(label string) []WorkerRef
+}
+
 type Scheduler interface {
 	PickWorker(model string) (WorkerRef, error)
 }
 
+// SizedScheduler is optionally implemented by schedulers that can exclude
+// workers whose model limits cannot fit a request.
+type SizedScheduler interface {
+	PickWorkerFor(model string, size RequestSize) (WorkerRef, error)
+}
+
 // PartitionJob describes a request that can be split into multiple independent
 // chunks and recombined. Implemented by extensions that support partitioning.
 type PartitionJob interface {
@@ -107,6 +172,44 @@ type ServerState interface {
 	SetStatus(status string)
 }
 
+// JobTracker records work that a plugin runs inside the server, such as an
+// LLM batch, as a job so that its progress is visible through the jobs API
+// and it can be canceled there.
+type JobTracker interface {
+	// Track registers a running job of the given type and returns its ID.
+	// cancel is called when the job is canceled through the jobs API.
+	Track(jobType string, metadata map[string]any, cancel func()) string
+	// Progress replaces the progress reported for a tracked job.
+	Progress(id string, progress map[string]any)
+	// Finish moves a tracked job to "completed", "failed" or "canceled";
+	// errCode and errMsg describe a failure.
+	Finish(id, status, errCode, errMsg string)
+}
+
+// JobTrackerUser is implemented by plugins that report work through a
+// JobTracker.
+type JobTrackerUser interface {
+	SetJobTracker(t JobTracker)
+}
+
+// TransferRelay is implemented by plugins whose agents can carry transfer
+// bodies over their control connection, for workers that cannot reach the
+// transfer API themselves.
+type TransferRelay interface {
+	// HasWorker reports whether the agent with the given worker ID is
+	// connected.
+	HasWorker(workerID string) bool
+	// Relay performs an HTTP request on the agent's backend, streaming body
+	// (when not nil) as the request body and the response body into dst.
+	Relay(ctx context.Context, workerID, method, path string, headers map[string]string, body io.Reader, dst io.Writer) error
+}
+
+// TransferRelayProvider is implemented by plugins that relay transfers to
+// their agents.
+type TransferRelayProvider interface {
+	TransferRelay() TransferRelay
+}
+
 type MCPClientSnapshot struct {
 	ID        string         `json:"id"`
 	Name      string         `json:"name"`
@@ -1,7 +1,6 @@
 package workerproxy
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"io"
@@ -11,9 +10,14 @@ import (
 
 	"github.com/gaspardpetit/nfrx/core/logx"
 	ctrl "github.com/gaspardpetit/nfrx/sdk/api/control"
+	"github.com/gaspardpetit/nfrx/sdk/base/chunkenc"
 )
 
-func handleHTTPProxy(ctx context.Context, cfg Config, sendCh chan []byte, req ctrl.HTTPProxyRequestMessage, cancels map[string]context.CancelFunc, mu *sync.Mutex, onDone func()) {
+// handleHTTPProxy performs req on the backend with body, which is req.Body or
+// the requestBody fed by the request's chunk messages, and streams the
+// response back, compressing chunks with chunkEnc when it is set. A streamed
+// body is aborted and removed from bodies once the request is over.
+func handleHTTPProxy(ctx context.Context, cfg Config, sendCh chan []byte, req ctrl.HTTPProxyRequestMessage, body io.Reader, chunkEnc string, cancels map[string]context.CancelFunc, bodies map[string]*requestBody, mu *sync.Mutex, onDone func()) {
 	reqCtx, cancel := context.WithCancel(ctx)
 	mu.Lock()
 	cancels[req.RequestID] = cancel
@@ -23,6 +27,13 @@ func handleHTTPProxy(ctx context.Context, cfg Config, sendCh chan []byte, req ct
 		cancel()
 		mu.Lock()
 		delete(cancels, req.RequestID)
+		if rb, ok := body.(*requestBody); ok {
+			// Drops chunks still arriving for a request that is over.
+			rb.abort(context.Canceled)
+			if bodies[req.RequestID] == rb {
+				delete(bodies, req.RequestID)
+			}
+		}
 		mu.Unlock()
 		_ = DecJobs()
 		onDone()
@@ -38,7 +49,7 @@ func handleHTTPProxy(ctx context.Context, cfg Config, sendCh chan []byte, req ct
 			Bytes("body", req.Body).
 			Msg("proxy request")
 	}
-	httpReq, err := http.NewRequestWithContext(reqCtx, req.Method, url, bytes.NewReader(req.Body))
+	httpReq, err := http.NewRequestWithContext(reqCtx, req.Method, url, body)
 	if err != nil {
 		sendProxyError(reqCtx, req.RequestID, req.Method, url, sendCh, err)
 		return
@@ -89,7 +100,8 @@ func handleHTTPProxy(ctx context.Context, cfg Config, sendCh chan []byte, req ct
 	for {
 		n, err := resp.Body.Read(buf)
 		if n > 0 {
-			cmsg := ctrl.HTTPProxyResponseChunkMessage{Type: "http_proxy_response_chunk", RequestID: req.RequestID, Data: append([]byte(nil), buf[:n]...)}
+			cmsg := ctrl.HTTPProxyResponseChunkMessage{Type: "http_proxy_response_chunk", RequestID: req.RequestID}
+			cmsg.Data, cmsg.Encoding = chunkenc.Encode(chunkEnc, buf[:n])
 			bb, _ := json.Marshal(cmsg)
 			sendMsg(reqCtx, sendCh, bb)
 			if evt := logx.Log.Debug(); evt.Enabled() {
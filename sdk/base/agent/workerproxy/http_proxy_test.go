@@ -1,14 +1,17 @@
 package workerproxy
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"sync"
 	"testing"
 
 	ctrl "github.com/gaspardpetit/nfrx/sdk/api/control"
+	"github.com/gaspardpetit/nfrx/sdk/base/chunkenc"
 )
 
 func TestHandleHTTPProxy_AuthAndStreaming(t *testing.T) {
@@ -40,7 +43,7 @@ func TestHandleHTTPProxy_AuthAndStreaming(t *testing.T) {
 	var mu sync.Mutex
 	req := ctrl.HTTPProxyRequestMessage{Type: "http_proxy_request", RequestID: "r1", Method: http.MethodGet, Path: "/stream", Headers: map[string]string{"Accept": "text/event-stream"}, Stream: true}
 	ctx := context.Background()
-	go handleHTTPProxy(ctx, cfg, sendCh, req, cancels, &mu, func() {})
+	go handleHTTPProxy(ctx, cfg, sendCh, req, bytes.NewReader(req.Body), "", cancels, nil, &mu, func() {})
 
 	// Read headers
 	b := <-sendCh
@@ -80,3 +83,99 @@ done:
 		t.Fatalf("auth %q", gotAuth)
 	}
 }
+
+func TestHandleHTTPProxy_CompressesChunks(t *testing.T) {
+	payload := bytes.Repeat([]byte(`{"embedding":[0.125,0.25,0.5]},`), 200)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(payload)
+	}))
+	defer upstream.Close()
+
+	for _, enc := range chunkenc.Supported {
+		sendCh := make(chan []byte, 16)
+		cancels := make(map[string]context.CancelFunc)
+		var mu sync.Mutex
+		req := ctrl.HTTPProxyRequestMessage{Type: "http_proxy_request", RequestID: "r1", Method: http.MethodPost, Path: "/embeddings"}
+		go handleHTTPProxy(context.Background(), Config{BaseURL: upstream.URL}, sendCh, req, bytes.NewReader(nil), enc, cancels, nil, &mu, func() {})
+
+		var body []byte
+		wire := 0
+	loop:
+		for b := range sendCh {
+			var c ctrl.HTTPProxyResponseChunkMessage
+			if err := json.Unmarshal(b, &c); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			switch c.Type {
+			case "http_proxy_response_chunk":
+				if c.Encoding != enc {
+					t.Fatalf("%s: chunk encoding %q", enc, c.Encoding)
+				}
+				data, err := chunkenc.Decode(c.Encoding, c.Data)
+				if err != nil {
+					t.Fatalf("%s: decode: %v", enc, err)
+				}
+				wire += len(c.Data)
+				body = append(body, data...)
+			case "http_proxy_response_end":
+				break loop
+			}
+		}
+		if !bytes.Equal(body, payload) {
+			t.Fatalf("%s: body of %d bytes, want %d", enc, len(body), len(payload))
+		}
+		if wire >= len(payload)/4 {
+			t.Fatalf("%s: %d bytes on the wire for %d", enc, wire, len(payload))
+		}
+	}
+}
+
+func TestStreamedRequestBodyNeverBlocksTheReader(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A backend that does not read its body until released.
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	rb := newRequestBody()
+	bodies := map[string]*requestBody{"r1": rb}
+	cancels := make(map[string]context.CancelFunc)
+	var mu sync.Mutex
+	done := make(chan struct{})
+	req := ctrl.HTTPProxyRequestMessage{Type: "http_proxy_request", RequestID: "r1", Method: http.MethodPost, Path: "/", BodyStream: true}
+	go handleHTTPProxy(context.Background(), Config{BaseURL: upstream.URL}, make(chan []byte, 16), req, rb, "", cancels, bodies, &mu, func() { close(done) })
+
+	chunk := bytes.Repeat([]byte("x"), 64<<10)
+	overflowed := false
+	for i := 0; i < 10*requestBodyChunks && !overflowed; i++ {
+		overflowed = !rb.push(chunk)
+	}
+	if !overflowed {
+		t.Fatalf("expected pushes to a stalled backend to overflow instead of blocking")
+	}
+	rb.abort(errRequestBodyOverflow)
+	<-done
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 0 || len(cancels) != 0 {
+		t.Fatalf("expected the finished request to be forgotten, got bodies=%v cancels=%v", bodies, cancels)
+	}
+}
+
+func TestStreamedRequestBodyDeliversChunksInOrder(t *testing.T) {
+	rb := newRequestBody()
+	for _, c := range []string{"a", "b", "c"} {
+		if !rb.push([]byte(c)) {
+			t.Fatalf("push %q overflowed", c)
+		}
+	}
+	rb.end(nil)
+	got, err := io.ReadAll(rb)
+	if err != nil || string(got) != "abc" {
+		t.Fatalf("read %q %v", got, err)
+	}
+}
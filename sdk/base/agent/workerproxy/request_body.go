@@ -0,0 +1,77 @@
+package workerproxy
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// requestBodyChunks bounds the chunks buffered for a streamed request body
+// whose backend is not reading yet.
+const requestBodyChunks = 64
+
+var errRequestBodyOverflow = errors.New("request body overflow: backend is not reading")
+
+// requestBody is a streamed request body fed by http_proxy_request_chunk
+// messages. Chunks are handed to a goroutine writing them to the backend, so
+// a backend reading slowly never holds back the connection's read loop.
+type requestBody struct {
+	pr     *io.PipeReader
+	pw     *io.PipeWriter
+	chunks chan []byte
+	endErr error
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newRequestBody() *requestBody {
+	pr, pw := io.Pipe()
+	b := &requestBody{pr: pr, pw: pw, chunks: make(chan []byte, requestBodyChunks), done: make(chan struct{})}
+	go b.feed()
+	return b
+}
+
+func (b *requestBody) Read(p []byte) (int, error) { return b.pr.Read(p) }
+
+func (b *requestBody) feed() {
+	for {
+		select {
+		case <-b.done:
+			return
+		case data, ok := <-b.chunks:
+			if !ok {
+				_ = b.pw.CloseWithError(b.endErr)
+				return
+			}
+			if _, err := b.pw.Write(data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// push queues a chunk without blocking; it reports false once the buffer is
+// full, in which case the caller drops the request.
+func (b *requestBody) push(data []byte) bool {
+	select {
+	case b.chunks <- data:
+		return true
+	default:
+		return false
+	}
+}
+
+// end marks the body complete once the queued chunks are written, or failed
+// with err. Only the read loop calls push and end.
+func (b *requestBody) end(err error) {
+	b.endErr = err
+	close(b.chunks)
+}
+
+// abort fails the body for the backend and stops feeding it.
+func (b *requestBody) abort(err error) {
+	b.once.Do(func() {
+		close(b.done)
+		_ = b.pr.CloseWithError(err)
+	})
+}
@@ -1,10 +1,12 @@
 package workerproxy
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"sync"
 	"time"
@@ -15,6 +17,7 @@ import (
 	ctrl "github.com/gaspardpetit/nfrx/sdk/api/control"
 	"github.com/gaspardpetit/nfrx/sdk/base/agent"
 	dr "github.com/gaspardpetit/nfrx/sdk/base/agent/drain"
+	"github.com/gaspardpetit/nfrx/sdk/base/chunkenc"
 )
 
 // Run starts the generic worker HTTP-proxy agent using the provided config.
@@ -225,12 +228,13 @@ func errIfNil(err error, resp *http.Response) error {
 func connectAndServe(ctx context.Context, cancelAll context.CancelFunc, cfg Config, statusUpdates <-chan ctrl.StatusUpdateMessage) (bool, error) {
 	connCtx, cancelConn := context.WithCancel(ctx)
 	// When a client key is configured, send it as an Authorization bearer header for proxies expecting header-based auth
-	var dialOpts *websocket.DialOptions
+	// Offer permessage-deflate; servers that do not support it ignore the offer.
+	dialOpts := &websocket.DialOptions{CompressionMode: websocket.CompressionNoContextTakeover}
 	hasAuth := false
 	if cfg.ClientKey != "" {
 		hdr := make(http.Header)
 		hdr.Set("Authorization", "Bearer "+cfg.ClientKey)
-		dialOpts = &websocket.DialOptions{HTTPHeader: hdr}
+		dialOpts.HTTPHeader = hdr
 		hasAuth = true
 	}
 	logx.Log.Info().Str("server", cfg.ServerURL).Bool("auth_header", hasAuth).Msg("dialing server")
@@ -264,7 +268,7 @@ func connectAndServe(ctx context.Context, cancelAll context.CancelFunc, cfg Conf
 
 	// Populate AgentConfig for extensible values
 	agentCfg := currentAgentConfig(cfg)
-	regMsg := ctrl.RegisterMessage{Type: "register", WorkerID: cfg.ClientID, WorkerName: cfg.ClientName, Models: GetState().Labels, MaxConcurrency: GetState().MaxConcurrency, AgentConfig: agentCfg}
+	regMsg := ctrl.RegisterMessage{Type: "register", WorkerID: cfg.ClientID, WorkerName: cfg.ClientName, Models: GetState().Labels, MaxConcurrency: GetState().MaxConcurrency, AgentConfig: agentCfg, ChunkEncodings: chunkenc.Supported}
 	vi := GetVersionInfo()
 	regMsg.Version = vi.Version
 	regMsg.BuildSHA = vi.BuildSHA
@@ -364,7 +368,12 @@ func connectAndServe(ctx context.Context, cancelAll context.CancelFunc, cfg Conf
 
 	// Shared cancel registry for in-flight proxy requests
 	reqCancels := make(map[string]context.CancelFunc)
+	// Streamed request bodies, fed by http_proxy_request_chunk messages
+	reqBodies := make(map[string]*requestBody)
 	var jobMu sync.Mutex
+	// Response chunk encoding agreed in the server's register_ack; servers
+	// without one never send it, leaving chunks uncompressed.
+	chunkEnc := ""
 
 	for {
 		_, data, err := ws.Read(connCtx)
@@ -395,6 +404,14 @@ func connectAndServe(ctx context.Context, cancelAll context.CancelFunc, cfg Conf
 			continue
 		}
 		switch env.Type {
+		case "register_ack":
+			var ack ctrl.RegisterAckMessage
+			if err := json.Unmarshal(data, &ack); err != nil {
+				logx.Log.Warn().Err(err).Str("type", env.Type).Str("body", summarizeBody(data, 512)).Msg("server message decode failed")
+				continue
+			}
+			chunkEnc = ack.ChunkEncoding
+			logx.Log.Info().Str("chunk_encoding", chunkEnc).Msg("registration acknowledged")
 		case "http_proxy_request":
 			var hr ctrl.HTTPProxyRequestMessage
 			if err := json.Unmarshal(data, &hr); err != nil {
@@ -410,7 +427,48 @@ func connectAndServe(ctx context.Context, cancelAll context.CancelFunc, cfg Conf
 				sendMsg(connCtx, sendCh, eb)
 				continue
 			}
-			go handleHTTPProxy(connCtx, cfg, sendCh, hr, reqCancels, &jobMu, checkDrain)
+			var body io.Reader = bytes.NewReader(hr.Body)
+			if hr.BodyStream {
+				rb := newRequestBody()
+				jobMu.Lock()
+				reqBodies[hr.RequestID] = rb
+				jobMu.Unlock()
+				body = rb
+			}
+			go handleHTTPProxy(connCtx, cfg, sendCh, hr, body, chunkEnc, reqCancels, reqBodies, &jobMu, checkDrain)
+		case "http_proxy_request_chunk":
+			var hc ctrl.HTTPProxyRequestChunkMessage
+			if err := json.Unmarshal(data, &hc); err != nil {
+				logx.Log.Warn().Err(err).Str("type", env.Type).Str("body", summarizeBody(data, 512)).Msg("server message decode failed")
+				continue
+			}
+			jobMu.Lock()
+			rb := reqBodies[hc.RequestID]
+			if rb != nil && !rb.push(hc.Data) {
+				// The backend fell too far behind; dropping the request
+				// keeps the other requests on this connection flowing.
+				delete(reqBodies, hc.RequestID)
+				rb.abort(errRequestBodyOverflow)
+				logx.Log.Warn().Str("request_id", hc.RequestID).Msg("request body overflow; dropping request")
+			}
+			jobMu.Unlock()
+		case "http_proxy_request_end":
+			var he ctrl.HTTPProxyRequestEndMessage
+			if err := json.Unmarshal(data, &he); err != nil {
+				logx.Log.Warn().Err(err).Str("type", env.Type).Str("body", summarizeBody(data, 512)).Msg("server message decode failed")
+				continue
+			}
+			jobMu.Lock()
+			rb := reqBodies[he.RequestID]
+			delete(reqBodies, he.RequestID)
+			jobMu.Unlock()
+			if rb != nil {
+				if he.Error != nil {
+					rb.end(errors.New(he.Error.Code + ": " + he.Error.Message))
+				} else {
+					rb.end(nil)
+				}
+			}
 		case "http_proxy_cancel":
 			var hc ctrl.HTTPProxyCancelMessage
 			if err := json.Unmarshal(data, &hc); err == nil {
@@ -419,6 +477,10 @@ func connectAndServe(ctx context.Context, cancelAll context.CancelFunc, cfg Conf
 					cancel()
 					delete(reqCancels, hc.RequestID)
 				}
+				if rb, ok := reqBodies[hc.RequestID]; ok {
+					rb.abort(context.Canceled)
+					delete(reqBodies, hc.RequestID)
+				}
 				jobMu.Unlock()
 			} else {
 				logx.Log.Warn().Err(err).Str("type", env.Type).Str("body", summarizeBody(data, 512)).Msg("server message decode failed")
@@ -0,0 +1,105 @@
+// Package chunkenc compresses the body chunks agents send over their control
+// connection, once the server has agreed on an encoding at registration.
+package chunkenc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encodings an agent may apply to the data of http_proxy_response_chunk
+// messages, in the order the server prefers them.
+const (
+	Zstd = "zstd"
+	Gzip = "gzip"
+)
+
+// Supported lists the encodings this package implements, preferred first.
+var Supported = []string{Zstd, Gzip}
+
+// minSize is the smallest chunk worth compressing.
+const minSize = 512
+
+// maxDecoded bounds a decoded chunk, so that a small message cannot expand
+// into an unbounded allocation.
+const maxDecoded = 16 << 20
+
+var ErrTooLarge = errors.New("chunk too large once decoded")
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecoded))
+)
+
+// Negotiate picks the preferred encoding among those an agent offered, or ""
+// for none.
+func Negotiate(offered []string) string {
+	for _, enc := range Supported {
+		for _, o := range offered {
+			if o == enc {
+				return enc
+			}
+		}
+	}
+	return ""
+}
+
+// Encode compresses data with enc. It returns data unchanged and an empty
+// encoding when enc is empty or unknown, or when compressing does not make
+// data smaller.
+func Encode(enc string, data []byte) ([]byte, string) {
+	if len(data) < minSize {
+		return data, ""
+	}
+	var out []byte
+	switch enc {
+	case Zstd:
+		out = zstdEncoder.EncodeAll(data, make([]byte, 0, len(data)/2))
+	case Gzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil || zw.Close() != nil {
+			return data, ""
+		}
+		out = buf.Bytes()
+	default:
+		return data, ""
+	}
+	if len(out) >= len(data) {
+		return data, ""
+	}
+	return out, enc
+}
+
+// Decode reverses Encode. An empty enc returns data as is.
+func Decode(enc string, data []byte) ([]byte, error) {
+	switch enc {
+	case "":
+		return data, nil
+	case Zstd:
+		out, err := zstdDecoder.DecodeAll(data, nil)
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+			return nil, ErrTooLarge
+		}
+		return out, err
+	case Gzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		out, err := io.ReadAll(io.LimitReader(zr, maxDecoded+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(out) > maxDecoded {
+			return nil, ErrTooLarge
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unknown chunk encoding %q", enc)
+}
@@ -0,0 +1,106 @@
+package chunkenc
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("data: {\"choices\":[{\"delta\":\"hello\"}]}\n\n"), 64)
+	for _, enc := range Supported {
+		out, used := Encode(enc, data)
+		if used != enc {
+			t.Fatalf("%s: encoding = %q, want %q", enc, used, enc)
+		}
+		if len(out) >= len(data) {
+			t.Fatalf("%s: encoded %d bytes into %d", enc, len(data), len(out))
+		}
+		got, err := Decode(used, out)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", enc, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%s: round trip mismatch", enc)
+		}
+	}
+}
+
+func TestEncodeLeavesDataUnencoded(t *testing.T) {
+	small := []byte("tiny chunk")
+	random := make([]byte, 4096)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatalf("rand: %v", err)
+	}
+	large := bytes.Repeat([]byte("x"), 4096)
+	cases := []struct {
+		name string
+		enc  string
+		data []byte
+	}{
+		{"below minimum size", Zstd, small},
+		{"incompressible", Gzip, random},
+		{"no encoding", "", large},
+		{"unknown encoding", "br", large},
+	}
+	for _, tc := range cases {
+		out, used := Encode(tc.enc, tc.data)
+		if used != "" || !bytes.Equal(out, tc.data) {
+			t.Fatalf("%s: got encoding %q and %d bytes, want data unchanged", tc.name, used, len(out))
+		}
+		got, err := Decode(used, out)
+		if err != nil || !bytes.Equal(got, tc.data) {
+			t.Fatalf("%s: decode of unencoded data: %v", tc.name, err)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		offered []string
+		want    string
+	}{
+		{[]string{Gzip, Zstd}, Zstd},
+		{[]string{"br", Gzip}, Gzip},
+		{[]string{"br", "deflate"}, ""},
+		{nil, ""},
+	}
+	for _, tc := range cases {
+		if got := Negotiate(tc.offered); got != tc.want {
+			t.Fatalf("Negotiate(%v) = %q, want %q", tc.offered, got, tc.want)
+		}
+	}
+}
+
+func TestDecodeRejectsBadInput(t *testing.T) {
+	if _, err := Decode("br", []byte("data")); err == nil {
+		t.Fatalf("expected an error for an unknown encoding")
+	}
+	data := bytes.Repeat([]byte("abcdefgh"), 256)
+	for _, enc := range Supported {
+		out, _ := Encode(enc, data)
+		corrupt := append([]byte(nil), out...)
+		corrupt[len(corrupt)/2] ^= 0xff
+		corrupt = corrupt[:len(corrupt)-4]
+		if _, err := Decode(enc, corrupt); err == nil {
+			t.Fatalf("%s: expected an error for corrupt input", enc)
+		}
+		if _, err := Decode(enc, []byte("not compressed")); err == nil {
+			t.Fatalf("%s: expected an error for garbage input", enc)
+		}
+	}
+}
+
+func TestDecodeBoundsExpansion(t *testing.T) {
+	bomb := make([]byte, maxDecoded+1)
+	for _, enc := range Supported {
+		out, used := Encode(enc, bomb)
+		if used != enc {
+			t.Fatalf("%s: expected the zero-filled chunk to compress", enc)
+		}
+		if _, err := Decode(enc, out); !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("%s: decode error = %v, want %v", enc, err, ErrTooLarge)
+		}
+	}
+}
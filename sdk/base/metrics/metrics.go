@@ -28,6 +28,10 @@ var (
 		prometheus.CounterOpts{Name: "nfrx_request_size_total", Help: "Total request sizes by kind"},
 		[]string{"ext", "plugin_type", "job_type", "label", "size_kind"},
 	)
+	requestCostTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{Name: "nfrx_request_cost_total", Help: "Total computed request cost by kind (price, energy_wh)"},
+		[]string{"ext", "plugin_type", "job_type", "label", "cost_kind"},
+	)
 	requestInflight = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{Name: "nfrx_request_inflight", Help: "In-flight requests"},
 		[]string{"ext", "plugin_type", "job_type", "label"},
@@ -59,6 +63,7 @@ func Register(reg spi.MetricsRegistry) {
 			requestCompletedTotal,
 			requestDuration,
 			requestSizeTotal,
+			requestCostTotal,
 			requestInflight,
 			chunkCompletedTotal,
 			chunkDuration,
@@ -90,6 +95,14 @@ func AddSize(ext, pluginType, jobType, label, sizeKind string, n uint64) {
 	requestSizeTotal.WithLabelValues(ext, pluginType, jobType, label, sizeKind).Add(float64(n))
 }
 
+// AddCost adds a computed request cost of the given kind (e.g. "price" or "energy_wh").
+func AddCost(ext, pluginType, jobType, label, costKind string, v float64) {
+	if v <= 0 {
+		return
+	}
+	requestCostTotal.WithLabelValues(ext, pluginType, jobType, label, costKind).Add(v)
+}
+
 // Chunk-level helpers
 func RecordChunkComplete(ext, pluginType, jobType, label, workerID, errorCode string, success bool, dur time.Duration) {
 	s := "false"
@@ -74,7 +74,8 @@ func (r *Registry) WSHandler(expectKey string, decode RegisterAdapter, reader Re
 			http.Error(w, "draining", http.StatusServiceUnavailable)
 			return
 		}
-		c, err := websocket.Accept(w, req, nil)
+		// permessage-deflate is used with agents that offer it.
+		c, err := websocket.Accept(w, req, &websocket.AcceptOptions{CompressionMode: websocket.CompressionNoContextTakeover})
 		if err != nil {
 			return
 		}
@@ -0,0 +1,135 @@
+package worker
+
+import "time"
+
+// Defaults for adaptive concurrency tuning.
+const (
+	DefaultLatencyTolerance = 2.0
+	adaptiveBackoff         = 0.75
+	// baselineDrift lets a stale minimum latency recover by 1% per sample.
+	baselineDrift = 100
+)
+
+// AdaptiveConcurrency configures AIMD tuning of the effective concurrency of
+// each model on each worker. Fast time-to-first-byte samples raise a limit by
+// roughly one slot per limit's worth of requests; slow samples and failures
+// cut it by a constant factor. Limits never exceed the agent-declared maximum,
+// and a model backing off leaves the other models of the worker untouched.
+type AdaptiveConcurrency struct {
+	// Tolerance is the multiple of a model's baseline latency on a worker
+	// above which a sample counts as slow. Values <= 1 use
+	// DefaultLatencyTolerance.
+	Tolerance float64
+	// OnChange, if set, is called with the worker's new effective limit, that
+	// of its most throttled model, whenever it changes.
+	OnChange func(id string, limit int)
+}
+
+// modelLimit is the AIMD state of one model on a worker.
+type modelLimit struct {
+	limit    float64
+	baseline time.Duration
+}
+
+// EnableAdaptiveConcurrency turns on latency-driven concurrency tuning.
+func (r *Registry) EnableAdaptiveConcurrency(cfg AdaptiveConcurrency) {
+	if cfg.Tolerance <= 1 {
+		cfg.Tolerance = DefaultLatencyTolerance
+	}
+	r.mu.Lock()
+	r.adaptive = &cfg
+	r.mu.Unlock()
+}
+
+// ObserveFailure lowers the effective concurrency of model on the worker after
+// a failed request.
+func (r *Registry) ObserveFailure(id, model string) {
+	r.adapt(id, model, 0, true)
+}
+
+func (r *Registry) adapt(id, model string, d time.Duration, failed bool) {
+	r.mu.RLock()
+	w, ok := r.workers[id]
+	a := r.adaptive
+	r.mu.RUnlock()
+	if !ok || a == nil {
+		return
+	}
+	w.mu.Lock()
+	before := w.effectiveLimitLocked()
+	a.update(w, model, d, failed)
+	after := w.effectiveLimitLocked()
+	w.mu.Unlock()
+	if before != after && a.OnChange != nil {
+		a.OnChange(id, after)
+	}
+}
+
+// update applies one AIMD step to the limit of model on w; the caller holds
+// w.mu.
+func (a *AdaptiveConcurrency) update(w *Worker, model string, d time.Duration, failed bool) {
+	maxc := float64(w.MaxConcurrency)
+	if maxc <= 0 {
+		return
+	}
+	if w.adaptive == nil {
+		w.adaptive = make(map[string]*modelLimit)
+	}
+	m := w.adaptive[model]
+	if m == nil {
+		m = &modelLimit{}
+		w.adaptive[model] = m
+	}
+	if m.limit <= 0 || m.limit > maxc {
+		m.limit = maxc
+	}
+	if !failed {
+		if m.baseline == 0 || d < m.baseline {
+			m.baseline = d
+		} else {
+			m.baseline += m.baseline / baselineDrift
+		}
+		if float64(d) <= a.Tolerance*float64(m.baseline) {
+			m.limit = min(maxc, m.limit+1/m.limit)
+			return
+		}
+	}
+	m.limit = max(1, m.limit*adaptiveBackoff)
+}
+
+// limitForLocked returns the in-flight count up to which w accepts requests
+// for model; the caller holds w.mu.
+func (w *Worker) limitForLocked(model string) int {
+	m := w.adaptive[model]
+	if m == nil || m.limit <= 0 || int(m.limit) >= w.MaxConcurrency {
+		return w.MaxConcurrency
+	}
+	return max(1, int(m.limit))
+}
+
+// effectiveLimitLocked returns the limit of the most throttled model on w;
+// the caller holds w.mu.
+func (w *Worker) effectiveLimitLocked() int {
+	n := w.MaxConcurrency
+	for model := range w.adaptive {
+		n = min(n, w.limitForLocked(model))
+	}
+	return n
+}
+
+// EffectiveConcurrency safely returns the concurrency currently allowed for
+// the worker's most throttled model, which is below MaxConcurrency while
+// adaptive tuning backs off.
+func (w *Worker) EffectiveConcurrency() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.effectiveLimitLocked()
+}
+
+// EffectiveConcurrencyFor safely returns the concurrency currently allowed for
+// requests for model on the worker.
+func (w *Worker) EffectiveConcurrencyFor(model string) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.limitForLocked(model)
+}
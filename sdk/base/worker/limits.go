@@ -0,0 +1,34 @@
+package worker
+
+import (
+	"encoding/json"
+
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
+)
+
+// AgentConfigModelLimits is the agent config key carrying a JSON object that
+// maps model names to their advertised limits.
+const AgentConfigModelLimits = "model_limits"
+
+// parseModelLimits decodes the model limits advertised in agentConfig. The
+// second result is false when the key is absent or malformed, in which case
+// previously known limits should be kept.
+func parseModelLimits(agentConfig map[string]string) (map[string]spi.ModelLimits, bool) {
+	raw, ok := agentConfig[AgentConfigModelLimits]
+	if !ok || raw == "" {
+		return nil, false
+	}
+	var limits map[string]spi.ModelLimits
+	if err := json.Unmarshal([]byte(raw), &limits); err != nil {
+		return nil, false
+	}
+	return limits, true
+}
+
+// ModelLimitsFor safely returns the limits advertised for model, if any.
+func (w *Worker) ModelLimitsFor(model string) (spi.ModelLimits, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	l, ok := w.Limits[model]
+	return l, ok
+}
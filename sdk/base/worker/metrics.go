@@ -4,6 +4,8 @@ import (
 	"sort"
 	"sync"
 	"time"
+
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
 )
 
 type WorkerStatus string
@@ -28,10 +30,17 @@ type WorkerSnapshot struct {
 	HostRAMUsedPercent float64      `json:"host_ram_used_percent,omitempty"`
 	InputTokensTotal   uint64       `json:"input_tokens_total"`
 	OutputTokensTotal  uint64       `json:"output_tokens_total"`
+	CostPer1KInput     float64      `json:"cost_per_1k_input_tokens,omitempty"`
+	CostPer1KOutput    float64      `json:"cost_per_1k_output_tokens,omitempty"`
+	PowerWatts         float64      `json:"power_watts,omitempty"`
+	CostTotal          float64      `json:"cost_total"`
+	EnergyWhTotal      float64      `json:"energy_wh_total"`
 	Status             WorkerStatus `json:"status"`
 	ConnectedAt        time.Time    `json:"connected_at"`
 	LastHeartbeat      time.Time    `json:"last_heartbeat"`
 	MaxConcurrency     int          `json:"max_concurrency"`
+	// EffectiveConcurrency is the limit currently enforced by adaptive tuning.
+	EffectiveConcurrency int `json:"effective_concurrency"`
 	// Keep historical UI label for preferred batch size
 	PreferredBatchSize int     `json:"embedding_batch_size"`
 	ProcessedTotal     uint64  `json:"processed_total"`
@@ -106,7 +115,10 @@ type workerMetrics struct {
 	hostInfo                            HostInfo
 	hostCPUPercent, hostRAMUsedPercent  float64
 	inputTokensTotal, outputTokensTotal uint64
+	pricing                             spi.WorkerPricing
+	costTotal, energyWhTotal            float64
 	maxConcurrency, preferredBatchSize  int
+	effectiveConcurrency                int
 	processedTotal, processingMsTotal   uint64
 	inflight                            int
 	failuresTotal                       uint64
@@ -145,6 +157,34 @@ func (m *MetricsRegistry) SetWorkerHostInfo(id string, agentConfig map[string]st
 	mergeHostInfo(&w.hostInfo, w.version, agentConfig)
 }
 
+// SetWorkerPricing records the worker's declared pricing for state reporting.
+func (m *MetricsRegistry) SetWorkerPricing(id string, pricing spi.WorkerPricing) {
+	m.mu.Lock()
+	if w, ok := m.workers[id]; ok {
+		w.pricing = pricing
+	}
+	m.mu.Unlock()
+}
+
+// SetWorkerEffectiveConcurrency records the concurrency limit currently enforced for a worker.
+func (m *MetricsRegistry) SetWorkerEffectiveConcurrency(id string, n int) {
+	m.mu.Lock()
+	if w, ok := m.workers[id]; ok {
+		w.effectiveConcurrency = n
+	}
+	m.mu.Unlock()
+}
+
+// AddWorkerCost accumulates the computed price and energy (in watt-hours) of a completed request.
+func (m *MetricsRegistry) AddWorkerCost(id string, cost, energyWh float64) {
+	m.mu.Lock()
+	if w, ok := m.workers[id]; ok {
+		w.costTotal += cost
+		w.energyWhTotal += energyWh
+	}
+	m.mu.Unlock()
+}
+
 func (m *MetricsRegistry) RemoveWorker(id string) { m.mu.Lock(); delete(m.workers, id); m.mu.Unlock() }
 func (m *MetricsRegistry) SetWorkerStatus(id string, status WorkerStatus) {
 	m.mu.Lock()
@@ -203,6 +243,20 @@ func (m *MetricsRegistry) RecordJobEnd(id, model string, duration time.Duration,
 	}
 }
 
+// RecordJobAbandoned ends a job started with RecordJobStart without counting
+// it as processed, completed or failed, e.g. the losing copy of a hedged
+// request.
+func (m *MetricsRegistry) RecordJobAbandoned(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if w, ok := m.workers[id]; ok && w.inflight > 0 {
+		w.inflight--
+	}
+	if m.jobsInflight > 0 {
+		m.jobsInflight--
+	}
+}
+
 func (m *MetricsRegistry) SetWorkerQueueLen(id string, n int) {
 	m.mu.Lock()
 	if w, ok := m.workers[id]; ok {
@@ -268,6 +322,10 @@ func (m *MetricsRegistry) Snapshot() StateResponse {
 		if w.processedTotal > 0 {
 			avg = float64(w.processingMsTotal) / float64(w.processedTotal)
 		}
+		effective := w.maxConcurrency
+		if w.effectiveConcurrency > 0 && w.effectiveConcurrency < effective {
+			effective = w.effectiveConcurrency
+		}
 		snapshot := WorkerSnapshot{
 			ID:                 w.id,
 			Name:               w.name,
@@ -282,6 +340,11 @@ func (m *MetricsRegistry) Snapshot() StateResponse {
 			HostRAMUsedPercent: w.hostRAMUsedPercent,
 			InputTokensTotal:   w.inputTokensTotal,
 			OutputTokensTotal:  w.outputTokensTotal,
+			CostPer1KInput:     w.pricing.InputPer1K,
+			CostPer1KOutput:    w.pricing.OutputPer1K,
+			PowerWatts:         w.pricing.PowerWatts,
+			CostTotal:          w.costTotal,
+			EnergyWhTotal:      w.energyWhTotal,
 			MaxConcurrency:     w.maxConcurrency,
 			PreferredBatchSize: w.preferredBatchSize,
 			ProcessedTotal:     w.processedTotal,
@@ -292,6 +355,7 @@ func (m *MetricsRegistry) Snapshot() StateResponse {
 			QueueLen:           w.queueLen,
 			LastError:          w.lastError,
 		}
+		snapshot.EffectiveConcurrency = effective
 		resp.Workers = append(resp.Workers, snapshot)
 	}
 	// Leave Models empty in generic base; extensions can expose their own catalogs
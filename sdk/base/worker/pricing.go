@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
+)
+
+// Agent config keys used by workers to declare pricing and power draw.
+const (
+	AgentConfigCostPer1KInput  = "cost_per_1k_input_tokens"
+	AgentConfigCostPer1KOutput = "cost_per_1k_output_tokens"
+	AgentConfigPowerWatts      = "power_watts"
+)
+
+// latencyEWMAWeight is the weight given to each new latency sample.
+const latencyEWMAWeight = 0.2
+
+// mergePricing overlays pricing values present in agentConfig onto p.
+// Keys that are absent or unparsable leave the current value untouched.
+func mergePricing(p spi.WorkerPricing, agentConfig map[string]string) spi.WorkerPricing {
+	if agentConfig == nil {
+		return p
+	}
+	if v, ok := parsePrice(agentConfig[AgentConfigCostPer1KInput]); ok {
+		p.InputPer1K = v
+	}
+	if v, ok := parsePrice(agentConfig[AgentConfigCostPer1KOutput]); ok {
+		p.OutputPer1K = v
+	}
+	if v, ok := parsePrice(agentConfig[AgentConfigPowerWatts]); ok {
+		p.PowerWatts = v
+	}
+	return p
+}
+
+func parsePrice(s string) (float64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+// PricingValue safely returns the worker's declared pricing.
+func (w *Worker) PricingValue() spi.WorkerPricing { w.mu.Lock(); defer w.mu.Unlock(); return w.Pricing }
+
+// LatencyValue safely returns the worker's smoothed time-to-first-byte.
+func (w *Worker) LatencyValue() time.Duration { w.mu.Lock(); defer w.mu.Unlock(); return w.Latency }
+
+// ObserveLatency folds a time-to-first-byte sample of a request for model into
+// the worker's moving average and, when enabled, the adaptive concurrency
+// limit of that model on the worker.
+func (r *Registry) ObserveLatency(id, model string, d time.Duration) {
+	r.mu.RLock()
+	w, ok := r.workers[id]
+	r.mu.RUnlock()
+	if !ok || d <= 0 {
+		return
+	}
+	w.mu.Lock()
+	if w.Latency == 0 {
+		w.Latency = d
+	} else {
+		w.Latency = time.Duration(latencyEWMAWeight*float64(d) + (1-latencyEWMAWeight)*float64(w.Latency))
+	}
+	w.mu.Unlock()
+	r.adapt(id, model, d, false)
+}
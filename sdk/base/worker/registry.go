@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gaspardpetit/nfrx/core/logx"
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
 )
 
 const (
@@ -19,10 +20,18 @@ type Worker struct {
 	MaxConcurrency     int
 	PreferredBatchSize int
 	InFlight           int
+	Pricing            spi.WorkerPricing
+	Latency            time.Duration
+	Limits             map[string]spi.ModelLimits
+	adaptive           map[string]*modelLimit
 	LastHeartbeat      time.Time
 	Send               chan interface{}
 	Jobs               map[string]chan interface{}
 	mu                 sync.Mutex
+	// sendMu is held for reading by senders that may block on Send, so
+	// the channel is only closed once they have been released by done.
+	sendMu sync.RWMutex
+	done   chan struct{}
 }
 
 // NameValue safely returns the worker's name.
@@ -47,8 +56,10 @@ func (w *Worker) HasLabel(label string) bool {
 }
 
 type Registry struct {
-	mu      sync.RWMutex
-	workers map[string]*Worker
+	mu       sync.RWMutex
+	workers  map[string]*Worker
+	adaptive *AdaptiveConcurrency
+	onRemove []func(id string)
 }
 
 func NewRegistry() *Registry { return &Registry{workers: make(map[string]*Worker)} }
@@ -59,9 +70,18 @@ func (r *Registry) Add(w *Worker) {
 	r.mu.Unlock()
 }
 
+// OnRemove registers fn to be called with the ID of each worker removed from
+// the registry, whether it disconnected or its heartbeat expired.
+func (r *Registry) OnRemove(fn func(id string)) {
+	r.mu.Lock()
+	r.onRemove = append(r.onRemove, fn)
+	r.mu.Unlock()
+}
+
 func (r *Registry) Remove(id string) {
 	r.mu.Lock()
-	if w, ok := r.workers[id]; ok {
+	w, ok := r.workers[id]
+	if ok {
 		delete(r.workers, id)
 		w.mu.Lock()
 		for id, ch := range w.Jobs {
@@ -71,11 +91,15 @@ func (r *Registry) Remove(id string) {
 			delete(w.Jobs, id)
 		}
 		w.mu.Unlock()
-		if w.Send != nil {
-			close(w.Send)
-		}
+		w.close()
 	}
+	hooks := r.onRemove
 	r.mu.Unlock()
+	if ok {
+		for _, fn := range hooks {
+			fn(id)
+		}
+	}
 }
 
 func (r *Registry) WorkerCount() int { r.mu.RLock(); defer r.mu.RUnlock(); return len(r.workers) }
@@ -108,7 +132,23 @@ func (r *Registry) WorkersForLabel(model string) []*Worker {
 	var res []*Worker
 	for _, w := range r.workers {
 		w.mu.Lock()
-		if w.Labels[model] && w.InFlight < w.MaxConcurrency {
+		if w.Labels[model] && w.InFlight < w.limitForLocked(model) {
+			res = append(res, w)
+		}
+		w.mu.Unlock()
+	}
+	return res
+}
+
+// WorkersServing returns every connected worker labelled model, including
+// those without free capacity.
+func (r *Registry) WorkersServing(model string) []*Worker {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var res []*Worker
+	for _, w := range r.workers {
+		w.mu.Lock()
+		if w.Labels[model] {
 			res = append(res, w)
 		}
 		w.mu.Unlock()
@@ -145,9 +185,11 @@ func (r *Registry) Snapshot() []*Worker {
 
 func (r *Registry) PruneExpired(maxAge time.Duration) {
 	r.mu.Lock()
+	var removed []string
 	for id, w := range r.workers {
 		if time.Since(w.LastHeartbeat) > maxAge {
 			delete(r.workers, id)
+			removed = append(removed, id)
 			w.mu.Lock()
 			for jobID, ch := range w.Jobs {
 				if ch != nil {
@@ -156,11 +198,17 @@ func (r *Registry) PruneExpired(maxAge time.Duration) {
 				delete(w.Jobs, jobID)
 			}
 			w.mu.Unlock()
-			close(w.Send)
+			w.close()
 			logx.Log.Info().Str("worker_id", id).Str("reason", "heartbeat_expired").Msg("evicted")
 		}
 	}
+	hooks := r.onRemove
 	r.mu.Unlock()
+	for _, id := range removed {
+		for _, fn := range hooks {
+			fn(id)
+		}
+	}
 }
 
 func (w *Worker) AddJob(id string, ch chan interface{}) { w.mu.Lock(); w.Jobs[id] = ch; w.mu.Unlock() }
@@ -0,0 +1,170 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+
+	ctrl "github.com/gaspardpetit/nfrx/sdk/api/control"
+)
+
+var (
+	ErrWorkerNotFound = errors.New("worker not connected")
+	ErrWorkerGone     = errors.New("worker disconnected")
+)
+
+// relayChunkSize bounds the request body carried by one chunk message.
+const relayChunkSize = 32 * 1024
+
+// HasWorker reports whether worker id is connected.
+func (r *Registry) HasWorker(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.workers[id]
+	return ok
+}
+
+// Relay performs an HTTP request on the backend of worker id through its
+// control connection, so the bytes never need a connection of their own. A
+// non-nil body is streamed in http_proxy_request_chunk messages; the response
+// body is copied to dst. Both directions move at the pace of the connection
+// and of the slower end. Relay fails when the backend answers with an error
+// status.
+func (r *Registry) Relay(ctx context.Context, id, method, path string, headers map[string]string, body io.Reader, dst io.Writer) error {
+	r.mu.RLock()
+	wk := r.workers[id]
+	r.mu.RUnlock()
+	if wk == nil {
+		return ErrWorkerNotFound
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	reqID := uuid.NewString()
+	ch := make(chan interface{}, 16)
+	wk.AddJob(reqID, ch)
+	defer wk.RemoveJob(reqID)
+
+	msg := ctrl.HTTPProxyRequestMessage{Type: "http_proxy_request", RequestID: reqID, Method: method, Path: path, Headers: headers, BodyStream: body != nil}
+	if err := relaySend(ctx, wk, msg); err != nil {
+		return err
+	}
+	if body != nil {
+		go relayBody(ctx, wk, reqID, body)
+	}
+
+	status := 0
+	for {
+		select {
+		case <-ctx.Done():
+			relayCancel(wk, reqID)
+			return ctx.Err()
+		case m, ok := <-ch:
+			if !ok {
+				return ErrWorkerGone
+			}
+			switch m := m.(type) {
+			case ctrl.HTTPProxyResponseHeadersMessage:
+				status = m.Status
+			case ctrl.HTTPProxyResponseChunkMessage:
+				if status >= 400 || len(m.Data) == 0 {
+					continue
+				}
+				if _, err := dst.Write(m.Data); err != nil {
+					relayCancel(wk, reqID)
+					return err
+				}
+			case ctrl.HTTPProxyResponseEndMessage:
+				if m.Error != nil {
+					return fmt.Errorf("relay: %s: %s", m.Error.Code, m.Error.Message)
+				}
+				if status >= 400 {
+					return fmt.Errorf("relay: backend status %d", status)
+				}
+				return nil
+			}
+		}
+	}
+}
+
+// relayBody streams body to the worker as request chunks, ending with an
+// http_proxy_request_end message that reports a failed read.
+func relayBody(ctx context.Context, wk *Worker, reqID string, body io.Reader) {
+	buf := make([]byte, relayChunkSize)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			chunk := ctrl.HTTPProxyRequestChunkMessage{Type: "http_proxy_request_chunk", RequestID: reqID, Data: append([]byte(nil), buf[:n]...)}
+			if relaySend(ctx, wk, chunk) != nil {
+				return
+			}
+		}
+		if err != nil {
+			end := ctrl.HTTPProxyRequestEndMessage{Type: "http_proxy_request_end", RequestID: reqID}
+			if !errors.Is(err, io.EOF) {
+				end.Error = &ctrl.HTTPProxyError{Code: "body_error", Message: err.Error()}
+			}
+			_ = relaySend(ctx, wk, end)
+			return
+		}
+	}
+}
+
+// relaySend queues msg for the worker, waiting while its queue is full.
+func relaySend(ctx context.Context, wk *Worker, msg interface{}) error {
+	// A long relay may well outlive the worker, whose Send is then closed.
+	wk.sendMu.RLock()
+	defer wk.sendMu.RUnlock()
+	done := wk.doneChan()
+	select {
+	case <-done:
+		return ErrWorkerGone
+	default:
+	}
+	select {
+	case wk.Send <- msg:
+		return nil
+	case <-done:
+		return ErrWorkerGone
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// relayCancel asks the worker to abort request reqID, if its queue has room.
+func relayCancel(wk *Worker, reqID string) {
+	wk.sendMu.RLock()
+	defer wk.sendMu.RUnlock()
+	select {
+	case <-wk.doneChan():
+		return
+	default:
+	}
+	select {
+	case wk.Send <- ctrl.HTTPProxyCancelMessage{Type: "http_proxy_cancel", RequestID: reqID}:
+	default:
+	}
+}
+
+// doneChan returns the channel closed when the worker is removed.
+func (w *Worker) doneChan() chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done == nil {
+		w.done = make(chan struct{})
+	}
+	return w.done
+}
+
+// close releases the senders blocked in relaySend, then closes Send once
+// none of them can still write to it.
+func (w *Worker) close() {
+	close(w.doneChan())
+	w.sendMu.Lock()
+	if w.Send != nil {
+		close(w.Send)
+	}
+	w.sendMu.Unlock()
+}
@@ -2,6 +2,9 @@ package worker
 
 import (
 	"errors"
+	"time"
+
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
 )
 
 // Scheduler picks the best worker for a given task key.
@@ -36,6 +39,13 @@ type ScoreThenLeastBusyScheduler struct {
 	// MinScore is the minimum score required for a worker to be considered.
 	// If all workers score below MinScore, no worker is selected.
 	MinScore float64
+	// CostAware picks the cheapest of the best-scoring workers (by declared
+	// price, then power draw) instead of the least busy one.
+	CostAware bool
+	// LatencyTarget excludes workers whose observed time-to-first-byte exceeds
+	// it from cost-aware selection. When every candidate violates the target,
+	// the least busy worker is chosen. Zero disables the check.
+	LatencyTarget time.Duration
 }
 
 // NewScoreScheduler constructs a scheduler using the provided registry and scorer.
@@ -54,6 +64,13 @@ func NewScoreSchedulerWithMinScore(reg *Registry, scorer Scorer, min float64) *S
 }
 
 func (s *ScoreThenLeastBusyScheduler) PickWorker(task string) (*Worker, error) {
+	return s.PickWorkerFor(task, spi.RequestSize{})
+}
+
+// PickWorkerFor is like PickWorker but skips workers whose advertised limits
+// for task cannot fit a request of the given size. Workers that advertise no
+// limits for task remain eligible.
+func (s *ScoreThenLeastBusyScheduler) PickWorkerFor(task string, size spi.RequestSize) (*Worker, error) {
 	s.Reg.mu.RLock()
 	// snapshot pointers; we won't mutate workers inside lock except reading fields
 	workers := make([]*Worker, 0, len(s.Reg.workers))
@@ -68,14 +85,18 @@ func (s *ScoreThenLeastBusyScheduler) PickWorker(task string) (*Worker, error) {
 	)
 	for _, w := range workers {
 		w.mu.Lock()
-		capOK := w.InFlight < w.MaxConcurrency
+		capOK := w.InFlight < w.limitForLocked(task)
 		score := 0.0
+		fits := true
 		if capOK {
 			// Score under worker lock to avoid races with model updates
 			score = s.Scorer.Score(task, w)
+			if l, ok := w.Limits[task]; ok && !l.Fits(size) {
+				fits = false
+			}
 		}
 		w.mu.Unlock()
-		if !capOK {
+		if !capOK || !fits {
 			continue
 		}
 		if score < s.MinScore {
@@ -94,6 +115,43 @@ func (s *ScoreThenLeastBusyScheduler) PickWorker(task string) (*Worker, error) {
 	if len(best) == 0 {
 		return nil, errors.New("no worker")
 	}
+	if s.CostAware {
+		if w := s.cheapest(best); w != nil {
+			return w, nil
+		}
+	}
+	return leastBusy(best), nil
+}
+
+// cheapest returns the lowest-priced candidate that meets the latency target,
+// breaking ties by power draw and then by in-flight count. It returns nil when
+// no candidate meets the target.
+func (s *ScoreThenLeastBusyScheduler) cheapest(candidates []*Worker) *Worker {
+	var (
+		chosen              *Worker
+		bestCost, bestPower float64
+		bestInFlight        int
+	)
+	for _, w := range candidates {
+		w.mu.Lock()
+		p, lat, inflight := w.Pricing, w.Latency, w.InFlight
+		w.mu.Unlock()
+		if s.LatencyTarget > 0 && lat > s.LatencyTarget {
+			continue
+		}
+		cost := p.InputPer1K + p.OutputPer1K
+		better := chosen == nil ||
+			cost < bestCost ||
+			(cost == bestCost && p.PowerWatts < bestPower) ||
+			(cost == bestCost && p.PowerWatts == bestPower && inflight < bestInFlight)
+		if better {
+			chosen, bestCost, bestPower, bestInFlight = w, cost, p.PowerWatts, inflight
+		}
+	}
+	return chosen
+}
+
+func leastBusy(best []*Worker) *Worker {
 	chosen := best[0]
 	for _, w := range best[1:] {
 		w.mu.Lock()
@@ -106,5 +164,5 @@ func (s *ScoreThenLeastBusyScheduler) PickWorker(task string) (*Worker, error) {
 			chosen = w
 		}
 	}
-	return chosen, nil
+	return chosen
 }
@@ -3,6 +3,7 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http/httptest"
 	"strings"
 	"sync"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/coder/websocket"
 	ctrl "github.com/gaspardpetit/nfrx/sdk/api/control"
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
+	"github.com/gaspardpetit/nfrx/sdk/base/chunkenc"
 )
 
 func TestScoreSchedulerLeastBusy(t *testing.T) {
@@ -52,6 +55,116 @@ func TestMinScoreThreshold(t *testing.T) {
 	}
 }
 
+func TestCostAwareSchedulerPrefersCheapestWithinLatencyTarget(t *testing.T) {
+	reg := NewRegistry()
+	labels := map[string]bool{"m": true}
+	reg.Add(&Worker{ID: "pricey", Labels: labels, MaxConcurrency: 1, Pricing: mergePricing(spi.WorkerPricing{}, map[string]string{AgentConfigCostPer1KInput: "2", AgentConfigCostPer1KOutput: "2"})})
+	reg.Add(&Worker{ID: "cheap-slow", Labels: labels, MaxConcurrency: 1, Pricing: spi.WorkerPricing{InputPer1K: 0.1}})
+	reg.Add(&Worker{ID: "cheap", Labels: labels, MaxConcurrency: 1, InFlight: 0, Pricing: spi.WorkerPricing{InputPer1K: 0.5}})
+	reg.ObserveLatency("cheap-slow", "m", 5*time.Second)
+	sched := NewScoreScheduler(reg, DefaultExactMatchScorer{})
+	sched.CostAware = true
+	sched.LatencyTarget = time.Second
+	w, err := sched.PickWorker("m")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if w.ID != "cheap" {
+		t.Fatalf("expected cheap, got %s", w.ID)
+	}
+	sched.LatencyTarget = 0
+	if w, _ = sched.PickWorker("m"); w.ID != "cheap-slow" {
+		t.Fatalf("expected cheap-slow without a latency target, got %s", w.ID)
+	}
+}
+
+func TestPickWorkerForSkipsWorkersThatCannotFitRequest(t *testing.T) {
+	reg := NewRegistry()
+	labels := map[string]bool{"m": true}
+	small := &Worker{ID: "small", Labels: labels, MaxConcurrency: 1}
+	small.Limits, _ = parseModelLimits(map[string]string{AgentConfigModelLimits: `{"m":{"context_length":8192}}`})
+	reg.Add(small)
+	reg.Add(&Worker{ID: "large", Labels: labels, MaxConcurrency: 1, InFlight: 0, Limits: map[string]spi.ModelLimits{"m": {ContextLength: 131072}}})
+	sched := NewScoreScheduler(reg, DefaultExactMatchScorer{})
+	for i := 0; i < 5; i++ {
+		w, err := sched.PickWorkerFor("m", spi.RequestSize{PromptTokens: 100000, MaxOutputTokens: 1024})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if w.ID != "large" {
+			t.Fatalf("expected large, got %s", w.ID)
+		}
+	}
+	if _, err := sched.PickWorkerFor("m", spi.RequestSize{PromptTokens: 200000}); err == nil {
+		t.Fatalf("expected no worker when nothing fits")
+	}
+}
+
+func TestAdaptiveConcurrencyBacksOffAndRecovers(t *testing.T) {
+	reg := NewRegistry()
+	labels := map[string]bool{"m": true}
+	reg.Add(&Worker{ID: "w1", Labels: labels, MaxConcurrency: 4})
+	var changes []int
+	reg.EnableAdaptiveConcurrency(AdaptiveConcurrency{OnChange: func(id string, limit int) { changes = append(changes, limit) }})
+	w := reg.WorkersForLabel("m")[0]
+	reg.ObserveLatency("w1", "m", 100*time.Millisecond)
+	if got := w.EffectiveConcurrency(); got != 4 {
+		t.Fatalf("expected full concurrency after a fast sample, got %d", got)
+	}
+	reg.ObserveLatency("w1", "m", time.Second)
+	reg.ObserveFailure("w1", "m")
+	reg.ObserveFailure("w1", "m")
+	if got := w.EffectiveConcurrency(); got != 1 {
+		t.Fatalf("expected concurrency to back off to 1, got %d", got)
+	}
+	w.InFlight = 1
+	if len(reg.WorkersForLabel("m")) != 0 {
+		t.Fatalf("expected worker at its effective limit to be excluded")
+	}
+	if _, err := NewScoreScheduler(reg, DefaultExactMatchScorer{}).PickWorker("m"); err == nil {
+		t.Fatalf("expected scheduler to respect the effective limit")
+	}
+	w.InFlight = 0
+	for i := 0; i < 50; i++ {
+		reg.ObserveLatency("w1", "m", 100*time.Millisecond)
+	}
+	if got := w.EffectiveConcurrency(); got != 4 {
+		t.Fatalf("expected concurrency to recover to the declared maximum, got %d", got)
+	}
+	if len(changes) == 0 || changes[len(changes)-1] != 4 {
+		t.Fatalf("expected change notifications ending at 4, got %v", changes)
+	}
+}
+
+func TestAdaptiveConcurrencyIsPerModel(t *testing.T) {
+	reg := NewRegistry()
+	reg.Add(&Worker{ID: "w1", Labels: map[string]bool{"slow": true, "fast": true}, MaxConcurrency: 4})
+	reg.EnableAdaptiveConcurrency(AdaptiveConcurrency{})
+	reg.ObserveLatency("w1", "fast", 100*time.Millisecond)
+	reg.ObserveLatency("w1", "slow", 100*time.Millisecond)
+	reg.ObserveFailure("w1", "slow")
+	reg.ObserveFailure("w1", "slow")
+	reg.ObserveFailure("w1", "slow")
+	w := reg.WorkersForLabel("fast")[0]
+	if got := w.EffectiveConcurrencyFor("slow"); got != 1 {
+		t.Fatalf("expected the failing model to back off to 1, got %d", got)
+	}
+	if got := w.EffectiveConcurrencyFor("fast"); got != 4 {
+		t.Fatalf("expected the healthy model to keep full concurrency, got %d", got)
+	}
+	w.InFlight = 2
+	if len(reg.WorkersForLabel("slow")) != 0 {
+		t.Fatalf("expected worker to be excluded for the throttled model")
+	}
+	sched := NewScoreScheduler(reg, DefaultExactMatchScorer{})
+	if _, err := sched.PickWorker("slow"); err == nil {
+		t.Fatalf("expected scheduler to respect the throttled model's limit")
+	}
+	if got, err := sched.PickWorker("fast"); err != nil || got.ID != "w1" {
+		t.Fatalf("expected the healthy model to still be scheduled on w1, got %v, %v", got, err)
+	}
+}
+
 func TestMetricsSnapshotBasic(t *testing.T) {
 	reg := NewMetricsRegistry("v", "sha", "date", func() string { return "" })
 	reg.UpsertWorker("w1", "w1", "1", "", "", 1, 0, []string{"m"})
@@ -76,6 +189,20 @@ func TestMetricsSnapshotBasic(t *testing.T) {
 	}
 }
 
+func TestMetricsAbandonedJobIsNotCounted(t *testing.T) {
+	reg := NewMetricsRegistry("v", "sha", "date", func() string { return "" })
+	reg.UpsertWorker("w1", "w1", "1", "", "", 2, 0, []string{"m"})
+	reg.RecordJobStart("w1")
+	reg.RecordJobAbandoned("w1")
+	snap := reg.Snapshot()
+	if snap.Server.JobsInflight != 0 || snap.Server.JobsCompletedTotal != 0 || snap.Server.JobsFailedTotal != 0 {
+		t.Fatalf("bad server snapshot %+v", snap.Server)
+	}
+	if w := snap.Workers[0]; w.Inflight != 0 || w.ProcessedTotal != 0 || w.FailuresTotal != 0 {
+		t.Fatalf("bad worker snapshot %+v", w)
+	}
+}
+
 func TestWSRegisterStoresWorkerName(t *testing.T) {
 	reg := NewRegistry()
 	mx := NewMetricsRegistry("test", "", "", func() string { return "" })
@@ -233,3 +360,171 @@ func TestMetricsRegistryRace(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestRelayStreamsBodyAndResponse(t *testing.T) {
+	reg := NewRegistry()
+	wk := &Worker{ID: "w1", Send: make(chan interface{}, 4), Jobs: make(map[string]chan interface{})}
+	reg.Add(wk)
+	if !reg.HasWorker("w1") || reg.HasWorker("w2") {
+		t.Fatalf("unexpected HasWorker result")
+	}
+
+	var got ctrl.HTTPProxyRequestMessage
+	var body []byte
+	go func() {
+		for m := range wk.Send {
+			switch m := m.(type) {
+			case ctrl.HTTPProxyRequestMessage:
+				got = m
+			case ctrl.HTTPProxyRequestChunkMessage:
+				body = append(body, m.Data...)
+			case ctrl.HTTPProxyRequestEndMessage:
+				wk.mu.Lock()
+				ch := wk.Jobs[m.RequestID]
+				wk.mu.Unlock()
+				ch <- ctrl.HTTPProxyResponseHeadersMessage{Type: "http_proxy_response_headers", RequestID: m.RequestID, Status: 200}
+				ch <- ctrl.HTTPProxyResponseChunkMessage{Type: "http_proxy_response_chunk", RequestID: m.RequestID, Data: []byte("got ")}
+				ch <- ctrl.HTTPProxyResponseChunkMessage{Type: "http_proxy_response_chunk", RequestID: m.RequestID, Data: body}
+				ch <- ctrl.HTTPProxyResponseEndMessage{Type: "http_proxy_response_end", RequestID: m.RequestID}
+			}
+		}
+	}()
+
+	payload := strings.Repeat("x", relayChunkSize+10)
+	var out strings.Builder
+	err := reg.Relay(context.Background(), "w1", "POST", "/ingest", map[string]string{"Content-Type": "text/plain"}, strings.NewReader(payload), &out)
+	if err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+	if got.Method != "POST" || got.Path != "/ingest" || !got.BodyStream || got.Headers["Content-Type"] != "text/plain" {
+		t.Fatalf("unexpected request %+v", got)
+	}
+	if out.String() != "got "+payload {
+		t.Fatalf("unexpected response of %d bytes", out.Len())
+	}
+	if err := reg.Relay(context.Background(), "w2", "GET", "/", nil, nil, &out); err != ErrWorkerNotFound {
+		t.Fatalf("expected ErrWorkerNotFound, got %v", err)
+	}
+}
+
+func TestRelayFailsOnErrorStatusAndDisconnect(t *testing.T) {
+	reg := NewRegistry()
+	wk := &Worker{ID: "w1", Send: make(chan interface{}, 4), Jobs: make(map[string]chan interface{})}
+	reg.Add(wk)
+	go func() {
+		n := 0
+		for m := range wk.Send {
+			req, ok := m.(ctrl.HTTPProxyRequestMessage)
+			if !ok {
+				continue
+			}
+			n++
+			if n == 2 {
+				reg.Remove("w1")
+				return
+			}
+			wk.mu.Lock()
+			ch := wk.Jobs[req.RequestID]
+			wk.mu.Unlock()
+			ch <- ctrl.HTTPProxyResponseHeadersMessage{Type: "http_proxy_response_headers", RequestID: req.RequestID, Status: 404}
+			ch <- ctrl.HTTPProxyResponseChunkMessage{Type: "http_proxy_response_chunk", RequestID: req.RequestID, Data: []byte("not found")}
+			ch <- ctrl.HTTPProxyResponseEndMessage{Type: "http_proxy_response_end", RequestID: req.RequestID}
+		}
+	}()
+	var out strings.Builder
+	if err := reg.Relay(context.Background(), "w1", "GET", "/missing", nil, nil, &out); err == nil || out.Len() != 0 {
+		t.Fatalf("expected error status to fail without output, got %v %q", err, out.String())
+	}
+	if err := reg.Relay(context.Background(), "w1", "GET", "/gone", nil, nil, &out); err != ErrWorkerGone {
+		t.Fatalf("expected ErrWorkerGone, got %v", err)
+	}
+}
+
+func TestRelayBlockedOnFullQueueFailsWhenWorkerIsRemoved(t *testing.T) {
+	reg := NewRegistry()
+	wk := &Worker{ID: "w1", Send: make(chan interface{}, 1), Jobs: make(map[string]chan interface{})}
+	reg.Add(wk)
+	// Nothing drains Send, so the request chunks block once it is full.
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- reg.Relay(context.Background(), "w1", "POST", "/ingest", nil, strings.NewReader(strings.Repeat("x", 4*relayChunkSize)), io.Discard)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	reg.Remove("w1")
+	select {
+	case err := <-errCh:
+		if err != ErrWorkerGone {
+			t.Fatalf("expected ErrWorkerGone, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("relay did not return after the worker was removed")
+	}
+}
+
+func TestWSNegotiatesChunkEncodingAndDecodesChunks(t *testing.T) {
+	reg := NewRegistry()
+	mx := NewMetricsRegistry("test", "", "", func() string { return "" })
+	srv := httptest.NewServer(WSHandler(reg, mx, "", nil))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	wsURL := strings.Replace(srv.URL, "http", "ws", 1)
+	c, resp, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{CompressionMode: websocket.CompressionNoContextTakeover})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer func() { _ = c.Close(websocket.StatusNormalClosure, "") }()
+	if ext := resp.Header.Get("Sec-WebSocket-Extensions"); !strings.Contains(ext, "permessage-deflate") {
+		t.Fatalf("permessage-deflate not negotiated: %q", ext)
+	}
+	rm := ctrl.RegisterMessage{Type: "register", WorkerID: "w1", Models: []string{"m"}, MaxConcurrency: 1, ChunkEncodings: []string{"br", "gzip", "zstd"}}
+	b, _ := json.Marshal(rm)
+	if err := c.Write(ctx, websocket.MessageText, b); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_, data, err := c.Read(ctx)
+	if err != nil {
+		t.Fatalf("read ack: %v", err)
+	}
+	var ack ctrl.RegisterAckMessage
+	if err := json.Unmarshal(data, &ack); err != nil || ack.Type != "register_ack" || ack.ChunkEncoding != "zstd" {
+		t.Fatalf("unexpected ack %s", data)
+	}
+
+	var wk *Worker
+	for i := 0; i < 50 && wk == nil; i++ {
+		reg.mu.RLock()
+		wk = reg.workers["w1"]
+		reg.mu.RUnlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if wk == nil {
+		t.Fatal("worker not registered")
+	}
+	ch := make(chan interface{}, 4)
+	wk.AddJob("r1", ch)
+	wk.AddJob("r2", ch)
+
+	plain := []byte(strings.Repeat(`{"text":"docling output"}`, 100))
+	enc, encoding := chunkenc.Encode(ack.ChunkEncoding, plain)
+	if encoding != "zstd" {
+		t.Fatalf("chunk not compressed")
+	}
+	for _, m := range []ctrl.HTTPProxyResponseChunkMessage{
+		{Type: "http_proxy_response_chunk", RequestID: "r1", Data: enc, Encoding: encoding},
+		{Type: "http_proxy_response_chunk", RequestID: "r2", Data: []byte("garbage"), Encoding: "zstd"},
+	} {
+		b, _ := json.Marshal(m)
+		if err := c.Write(ctx, websocket.MessageText, b); err != nil {
+			t.Fatalf("write chunk: %v", err)
+		}
+	}
+	got := (<-ch).(ctrl.HTTPProxyResponseChunkMessage)
+	if string(got.Data) != string(plain) || got.Encoding != "" {
+		t.Fatalf("chunk not decoded: %d bytes, encoding %q", len(got.Data), got.Encoding)
+	}
+	end, ok := (<-ch).(ctrl.HTTPProxyResponseEndMessage)
+	if !ok || end.RequestID != "r2" || end.Error == nil || end.Error.Code != "chunk_decode_error" {
+		t.Fatalf("expected decode error end, got %+v", end)
+	}
+}
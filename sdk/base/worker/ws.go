@@ -11,6 +11,7 @@ import (
 	"github.com/gaspardpetit/nfrx/core/logx"
 	ctrl "github.com/gaspardpetit/nfrx/sdk/api/control"
 	"github.com/gaspardpetit/nfrx/sdk/api/spi"
+	"github.com/gaspardpetit/nfrx/sdk/base/chunkenc"
 	"strconv"
 )
 
@@ -24,7 +25,8 @@ func WSHandler(reg *Registry, metrics *MetricsRegistry, clientKey string, state
 			http.Error(w, "draining", http.StatusServiceUnavailable)
 			return
 		}
-		c, err := websocket.Accept(w, r, nil)
+		// permessage-deflate is used with agents that offer it.
+		c, err := websocket.Accept(w, r, &websocket.AcceptOptions{CompressionMode: websocket.CompressionNoContextTakeover})
 		if err != nil {
 			logx.Log.Error().Err(err).Str("remote", r.RemoteAddr).Msg("ws accept")
 			return
@@ -84,10 +86,17 @@ func WSHandler(reg *Registry, metrics *MetricsRegistry, clientKey string, state
 				}
 			}
 		}
-		wk := &Worker{ID: rm.WorkerID, Name: name, Labels: map[string]bool{}, MaxConcurrency: rm.MaxConcurrency, PreferredBatchSize: prefBatch, InFlight: 0, LastHeartbeat: time.Now(), Send: make(chan interface{}, 32), Jobs: make(map[string]chan interface{})}
+		wk := &Worker{ID: rm.WorkerID, Name: name, Labels: map[string]bool{}, MaxConcurrency: rm.MaxConcurrency, PreferredBatchSize: prefBatch, Pricing: mergePricing(spi.WorkerPricing{}, rm.AgentConfig), InFlight: 0, LastHeartbeat: time.Now(), Send: make(chan interface{}, 32), Jobs: make(map[string]chan interface{})}
+		if limits, ok := parseModelLimits(rm.AgentConfig); ok {
+			wk.Limits = limits
+		}
 		for _, m := range rm.Models {
 			wk.Labels[m] = true
 		}
+		// The ack is queued before the worker can be picked, so the agent
+		// knows the chunk encoding before its first request.
+		chunkEnc := chunkenc.Negotiate(rm.ChunkEncodings)
+		wk.Send <- ctrl.RegisterAckMessage{Type: "register_ack", ChunkEncoding: chunkEnc}
 		// Add worker and update server readiness if this is the first one
 		reg.Add(wk)
 		if state != nil && reg.WorkerCount() == 1 {
@@ -96,12 +105,13 @@ func WSHandler(reg *Registry, metrics *MetricsRegistry, clientKey string, state
 		}
 		metrics.UpsertWorker(wk.ID, wk.Name, rm.Version, rm.BuildSHA, rm.BuildDate, rm.MaxConcurrency, prefBatch, rm.Models)
 		metrics.SetWorkerHostInfo(wk.ID, rm.AgentConfig)
+		metrics.SetWorkerPricing(wk.ID, wk.Pricing)
 		status := StatusIdle
 		if rm.MaxConcurrency == 0 {
 			status = StatusNotReady
 		}
 		metrics.SetWorkerStatus(wk.ID, status)
-		logx.Log.Info().Str("worker_id", wk.ID).Str("worker_name", wk.Name).Int("label_count", len(wk.Labels)).Msg("registered")
+		logx.Log.Info().Str("worker_id", wk.ID).Str("worker_name", wk.Name).Int("label_count", len(wk.Labels)).Str("chunk_encoding", chunkEnc).Msg("registered")
 		defer func() {
 			reg.Remove(wk.ID)
 			metrics.RemoveWorker(wk.ID)
@@ -175,6 +185,11 @@ func WSHandler(reg *Registry, metrics *MetricsRegistry, clientKey string, state
 						}
 					}
 					wk.PreferredBatchSize = prefBatch
+					wk.Pricing = mergePricing(wk.Pricing, m.AgentConfig)
+					pricing := wk.Pricing
+					if limits, ok := parseModelLimits(m.AgentConfig); ok {
+						wk.Limits = limits
+					}
 					if m.Models != nil {
 						wk.Labels = map[string]bool{}
 						for _, mm := range m.Models {
@@ -185,6 +200,7 @@ func WSHandler(reg *Registry, metrics *MetricsRegistry, clientKey string, state
 					metrics.UpdateWorker(wk.ID, m.MaxConcurrency, prefBatch, m.Models)
 					if m.AgentConfig != nil {
 						metrics.SetWorkerHostInfo(wk.ID, m.AgentConfig)
+						metrics.SetWorkerPricing(wk.ID, pricing)
 					}
 					if m.Status != "" {
 						metrics.SetWorkerStatus(wk.ID, WorkerStatus(m.Status))
@@ -266,12 +282,25 @@ func WSHandler(reg *Registry, metrics *MetricsRegistry, clientKey string, state
 			case "http_proxy_response_chunk":
 				var m ctrl.HTTPProxyResponseChunkMessage
 				if err := json.Unmarshal(msg, &m); err == nil {
+					data, derr := chunkenc.Decode(m.Encoding, m.Data)
 					wk.mu.Lock()
 					ch, ok := wk.Jobs[m.RequestID]
+					if ok && derr != nil {
+						delete(wk.Jobs, m.RequestID)
+					}
 					wk.mu.Unlock()
-					if ok {
-						ch <- m
+					if !ok {
+						continue
+					}
+					if derr != nil {
+						// The rest of the body cannot be trusted either.
+						logx.Log.Warn().Err(derr).Str("worker_id", wk.ID).Str("request_id", m.RequestID).Str("encoding", m.Encoding).Msg("ws decode chunk")
+						ch <- ctrl.HTTPProxyResponseEndMessage{Type: "http_proxy_response_end", RequestID: m.RequestID, Error: &ctrl.HTTPProxyError{Code: "chunk_decode_error", Message: derr.Error()}}
+						close(ch)
+						continue
 					}
+					m.Data, m.Encoding = data, ""
+					ch <- m
 				} else {
 					logx.Log.Warn().Err(err).Str("worker_id", wk.ID).Str("worker_name", wk.Name).Str("type", env.Type).Int("body_bytes", len(msg)).Msg("ws decode message")
 					if evt := logx.Log.Debug(); evt.Enabled() {
@@ -0,0 +1,235 @@
+// Package jobs is a client for the nfrx jobs and transfer APIs. It covers
+// both sides of a job: clients that submit work, follow its events and
+// exchange payloads and results, and workers that claim jobs and report on
+// them. Worker wraps the worker side in a ready-made claim loop.
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client calls the jobs API of an nfrx server. It is safe for concurrent use.
+type Client struct {
+	// BaseURL is the server root, such as "https://nfrx.example.com". API
+	// paths and the relative transfer URLs returned by the server are
+	// resolved against it.
+	BaseURL string
+	// Token is sent as a bearer token when set.
+	Token string
+	// HTTPClient performs requests; nil uses http.DefaultClient. A client
+	// timeout also cuts event streams and transfers short, so prefer
+	// contexts for deadlines.
+	HTTPClient *http.Client
+}
+
+// New returns a client for the server at baseURL.
+func New(baseURL, token string) *Client {
+	return &Client{BaseURL: baseURL, Token: token}
+}
+
+// Error is returned when the server answers with an unexpected status.
+type Error struct {
+	StatusCode int
+	// Code is the "error" field of the response body, if any.
+	Code string
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("jobs: unexpected status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("jobs: %s (status %d)", e.Code, e.StatusCode)
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// url resolves an API path or a transfer URL returned by the server.
+func (c *Client) url(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return strings.TrimRight(c.BaseURL, "/") + path
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url(path), body)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return req, nil
+}
+
+// send performs a request and turns non-2xx responses into *Error.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer func() { _ = resp.Body.Close() }()
+		var body struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body)
+		return nil, &Error{StatusCode: resp.StatusCode, Code: body.Error}
+	}
+	return resp, nil
+}
+
+// do sends in as JSON and decodes the response into out. It returns the
+// response status so callers can tell 204 No Content apart.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) (int, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return 0, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("jobs: decode response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+func jobPath(id string, rest ...string) string {
+	return "/api/jobs/" + url.PathEscape(id) + strings.Join(rest, "")
+}
+
+// Create submits a job.
+func (c *Client) Create(ctx context.Context, r CreateRequest) (*Created, error) {
+	var out Created
+	if _, err := c.do(ctx, http.MethodPost, "/api/jobs", r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Get returns the current state of a job.
+func (c *Client) Get(ctx context.Context, id string) (*Job, error) {
+	var out Job
+	if _, err := c.do(ctx, http.MethodGet, jobPath(id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Cancel cancels a job and returns its resulting status.
+func (c *Client) Cancel(ctx context.Context, id string) (string, error) {
+	var out struct {
+		Status string `json:"status"`
+	}
+	_, err := c.do(ctx, http.MethodPost, jobPath(id, "/cancel"), nil, &out)
+	return out.Status, err
+}
+
+// Claim asks for the next matching job, waiting up to r.MaxWaitSeconds. It
+// returns nil without error when no job became available.
+func (c *Client) Claim(ctx context.Context, r ClaimRequest) (*Claim, error) {
+	var out Claim
+	status, err := c.do(ctx, http.MethodPost, "/api/jobs/claim", r, &out)
+	if err != nil || status == http.StatusNoContent {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateStatus reports on a claimed job and returns its resulting status,
+// which is "queued" when a failure was retried. u.ClaimToken must be the
+// token of the worker's claim.
+func (c *Client) UpdateStatus(ctx context.Context, id string, u StatusUpdate) (string, error) {
+	var out struct {
+		Status string `json:"status"`
+	}
+	_, err := c.do(ctx, http.MethodPost, jobPath(id, "/status"), u, &out)
+	return out.Status, err
+}
+
+// AcknowledgeCancel tells the server that the worker has stopped working on a
+// job that was canceled while it held it under claimToken.
+func (c *Client) AcknowledgeCancel(ctx context.Context, id, claimToken string) error {
+	_, err := c.UpdateStatus(ctx, id, StatusUpdate{ClaimToken: claimToken, State: StatusCanceled})
+	return err
+}
+
+// AppendPartial publishes an incremental result of a job running under the
+// claim identified by claimToken, such as a transcript segment, to the job's
+// event subscribers and returns its sequence number.
+func (c *Client) AppendPartial(ctx context.Context, id, claimToken string, data any) (int64, error) {
+	var out struct {
+		Seq int64 `json:"seq"`
+	}
+	in := map[string]any{"claim_token": claimToken, "data": data}
+	_, err := c.do(ctx, http.MethodPost, jobPath(id, "/partial"), in, &out)
+	return out.Seq, err
+}
+
+// Heartbeat extends the lease of the claim identified by claimToken and
+// returns the new lease deadline, or the zero time when the server does not
+// use leases.
+func (c *Client) Heartbeat(ctx context.Context, id, claimToken string) (time.Time, error) {
+	var out struct {
+		LeaseExpiresAt string `json:"lease_expires_at"`
+	}
+	in := map[string]any{"claim_token": claimToken}
+	if _, err := c.do(ctx, http.MethodPost, jobPath(id, "/heartbeat"), in, &out); err != nil {
+		return time.Time{}, err
+	}
+	return parseTime(out.LeaseExpiresAt), nil
+}
+
+// RequestPayload opens a payload channel; the worker downloads the payload
+// from its ReaderURL once the client has uploaded it.
+func (c *Client) RequestPayload(ctx context.Context, id string, r TransferRequest) (*Channel, error) {
+	var out Channel
+	if _, err := c.do(ctx, http.MethodPost, jobPath(id, "/payload"), r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RequestResult opens a result channel; the worker uploads the result to its
+// WriterURL.
+func (c *Client) RequestResult(ctx context.Context, id string, r TransferRequest) (*Channel, error) {
+	var out Channel
+	if _, err := c.do(ctx, http.MethodPost, jobPath(id, "/result"), r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func parseTime(v string) time.Time {
+	t, _ := time.Parse(time.RFC3339, v)
+	return t
+}
@@ -0,0 +1,139 @@
+package jobs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Events follows the event stream of a job, calling fn for each event in
+// order: an initial "status" event and the buffered "partial" events, then
+// "status", "payload", "result" and "partial" events as the job progresses.
+// It returns nil when the server ends the stream, which it does after the job
+// finishes, or the first error returned by fn.
+func (c *Client) Events(ctx context.Context, id string, fn func(Event) error) error {
+	return c.EventsFrom(ctx, id, "", fn)
+}
+
+// EventsFrom is Events resuming after lastEventID, the ID of the last
+// "partial" event received on a previous stream, so that only newer partial
+// results are replayed. A "partial_gap" event reports partial results that
+// are no longer buffered.
+func (c *Client) EventsFrom(ctx context.Context, id, lastEventID string, fn func(Event) error) error {
+	return c.stream(ctx, jobPath(id, "/events"), lastEventID, fn)
+}
+
+// WorkflowEvents follows the status events of every job in a workflow until
+// the workflow finishes.
+func (c *Client) WorkflowEvents(ctx context.Context, workflowID string, fn func(Event) error) error {
+	return c.stream(ctx, "/api/jobs/workflows/"+url.PathEscape(workflowID)+"/events", "", fn)
+}
+
+// Stream keeps a claim stream open, calling fn with each job the server
+// assigns to this worker. The worker must handle the job as it would one
+// returned by Claim. When a job held by this worker is canceled, onCancel, if
+// set, is called with its ID; Worker.Cancel is a suitable callback. Stream
+// runs until ctx is done, the server closes the stream or fn fails.
+func (c *Client) Stream(ctx context.Context, r ClaimRequest, fn func(*Claim) error, onCancel func(jobID string)) error {
+	q := url.Values{}
+	for _, t := range r.Types {
+		q.Add("types", t)
+	}
+	if r.WorkerID != "" {
+		q.Set("worker_id", r.WorkerID)
+	}
+	if r.WorkerGroup != "" {
+		q.Set("worker_group", r.WorkerGroup)
+	}
+	path := "/api/jobs/stream"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	return c.stream(ctx, path, "", func(ev Event) error {
+		switch ev.Type {
+		case "job":
+			var claim Claim
+			if err := ev.Decode(&claim); err != nil {
+				return err
+			}
+			return fn(&claim)
+		case "cancel":
+			var body struct {
+				JobID string `json:"job_id"`
+			}
+			if err := ev.Decode(&body); err != nil {
+				return err
+			}
+			if onCancel != nil {
+				onCancel(body.JobID)
+			}
+		}
+		return nil
+	})
+}
+
+func (c *Client) stream(ctx context.Context, path, lastEventID string, fn func(Event) error) error {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	err = readEvents(resp.Body, fn)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// readEvents parses a text/event-stream body, dispatching each event to fn.
+// Comments are skipped and multi-line data is joined with newlines.
+func readEvents(r io.Reader, fn func(Event) error) error {
+	br := bufio.NewReader(r)
+	var ev Event
+	var data []string
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && line == "" {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if len(data) > 0 {
+				ev.Data = []byte(strings.Join(data, "\n"))
+				if err := fn(ev); err != nil {
+					return err
+				}
+			}
+			ev, data = Event{ID: ev.ID}, nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			ev.Type = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			ev.ID = value
+		}
+	}
+}
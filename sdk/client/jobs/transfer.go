@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Upload streams body to a transfer URL, such as the URL of a "payload" event
+// or the WriterURL of a result channel. It returns once the reader on the
+// other side has received everything, or when ctx is done.
+func (c *Client) Upload(ctx context.Context, transferURL string, body io.Reader) error {
+	return c.UploadEncoded(ctx, transferURL, body, "")
+}
+
+// UploadEncoded is Upload for a body already compressed with encoding, such
+// as "gzip", which the server passes on to readers as Content-Encoding.
+func (c *Client) UploadEncoded(ctx context.Context, transferURL string, body io.Reader, encoding string) error {
+	req, err := c.newRequest(ctx, http.MethodPost, transferURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Download opens a transfer URL, such as the URL of a "result" event or the
+// ReaderURL of a payload channel. The server answers once a writer has
+// attached, so the call blocks until then. Canceling ctx aborts the transfer;
+// the caller must close the returned body. Reading the body to the end fails
+// with ErrChecksumMismatch when it does not match the digest the server
+// sends along. A gzip body is decoded on the fly by the HTTP client; the
+// digest covers the encoded bytes, so it is not checked then.
+func (c *Client) Download(ctx context.Context, transferURL string) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, transferURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	return &verifiedBody{resp: resp, hash: sha256.New()}, nil
+}
+
+// ErrChecksumMismatch is returned at the end of a downloaded body whose
+// SHA-256 differs from the one reported by the server.
+var ErrChecksumMismatch = errors.New("jobs: transfer checksum mismatch")
+
+// verifiedBody hashes a download and, at its end, compares the digest with
+// the X-Transfer-Sha256 header or trailer.
+type verifiedBody struct {
+	resp *http.Response
+	hash hash.Hash
+}
+
+func (b *verifiedBody) Read(p []byte) (int, error) {
+	n, err := b.resp.Body.Read(p)
+	b.hash.Write(p[:n])
+	if errors.Is(err, io.EOF) && !b.resp.Uncompressed {
+		// Trailers are only available once the body has been read.
+		want := b.resp.Trailer.Get("X-Transfer-Sha256")
+		if want == "" {
+			want = b.resp.Header.Get("X-Transfer-Sha256")
+		}
+		if want != "" && !strings.EqualFold(want, hex.EncodeToString(b.hash.Sum(nil))) {
+			return n, ErrChecksumMismatch
+		}
+	}
+	return n, err
+}
+
+func (b *verifiedBody) Close() error {
+	return b.resp.Body.Close()
+}
+
+// WithToken adds a channel token to a transfer URL, so that it can be handed
+// to a party that does not hold the API keys.
+func WithToken(transferURL, token string) string {
+	if token == "" {
+		return transferURL
+	}
+	sep := "?"
+	if strings.Contains(transferURL, "?") {
+		sep = "&"
+	}
+	return transferURL + sep + "token=" + url.QueryEscape(token)
+}
+
+// Offset returns how many bytes a buffered channel already holds, which is
+// where an interrupted upload resumes.
+func (c *Client) Offset(ctx context.Context, transferURL string) (int64, error) {
+	req, err := c.newRequest(ctx, http.MethodHead, transferURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	return strconv.ParseInt(resp.Header.Get("X-Transfer-Offset"), 10, 64)
+}
+
+// UploadAt resumes an upload to a buffered channel: body holds the bytes of a
+// total-byte payload or result starting at offset, usually the value returned
+// by Offset.
+func (c *Client) UploadAt(ctx context.Context, transferURL string, body io.Reader, offset, total int64) error {
+	req, err := c.newRequest(ctx, http.MethodPost, transferURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, max(total-1, offset), total))
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// DownloadFrom reads a buffered channel from offset on, for instance to
+// resume a download that was cut short.
+func (c *Client) DownloadFrom(ctx context.Context, transferURL string, offset int64) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, transferURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
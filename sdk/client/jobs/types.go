@@ -0,0 +1,218 @@
+package jobs
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Job states reported by the server.
+const (
+	StatusQueued          = "queued"
+	StatusScheduled       = "scheduled"
+	StatusBlocked         = "blocked"
+	StatusClaimed         = "claimed"
+	StatusAwaitingPayload = "awaiting_payload"
+	StatusRunning         = "running"
+	StatusAwaitingResult  = "awaiting_result"
+	StatusCompleted       = "completed"
+	StatusFailed          = "failed"
+	StatusCanceled        = "canceled"
+)
+
+// Cancellation acknowledgement states of a job canceled while a worker held
+// it.
+const (
+	CancelRequested    = "requested"
+	CancelAcknowledged = "acknowledged"
+)
+
+// IsTerminal reports whether a job in status s will not change again.
+func IsTerminal(s string) bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCanceled
+}
+
+// CreateRequest describes a job to submit.
+type CreateRequest struct {
+	Type          string         `json:"type"`
+	Priority      int            `json:"priority,omitempty"`
+	Metadata      map[string]any `json:"metadata,omitempty"`
+	WorkerID      string         `json:"worker_id,omitempty"`
+	WorkerGroup   string         `json:"worker_group,omitempty"`
+	Retry         *RetryPolicy   `json:"retry,omitempty"`
+	NotBefore     *time.Time     `json:"not_before,omitempty"`
+	Schedule      string         `json:"schedule,omitempty"`
+	DependsOn     []string       `json:"depends_on,omitempty"`
+	WorkflowID    string         `json:"workflow_id,omitempty"`
+	RetainResults bool           `json:"retain_results,omitempty"`
+	Webhook       *Webhook       `json:"webhook,omitempty"`
+}
+
+// Created is the server's answer to CreateRequest.
+type Created struct {
+	JobID     string `json:"job_id"`
+	Status    string `json:"status"`
+	NotBefore string `json:"not_before,omitempty"`
+}
+
+// RetryPolicy controls how often a failed job is retried.
+type RetryPolicy struct {
+	MaxAttempts       int     `json:"max_attempts"`
+	BackoffSeconds    float64 `json:"backoff_seconds,omitempty"`
+	BackoffMultiplier float64 `json:"backoff_multiplier,omitempty"`
+	MaxBackoffSeconds float64 `json:"max_backoff_seconds,omitempty"`
+}
+
+// Webhook receives a signed POST for each status change of a job.
+type Webhook struct {
+	URL          string `json:"url"`
+	Secret       string `json:"secret,omitempty"`
+	TerminalOnly bool   `json:"terminal_only,omitempty"`
+}
+
+// Job is the client view of a job.
+type Job struct {
+	ID                 string                   `json:"id"`
+	Type               string                   `json:"type"`
+	Status             string                   `json:"status"`
+	Priority           int                      `json:"priority"`
+	Metadata           map[string]any           `json:"metadata,omitempty"`
+	WorkerID           string                   `json:"worker_id,omitempty"`
+	WorkerGroup        string                   `json:"worker_group,omitempty"`
+	ClaimedWorkerID    string                   `json:"claimed_worker_id,omitempty"`
+	ClaimedWorkerGroup string                   `json:"claimed_worker_group,omitempty"`
+	Attempts           int                      `json:"attempts,omitempty"`
+	LeaseExpiresAt     string                   `json:"lease_expires_at,omitempty"`
+	DependsOn          []string                 `json:"depends_on,omitempty"`
+	WorkflowID         string                   `json:"workflow_id,omitempty"`
+	CancelState        string                   `json:"cancel_state,omitempty"`
+	CancelAckAt        string                   `json:"cancel_acknowledged_at,omitempty"`
+	Progress           map[string]any           `json:"progress,omitempty"`
+	Error              *JobError                `json:"error,omitempty"`
+	Payloads           map[string]*TransferInfo `json:"payloads,omitempty"`
+	Results            map[string]*TransferInfo `json:"results,omitempty"`
+	QueuePosition      int                      `json:"queue_position,omitempty"`
+	CreatedAt          string                   `json:"created_at"`
+	UpdatedAt          string                   `json:"updated_at"`
+}
+
+// JobError is the failure reported for a job. A Handler may return one to
+// choose the code recorded on the job.
+type JobError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *JobError) Error() string {
+	if e.Message == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Message
+}
+
+// TransferInfo announces a payload or result channel to the client. URL is
+// where the client uploads the payload or downloads the result.
+type TransferInfo struct {
+	ChannelID  string         `json:"channel_id"`
+	Method     string         `json:"method"`
+	URL        string         `json:"url"`
+	ExpiresAt  string         `json:"expires_at"`
+	Key        string         `json:"key,omitempty"`
+	Mode       string         `json:"mode,omitempty"`
+	Properties map[string]any `json:"properties,omitempty"`
+	// Size, ContentType, ContentEncoding and SHA256 describe the body when
+	// the channel's creator declared them, or as stored for retained
+	// results.
+	Size            int64  `json:"size,omitempty"`
+	ContentType     string `json:"content_type,omitempty"`
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	SHA256          string `json:"sha256,omitempty"`
+	MaxBytes        int64  `json:"max_bytes,omitempty"`
+	// Token grants the client's side of the channel without the API keys;
+	// see WithToken.
+	Token          string `json:"token,omitempty"`
+	TokenExpiresAt string `json:"token_expires_at,omitempty"`
+}
+
+// ClaimRequest selects the jobs a worker accepts.
+type ClaimRequest struct {
+	Types          []string `json:"types,omitempty"`
+	MaxWaitSeconds int      `json:"max_wait_seconds,omitempty"`
+	WorkerID       string   `json:"worker_id,omitempty"`
+	WorkerGroup    string   `json:"worker_group,omitempty"`
+}
+
+// Claim is a job handed to a worker. Its ClaimToken must accompany status
+// updates and heartbeats; it stops being accepted once the job is re-queued.
+type Claim struct {
+	JobID              string         `json:"job_id"`
+	Type               string         `json:"type"`
+	Priority           int            `json:"priority"`
+	Metadata           map[string]any `json:"metadata,omitempty"`
+	WorkerID           string         `json:"worker_id,omitempty"`
+	WorkerGroup        string         `json:"worker_group,omitempty"`
+	ClaimedWorkerID    string         `json:"claimed_worker_id,omitempty"`
+	ClaimedWorkerGroup string         `json:"claimed_worker_group,omitempty"`
+	Attempt            int            `json:"attempt"`
+	ClaimToken         string         `json:"claim_token"`
+	LeaseExpiresAt     string         `json:"lease_expires_at,omitempty"`
+}
+
+// StatusUpdate is a worker's report on a claimed job.
+type StatusUpdate struct {
+	ClaimToken string         `json:"claim_token"`
+	State      string         `json:"state"`
+	Progress   map[string]any `json:"progress,omitempty"`
+	Error      *JobError      `json:"error,omitempty"`
+}
+
+// TransferRequest opens a payload or result channel. An empty Key uses the
+// server default, "payload" or "result". Mode "buffered" spools the body on
+// the server so transfers can resume and Readers clients can fetch it; the
+// default "stream" pipes one writer into one reader. Size, ContentType and
+// SHA256 (hex) declare the body; the server fails a transfer that does not
+// match them or exceeds MaxBytes. ContentEncoding declares how the body is
+// encoded, such as "gzip"; readers are served it as Content-Encoding.
+type TransferRequest struct {
+	Key             string         `json:"key,omitempty"`
+	Mode            string         `json:"mode,omitempty"`
+	Readers         int            `json:"readers,omitempty"`
+	Properties      map[string]any `json:"properties,omitempty"`
+	Size            int64          `json:"size,omitempty"`
+	ContentType     string         `json:"content_type,omitempty"`
+	ContentEncoding string         `json:"content_encoding,omitempty"`
+	SHA256          string         `json:"sha256,omitempty"`
+	MaxBytes        int64          `json:"max_bytes,omitempty"`
+}
+
+// Channel is a transfer channel opened by a worker. ReaderURL is set for
+// payloads and WriterURL for results.
+type Channel struct {
+	Key        string         `json:"key"`
+	ChannelID  string         `json:"channel_id"`
+	ReaderURL  string         `json:"reader_url,omitempty"`
+	WriterURL  string         `json:"writer_url,omitempty"`
+	ExpiresAt  string         `json:"expires_at"`
+	Mode       string         `json:"mode,omitempty"`
+	Token      string         `json:"token,omitempty"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+// Partial is an incremental result appended by a worker, delivered as the data
+// of "partial" events.
+type Partial struct {
+	Seq       int64           `json:"seq"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Event is one server-sent event.
+type Event struct {
+	ID   string
+	Type string
+	Data json.RawMessage
+}
+
+// Decode unmarshals the event data into v.
+func (e Event) Decode(v any) error {
+	return json.Unmarshal(e.Data, v)
+}
@@ -0,0 +1,304 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxWait    = 30 * time.Second
+	defaultRetryDelay = time.Second
+	maxRetryDelay     = 30 * time.Second
+	minHeartbeat      = time.Second
+)
+
+// ErrJobCanceled is the context cause seen by a handler whose job was
+// canceled.
+var ErrJobCanceled = errors.New("jobs: job canceled")
+
+// Handler processes one claimed job. It reads the job payload and writes the
+// job result, which is uploaded as it is written. Returning an error fails
+// the job and discards the partial result; a *JobError sets the code recorded
+// on it, other errors are recorded as "handler_error".
+type Handler func(ctx context.Context, job *Claim, payload io.Reader, result io.Writer) error
+
+// Worker claims jobs in a loop and runs them through a Handler, taking care
+// of the transfers and status reports around it: the job moves to claimed,
+// its payload is downloaded, it runs while its result is uploaded, and it
+// is marked completed or failed. While the handler runs, the lease of the
+// job is renewed with heartbeats.
+//
+// When a job is canceled, the handler's context is canceled with a cause
+// that satisfies errors.Is(context.Cause(ctx), ErrJobCanceled) and, once the
+// handler returns, the cancellation is acknowledged to the server. The worker
+// notices from a failed heartbeat or transfer, or immediately when Cancel is
+// called, for instance from the onCancel callback of Client.Stream.
+type Worker struct {
+	Client      *Client
+	Types       []string
+	WorkerID    string
+	WorkerGroup string
+	// MaxWait is how long each claim waits for a job (default 30s).
+	MaxWait time.Duration
+	// RetryDelay is the initial pause after a failed claim; it doubles up to
+	// 30s while failures persist (default 1s).
+	RetryDelay time.Duration
+	// NoPayload skips the payload transfer for jobs that carry everything
+	// in their metadata; the handler then reads an empty payload.
+	NoPayload bool
+	Handler   Handler
+
+	mu      sync.Mutex
+	running map[string]context.CancelCauseFunc
+}
+
+// Cancel stops the handler running the given job, if any.
+func (w *Worker) Cancel(jobID string) {
+	w.mu.Lock()
+	stop := w.running[jobID]
+	w.mu.Unlock()
+	if stop != nil {
+		stop(ErrJobCanceled)
+	}
+}
+
+func (w *Worker) track(jobID string, stop context.CancelCauseFunc) {
+	w.mu.Lock()
+	if w.running == nil {
+		w.running = make(map[string]context.CancelCauseFunc)
+	}
+	w.running[jobID] = stop
+	w.mu.Unlock()
+}
+
+func (w *Worker) untrack(jobID string) {
+	w.mu.Lock()
+	delete(w.running, jobID)
+	w.mu.Unlock()
+}
+
+// Run claims and processes jobs until ctx is done, then returns ctx.Err().
+// Failures to reach the server are retried with backoff.
+func (w *Worker) Run(ctx context.Context) error {
+	delay := w.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	backoff := delay
+	for ctx.Err() == nil {
+		if _, err := w.RunOnce(ctx); err == nil || ctx.Err() != nil {
+			backoff = delay
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryDelay)
+	}
+	return ctx.Err()
+}
+
+// RunOnce claims at most one job and processes it. It reports whether a job
+// was claimed. A handler failure is reported to the server and is not an
+// error of RunOnce; failing to claim or to transfer is.
+func (w *Worker) RunOnce(ctx context.Context) (bool, error) {
+	wait := w.MaxWait
+	if wait <= 0 {
+		wait = defaultMaxWait
+	}
+	claim, err := w.Client.Claim(ctx, ClaimRequest{
+		Types:          w.Types,
+		MaxWaitSeconds: int(wait / time.Second),
+		WorkerID:       w.WorkerID,
+		WorkerGroup:    w.WorkerGroup,
+	})
+	if err != nil || claim == nil {
+		return false, err
+	}
+	if err := w.Process(ctx, claim); err != nil {
+		// Best effort: let the client know instead of waiting for the lease
+		// to expire.
+		_, _ = w.Client.UpdateStatus(context.WithoutCancel(ctx), claim.JobID, StatusUpdate{
+			ClaimToken: claim.ClaimToken,
+			State:      StatusFailed,
+			Error:      &JobError{Code: "worker_error", Message: err.Error()},
+		})
+		return true, err
+	}
+	return true, nil
+}
+
+// Process runs a job that was already claimed, for instance through Stream.
+// A job canceled while it runs is acknowledged rather than reported as an
+// error.
+func (w *Worker) Process(ctx context.Context, claim *Claim) error {
+	jctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+	w.track(claim.JobID, stop)
+	defer w.untrack(claim.JobID)
+	if lease := parseTime(claim.LeaseExpiresAt); !lease.IsZero() {
+		go w.heartbeat(jctx, stop, claim, lease)
+	}
+	err := w.process(jctx, claim)
+	if err == nil {
+		return nil
+	}
+	var apiErr *Error
+	if errors.Is(context.Cause(jctx), ErrJobCanceled) ||
+		(errors.As(err, &apiErr) && (apiErr.Code == "transfer_canceled" || apiErr.StatusCode == http.StatusConflict)) {
+		// The acknowledgement only succeeds if the job was indeed canceled.
+		if w.Client.AcknowledgeCancel(context.WithoutCancel(ctx), claim.JobID, claim.ClaimToken) == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (w *Worker) process(ctx context.Context, claim *Claim) error {
+	c := w.Client
+	if _, err := c.UpdateStatus(ctx, claim.JobID, StatusUpdate{ClaimToken: claim.ClaimToken, State: StatusClaimed}); err != nil {
+		return err
+	}
+	payload := io.Reader(bytes.NewReader(nil))
+	if !w.NoPayload {
+		ch, err := c.RequestPayload(ctx, claim.JobID, TransferRequest{})
+		if err != nil {
+			return err
+		}
+		body, err := c.Download(ctx, ch.ReaderURL)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = body.Close() }()
+		payload = body
+	}
+	if _, err := c.UpdateStatus(ctx, claim.JobID, StatusUpdate{ClaimToken: claim.ClaimToken, State: StatusRunning}); err != nil {
+		return err
+	}
+
+	// The result streams to the server as the handler writes it.
+	result := &resultWriter{ctx: ctx, client: c, jobID: claim.JobID}
+	herr := w.Handler(ctx, claim, payload, result)
+	if err := result.finish(herr); err != nil {
+		return err
+	}
+	if herr != nil {
+		var jerr *JobError
+		if !errors.As(herr, &jerr) {
+			jerr = &JobError{Code: "handler_error", Message: herr.Error()}
+		}
+		_, err := c.UpdateStatus(ctx, claim.JobID, StatusUpdate{ClaimToken: claim.ClaimToken, State: StatusFailed, Error: jerr})
+		return err
+	}
+	_, err := c.UpdateStatus(ctx, claim.JobID, StatusUpdate{ClaimToken: claim.ClaimToken, State: StatusCompleted})
+	return err
+}
+
+// resultWriter uploads a handler's result as it is written. The result
+// channel is requested on the first write, so the job stays running while the
+// handler computes.
+type resultWriter struct {
+	ctx    context.Context
+	client *Client
+	jobID  string
+
+	err    error
+	pipe   *io.PipeWriter
+	done   chan error
+	cancel context.CancelFunc
+}
+
+func (r *resultWriter) Write(p []byte) (int, error) {
+	if r.pipe == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	return r.pipe.Write(p)
+}
+
+func (r *resultWriter) open() error {
+	if r.err != nil {
+		return r.err
+	}
+	ch, err := r.client.RequestResult(r.ctx, r.jobID, TransferRequest{})
+	if err != nil {
+		r.err = err
+		return err
+	}
+	ctx, cancel := context.WithCancel(r.ctx)
+	body, pipe := io.Pipe()
+	r.pipe, r.done, r.cancel = pipe, make(chan error, 1), cancel
+	go func() {
+		err := r.client.Upload(ctx, ch.WriterURL, body)
+		r.done <- err
+		// Unblocks the handler if the upload stopped reading early.
+		_ = body.CloseWithError(errors.Join(err, io.ErrClosedPipe))
+	}()
+	return nil
+}
+
+// finish ends the upload once the handler returned herr. A failed handler
+// aborts the upload so readers do not take a partial result; a successful
+// one that wrote nothing uploads an empty result.
+func (r *resultWriter) finish(herr error) error {
+	if r.pipe == nil {
+		if herr != nil && r.err == nil {
+			return nil
+		}
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	defer r.cancel()
+	select {
+	case err := <-r.done:
+		// The upload ended first; a handler error then most likely comes
+		// from writing to it.
+		if err != nil {
+			return err
+		}
+		r.done <- nil
+	default:
+	}
+	if herr != nil {
+		_ = r.pipe.CloseWithError(herr)
+		r.cancel()
+		<-r.done
+		return nil
+	}
+	_ = r.pipe.Close()
+	return <-r.done
+}
+
+// heartbeat renews the lease of a job halfway before each deadline until ctx
+// is done. A conflict means the job is no longer held by this worker, because
+// it was canceled or its lease expired, so the job context is canceled.
+func (w *Worker) heartbeat(ctx context.Context, stop context.CancelCauseFunc, claim *Claim, lease time.Time) {
+	for {
+		wait := max(time.Until(lease)/2, minHeartbeat)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		next, err := w.Client.Heartbeat(ctx, claim.JobID, claim.ClaimToken)
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+			stop(ErrJobCanceled)
+			return
+		}
+		if err != nil || next.IsZero() {
+			// Retry on the minimum interval; the lease may still be valid.
+			lease = time.Now().Add(2 * minHeartbeat)
+			continue
+		}
+		lease = next
+	}
+}
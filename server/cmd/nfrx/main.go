@@ -21,6 +21,7 @@ import (
 	"github.com/gaspardpetit/nfrx/sdk/base/inflight"
 	"github.com/gaspardpetit/nfrx/server/internal/adapters"
 	"github.com/gaspardpetit/nfrx/server/internal/config"
+	"github.com/gaspardpetit/nfrx/server/internal/jobs"
 	"github.com/gaspardpetit/nfrx/server/internal/metrics"
 	"github.com/gaspardpetit/nfrx/server/internal/plugin"
 	"github.com/gaspardpetit/nfrx/server/internal/server"
@@ -172,6 +173,17 @@ func main() {
 		serverstate.UseStore(rs)
 		logx.Log.Info().Str("addr", cfg.RedisAddr).Msg("using redis state store")
 	}
+	// Job writes reach the durable store in the background; they are
+	// flushed once the server has stopped handling requests.
+	var jobStore *jobs.WriteBehindStore
+	if cfg.JobsStore != "" {
+		js, err := jobs.OpenStore(cfg.JobsStore)
+		if err != nil {
+			logx.Log.Fatal().Err(err).Msg("open jobs store")
+		}
+		jobStore = jobs.NewWriteBehindStore(js)
+		logx.Log.Info().Msg("using durable jobs store")
+	}
 
 	stateReg := serverstate.NewRegistry()
 	var plugins []plugin.Plugin
@@ -238,7 +250,11 @@ func main() {
 			logx.Log.Warn().Str("plugin", id).Msg("unknown plugin; skipping")
 		}
 	}
-	handler := server.New(cfg, stateReg, plugins)
+	var store jobs.Store
+	if jobStore != nil {
+		store = jobStore
+	}
+	handler := server.NewWithJobStore(cfg, stateReg, plugins, store)
 	srv := &http.Server{Addr: fmt.Sprintf(":%d", cfg.Port), Handler: handler}
 	var metricsSrv *http.Server
 	if cfg.MetricsAddr != fmt.Sprintf(":%d", cfg.Port) {
@@ -284,7 +300,10 @@ func main() {
 			}(stop, waitCtx)
 		}
 	}()
+	// Closed once Shutdown has waited for the active handlers.
+	shutdown := make(chan struct{})
 	go func() {
+		defer close(shutdown)
 		<-ctx.Done()
 		if err := srv.Shutdown(context.Background()); err != nil {
 			logx.Log.Error().Err(err).Msg("server shutdown")
@@ -317,4 +336,8 @@ func main() {
 	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		logx.Log.Fatal().Err(err).Msg("server error")
 	}
+	<-shutdown
+	if jobStore != nil {
+		jobStore.Flush()
+	}
 }
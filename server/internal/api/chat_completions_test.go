@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -101,6 +102,111 @@ func TestChatCompletionsEarlyError(t *testing.T) {
 	}
 }
 
+func TestChatCompletionsRejectsRequestExceedingModelLimits(t *testing.T) {
+	wk := newTestWorker("w1", []string{"m"})
+	wk.limits = map[string]spi.ModelLimits{"m": {ContextLength: 16}}
+	h := openai.ChatCompletionsHandler(testReg{w: wk}, testSched{w: wk}, testMetrics{}, openai.Options{RequestTimeout: time.Second}, nil)
+
+	prompt := strings.Repeat("x", 200)
+	req := httptest.NewRequest(http.MethodPost, "/api/llm/v1/chat/completions", strings.NewReader(`{"model":"m","messages":[{"role":"user","content":"`+prompt+`"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "context_length_exceeded") {
+		t.Fatalf("body %q", rec.Body.String())
+	}
+	select {
+	case <-wk.send:
+		t.Fatalf("request must not be dispatched")
+	default:
+	}
+}
+
+// testBusyReg reports the workers in testMultiReg as having free capacity;
+// serving lists every connected worker regardless of load.
+type testBusyReg struct {
+	testMultiReg
+	serving []spi.WorkerRef
+}
+
+func (r testBusyReg) WorkersServing(model string) []spi.WorkerRef { return r.serving }
+
+type testBusySched struct{}
+
+func (testBusySched) PickWorker(model string) (spi.WorkerRef, error) {
+	return nil, errors.New("no worker")
+}
+
+func TestChatCompletionsAdmissionChecksBusyWorkers(t *testing.T) {
+	small := newTestWorker("small", []string{"m"})
+	small.limits = map[string]spi.ModelLimits{"m": {ContextLength: 16}}
+	big := newTestWorker("big", []string{"m"})
+	big.limits = map[string]spi.ModelLimits{"m": {ContextLength: 4096}}
+	prompt := strings.Repeat("x", 200)
+	for _, tc := range []struct {
+		name     string
+		serving  []spi.WorkerRef
+		rejected bool
+	}{
+		{"only small workers", []spi.WorkerRef{small}, true},
+		{"large worker busy", []spi.WorkerRef{small, big}, false},
+	} {
+		h := openai.ChatCompletionsHandler(testBusyReg{testMultiReg{ws: []spi.WorkerRef{small}}, tc.serving}, testBusySched{}, testMetrics{}, openai.Options{RequestTimeout: time.Second}, nil)
+		req := httptest.NewRequest(http.MethodPost, "/api/llm/v1/chat/completions", strings.NewReader(`{"model":"m","messages":[{"role":"user","content":"`+prompt+`"}]}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rejected := strings.Contains(rec.Body.String(), "context_length_exceeded"); rejected != tc.rejected {
+			t.Fatalf("%s: status %d body %q", tc.name, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+type testHedgeReg struct{ testMultiReg }
+
+func (testHedgeReg) TotalCapacity() int { return 10 }
+
+func TestChatCompletionsHedgesSlowWorker(t *testing.T) {
+	slow := newTestWorker("slow", []string{"m"})
+	fast := newTestWorker("fast", []string{"m"})
+	hedge := openai.NewHedgePolicy(50, 0.5, 0)
+	for i := 0; i < 20; i++ {
+		hedge.Observe(time.Millisecond)
+	}
+	reg := testHedgeReg{testMultiReg{ws: []spi.WorkerRef{slow, fast}}}
+	h := openai.ChatCompletionsHandler(reg, testSched{w: slow}, testMetrics{}, openai.Options{RequestTimeout: time.Second, Hedge: hedge}, nil)
+
+	go func() {
+		msg := <-fast.send
+		req := msg.(ctrl.HTTPProxyRequestMessage)
+		ch := fast.jobs[req.RequestID]
+		ch <- ctrl.HTTPProxyResponseHeadersMessage{Type: "http_proxy_response_headers", RequestID: req.RequestID, Status: 200, Headers: map[string]string{"Content-Type": "application/json"}}
+		ch <- ctrl.HTTPProxyResponseChunkMessage{Type: "http_proxy_response_chunk", RequestID: req.RequestID, Data: []byte(`{"from":"fast"}`)}
+		ch <- ctrl.HTTPProxyResponseEndMessage{Type: "http_proxy_response_end", RequestID: req.RequestID}
+	}()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/llm/v1/chat/completions", strings.NewReader(`{"model":"m"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Body.String() != `{"from":"fast"}` {
+		t.Fatalf("body %q", rec.Body.String())
+	}
+	primary := (<-slow.send).(ctrl.HTTPProxyRequestMessage)
+	select {
+	case msg := <-slow.send:
+		cancel, ok := msg.(ctrl.HTTPProxyCancelMessage)
+		if !ok || cancel.RequestID != primary.RequestID {
+			t.Fatalf("expected cancel for the slow worker, got %#v", msg)
+		}
+	default:
+		t.Fatalf("expected the losing worker to be cancelled")
+	}
+}
+
 func TestResponsesHeaders(t *testing.T) {
 	wk := newTestWorker("w1", []string{"m"})
 	h := openai.ResponsesHandler(testReg{w: wk}, testSched{w: wk}, testMetrics{}, openai.Options{RequestTimeout: time.Second}, nil)
@@ -129,12 +129,20 @@ func TestGetStateIncludesJobsSummaryAggregates(t *testing.T) {
 	if err != nil {
 		t.Fatalf("claim job: %v", err)
 	}
-	_ = claimResp.Body.Close()
+	defer func() {
+		_ = claimResp.Body.Close()
+	}()
 	if claimResp.StatusCode != http.StatusOK {
 		t.Fatalf("claim status = %d, want %d", claimResp.StatusCode, http.StatusOK)
 	}
+	var claim struct {
+		ClaimToken string `json:"claim_token"`
+	}
+	if err := json.NewDecoder(claimResp.Body).Decode(&claim); err != nil {
+		t.Fatalf("decode claim response: %v", err)
+	}
 
-	statusReq, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/jobs/"+created.JobID+"/status", strings.NewReader(`{"state":"completed"}`))
+	statusReq, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/jobs/"+created.JobID+"/status", strings.NewReader(`{"claim_token":"`+claim.ClaimToken+`","state":"completed"}`))
 	statusReq.Header.Set("Content-Type", "application/json")
 	statusResp, err := http.DefaultClient.Do(statusReq)
 	if err != nil {
@@ -14,6 +14,7 @@ type testWorker struct {
 	hb     time.Time
 	infl   int
 	embBS  int
+	limits map[string]spi.ModelLimits
 }
 
 func newTestWorker(id string, models []string) *testWorker {
@@ -44,6 +45,10 @@ func (w *testWorker) RemoveJob(id string) {
 func (w *testWorker) LastHeartbeat() time.Time { return w.hb }
 func (w *testWorker) PreferredBatchSize() int  { return w.embBS }
 func (w *testWorker) InFlight() int            { return w.infl }
+func (w *testWorker) ModelLimits(model string) (spi.ModelLimits, bool) {
+	l, ok := w.limits[model]
+	return l, ok
+}
 
 type testReg struct{ w *testWorker }
 
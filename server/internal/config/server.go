@@ -22,17 +22,35 @@ type ServerConfig struct {
 	// APIHTTPRoles are roles that, when present in X-User-Roles, grant API access
 	APIHTTPRoles []string `yaml:"api_http_roles"`
 	// ClientHTTPRoles are roles that, when present in X-User-Roles, grant client connect access
-	ClientHTTPRoles   []string `yaml:"client_http_roles"`
-	RequestTimeout    time.Duration
-	DrainTimeout      time.Duration
-	JobsSSECloseDelay time.Duration `yaml:"jobs_sse_close_delay"`
-	JobsClientTTL     time.Duration `yaml:"jobs_client_ttl"`
-	AllowedOrigins    []string
-	ConfigFile        string
-	LogLevel          string
-	RedisAddr         string
-	Plugins           []string                     `yaml:"plugins"`
-	PluginOptions     map[string]map[string]string `yaml:"plugin_options"`
+	ClientHTTPRoles    []string `yaml:"client_http_roles"`
+	RequestTimeout     time.Duration
+	DrainTimeout       time.Duration
+	JobsSSECloseDelay  time.Duration `yaml:"jobs_sse_close_delay"`
+	JobsClientTTL      time.Duration `yaml:"jobs_client_ttl"`
+	JobsStore          string        `yaml:"jobs_store"`
+	JobsRecoveryPolicy string        `yaml:"jobs_recovery_policy"`
+	JobsPriorityAging  time.Duration `yaml:"jobs_priority_aging"`
+	JobsLeaseTTL       time.Duration `yaml:"jobs_lease_ttl"`
+	JobsRetention      time.Duration `yaml:"jobs_retention"`
+	JobsMaxAttempts    int           `yaml:"jobs_max_attempts"`
+	JobsPartialHistory int           `yaml:"jobs_partial_history"`
+	JobsResultDir      string        `yaml:"jobs_result_dir"`
+	JobsResultTTL      time.Duration `yaml:"jobs_result_ttl"`
+	JobsResultMaxBytes int64         `yaml:"jobs_result_max_bytes"`
+	JobsSpoolMaxBytes  int64         `yaml:"jobs_spool_max_bytes"`
+	TransferSpoolDir   string        `yaml:"transfer_spool_dir"`
+	TransferMaxBytes   int64         `yaml:"transfer_max_bytes"`
+	TransferTokenTTL   time.Duration `yaml:"transfer_token_ttl"`
+	AllowedOrigins     []string
+	ConfigFile         string
+	LogLevel           string
+	RedisAddr          string
+	Plugins            []string                     `yaml:"plugins"`
+	PluginOptions      map[string]map[string]string `yaml:"plugin_options"`
+
+	// JobsWebhookAllowedNetworks are IPs or CIDR ranges webhooks may reach
+	// even though they are not public addresses.
+	JobsWebhookAllowedNetworks []string `yaml:"jobs_webhook_allowed_networks"`
 }
 
 // SetDefaults initializes c with built-in defaults.
@@ -58,6 +76,33 @@ func (c *ServerConfig) SetDefaults() {
 	if c.JobsClientTTL == 0 {
 		c.JobsClientTTL = 30 * time.Second
 	}
+	if c.JobsRecoveryPolicy == "" {
+		c.JobsRecoveryPolicy = "requeue"
+	}
+	if c.JobsMaxAttempts == 0 {
+		c.JobsMaxAttempts = 3
+	}
+	if c.JobsRetention == 0 {
+		c.JobsRetention = 24 * time.Hour
+	}
+	if c.JobsPartialHistory == 0 {
+		c.JobsPartialHistory = 256
+	}
+	if c.JobsResultTTL == 0 {
+		c.JobsResultTTL = time.Hour
+	}
+	if c.JobsResultMaxBytes == 0 {
+		c.JobsResultMaxBytes = 64 << 20
+	}
+	if c.JobsSpoolMaxBytes == 0 {
+		c.JobsSpoolMaxBytes = 1 << 30
+	}
+	if c.TransferMaxBytes == 0 {
+		c.TransferMaxBytes = 4 << 30
+	}
+	if c.TransferTokenTTL == 0 {
+		c.TransferTokenTTL = 15 * time.Minute
+	}
 	if c.Plugins == nil {
 		c.Plugins = []string{"*"}
 	}
@@ -118,6 +163,68 @@ func (c *ServerConfig) ApplyEnv() {
 			c.JobsClientTTL = d
 		}
 	}
+	if v := commoncfg.GetEnv("JOBS_STORE", ""); v != "" {
+		c.JobsStore = v
+	}
+	if v := commoncfg.GetEnv("JOBS_RECOVERY_POLICY", ""); v != "" {
+		c.JobsRecoveryPolicy = v
+	}
+	if v := commoncfg.GetEnv("JOBS_PRIORITY_AGING", ""); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			c.JobsPriorityAging = d
+		}
+	}
+	if v := commoncfg.GetEnv("JOBS_LEASE_TTL", ""); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			c.JobsLeaseTTL = d
+		}
+	}
+	if v := commoncfg.GetEnv("JOBS_RETENTION", ""); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			c.JobsRetention = d
+		}
+	}
+	if v := commoncfg.GetEnv("JOBS_MAX_ATTEMPTS", ""); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.JobsMaxAttempts = n
+		}
+	}
+	if v := commoncfg.GetEnv("JOBS_PARTIAL_HISTORY", ""); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			c.JobsPartialHistory = n
+		}
+	}
+	if v := commoncfg.GetEnv("JOBS_RESULT_DIR", ""); v != "" {
+		c.JobsResultDir = v
+	}
+	if v := commoncfg.GetEnv("JOBS_RESULT_TTL", ""); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			c.JobsResultTTL = d
+		}
+	}
+	if v := commoncfg.GetEnv("JOBS_RESULT_MAX_BYTES", ""); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			c.JobsResultMaxBytes = n
+		}
+	}
+	if v := commoncfg.GetEnv("JOBS_SPOOL_MAX_BYTES", ""); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			c.JobsSpoolMaxBytes = n
+		}
+	}
+	if v := commoncfg.GetEnv("TRANSFER_SPOOL_DIR", ""); v != "" {
+		c.TransferSpoolDir = v
+	}
+	if v := commoncfg.GetEnv("TRANSFER_MAX_BYTES", ""); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			c.TransferMaxBytes = n
+		}
+	}
+	if v := commoncfg.GetEnv("TRANSFER_TOKEN_TTL", ""); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			c.TransferTokenTTL = d
+		}
+	}
 	if v := commoncfg.GetEnv("DRAIN_TIMEOUT", ""); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			c.DrainTimeout = d
@@ -126,6 +233,9 @@ func (c *ServerConfig) ApplyEnv() {
 	if v := commoncfg.GetEnv("ALLOWED_ORIGINS", ""); v != "" {
 		c.AllowedOrigins = splitComma(v)
 	}
+	if v := commoncfg.GetEnv("JOBS_WEBHOOK_ALLOWED_NETWORKS", ""); v != "" {
+		c.JobsWebhookAllowedNetworks = splitComma(v)
+	}
 	if v := commoncfg.GetEnv("PLUGINS", ""); v != "" {
 		c.Plugins = splitComma(v)
 	}
@@ -162,11 +272,29 @@ func (c *ServerConfig) BindFlagsFromCurrent() {
 	})
 	flag.DurationVar(&c.JobsSSECloseDelay, "jobs-sse-close-delay", c.JobsSSECloseDelay, "delay before closing job SSE after completion")
 	flag.DurationVar(&c.JobsClientTTL, "jobs-client-ttl", c.JobsClientTTL, "client inactivity TTL for jobs when no client is connected (0 to disable)")
+	flag.StringVar(&c.JobsStore, "jobs-store", c.JobsStore, "durable job store: directory path, file:// URL or redis:// URL (empty keeps jobs in memory)")
+	flag.StringVar(&c.JobsRecoveryPolicy, "jobs-recovery-policy", c.JobsRecoveryPolicy, "on restart, requeue or fail jobs that were in flight (requeue, fail)")
+	flag.DurationVar(&c.JobsPriorityAging, "jobs-priority-aging", c.JobsPriorityAging, "raise a queued job's priority by one per interval waited (0 to disable)")
+	flag.DurationVar(&c.JobsLeaseTTL, "jobs-lease-ttl", c.JobsLeaseTTL, "lease granted on claim and extended by worker updates or heartbeats; expired jobs are requeued (0 to disable)")
+	flag.IntVar(&c.JobsMaxAttempts, "jobs-max-attempts", c.JobsMaxAttempts, "claims allowed before a job whose lease expires is failed (0 for unlimited)")
+	flag.DurationVar(&c.JobsRetention, "jobs-retention", c.JobsRetention, "how long finished jobs are kept in memory and in the job store (0 keeps them forever)")
+	flag.IntVar(&c.JobsPartialHistory, "jobs-partial-history", c.JobsPartialHistory, "partial results buffered per job for SSE replay")
+	flag.StringVar(&c.JobsResultDir, "jobs-result-dir", c.JobsResultDir, "directory spooling results of jobs created with retain_results (empty disables retention)")
+	flag.DurationVar(&c.JobsResultTTL, "jobs-result-ttl", c.JobsResultTTL, "how long retained job results are kept")
+	flag.Int64Var(&c.JobsResultMaxBytes, "jobs-result-max-bytes", c.JobsResultMaxBytes, "maximum size of a single retained job result")
+	flag.Int64Var(&c.JobsSpoolMaxBytes, "jobs-spool-max-bytes", c.JobsSpoolMaxBytes, "maximum total size of retained job results")
+	flag.StringVar(&c.TransferSpoolDir, "transfer-spool-dir", c.TransferSpoolDir, "directory holding the bodies of buffered transfer channels (empty for the system temp directory)")
+	flag.Int64Var(&c.TransferMaxBytes, "transfer-max-bytes", c.TransferMaxBytes, "maximum size of a buffered transfer body")
+	flag.DurationVar(&c.TransferTokenTTL, "transfer-token-ttl", c.TransferTokenTTL, "validity of the per-channel reader and writer tokens")
 	flag.DurationVar(&c.DrainTimeout, "drain-timeout", c.DrainTimeout, "time to wait for in-flight requests on shutdown (-1 to wait indefinitely, 0 to exit immediately)")
 	flag.Func("allowed-origins", "comma separated list of allowed CORS origins", func(v string) error {
 		c.AllowedOrigins = splitComma(v)
 		return nil
 	})
+	flag.Func("jobs-webhook-allowed-networks", "comma separated IPs or CIDR ranges that job webhooks may reach even though they are loopback, private or link-local", func(v string) error {
+		c.JobsWebhookAllowedNetworks = splitComma(v)
+		return nil
+	})
 }
 
 // BindFlags populates the struct with defaults from environment variables and
@@ -210,12 +338,63 @@ func (c *ServerConfig) BindFlags() {
 	} else {
 		c.JobsClientTTL = 30 * time.Second
 	}
+	c.JobsStore = commoncfg.GetEnv("JOBS_STORE", "")
+	c.JobsRecoveryPolicy = commoncfg.GetEnv("JOBS_RECOVERY_POLICY", "requeue")
+	if d, err := time.ParseDuration(commoncfg.GetEnv("JOBS_PRIORITY_AGING", "0s")); err == nil {
+		c.JobsPriorityAging = d
+	}
+	if d, err := time.ParseDuration(commoncfg.GetEnv("JOBS_LEASE_TTL", "0s")); err == nil {
+		c.JobsLeaseTTL = d
+	}
+	if d, err := time.ParseDuration(commoncfg.GetEnv("JOBS_RETENTION", "24h")); err == nil {
+		c.JobsRetention = d
+	} else {
+		c.JobsRetention = 24 * time.Hour
+	}
+	if n, err := strconv.Atoi(commoncfg.GetEnv("JOBS_MAX_ATTEMPTS", "3")); err == nil {
+		c.JobsMaxAttempts = n
+	} else {
+		c.JobsMaxAttempts = 3
+	}
+	if n, err := strconv.Atoi(commoncfg.GetEnv("JOBS_PARTIAL_HISTORY", "256")); err == nil {
+		c.JobsPartialHistory = n
+	} else {
+		c.JobsPartialHistory = 256
+	}
+	c.JobsResultDir = commoncfg.GetEnv("JOBS_RESULT_DIR", "")
+	if d, err := time.ParseDuration(commoncfg.GetEnv("JOBS_RESULT_TTL", "1h")); err == nil {
+		c.JobsResultTTL = d
+	} else {
+		c.JobsResultTTL = time.Hour
+	}
+	if n, err := strconv.ParseInt(commoncfg.GetEnv("JOBS_RESULT_MAX_BYTES", "67108864"), 10, 64); err == nil {
+		c.JobsResultMaxBytes = n
+	} else {
+		c.JobsResultMaxBytes = 64 << 20
+	}
+	if n, err := strconv.ParseInt(commoncfg.GetEnv("JOBS_SPOOL_MAX_BYTES", "1073741824"), 10, 64); err == nil {
+		c.JobsSpoolMaxBytes = n
+	} else {
+		c.JobsSpoolMaxBytes = 1 << 30
+	}
+	c.TransferSpoolDir = commoncfg.GetEnv("TRANSFER_SPOOL_DIR", "")
+	if n, err := strconv.ParseInt(commoncfg.GetEnv("TRANSFER_MAX_BYTES", "4294967296"), 10, 64); err == nil {
+		c.TransferMaxBytes = n
+	} else {
+		c.TransferMaxBytes = 4 << 30
+	}
+	if d, err := time.ParseDuration(commoncfg.GetEnv("TRANSFER_TOKEN_TTL", "15m")); err == nil {
+		c.TransferTokenTTL = d
+	} else {
+		c.TransferTokenTTL = 15 * time.Minute
+	}
 	if d, err := time.ParseDuration(commoncfg.GetEnv("DRAIN_TIMEOUT", "5m")); err == nil {
 		c.DrainTimeout = d
 	} else {
 		c.DrainTimeout = 5 * time.Minute
 	}
 	c.AllowedOrigins = splitComma(commoncfg.GetEnv("ALLOWED_ORIGINS", strings.Join(c.AllowedOrigins, ",")))
+	c.JobsWebhookAllowedNetworks = splitComma(commoncfg.GetEnv("JOBS_WEBHOOK_ALLOWED_NETWORKS", strings.Join(c.JobsWebhookAllowedNetworks, ",")))
 	if p := commoncfg.GetEnv("PLUGINS", ""); p != "" {
 		c.Plugins = splitComma(p)
 	} else if c.Plugins == nil {
@@ -252,11 +431,29 @@ func (c *ServerConfig) BindFlags() {
 	})
 	flag.DurationVar(&c.JobsSSECloseDelay, "jobs-sse-close-delay", c.JobsSSECloseDelay, "delay before closing job SSE after completion")
 	flag.DurationVar(&c.JobsClientTTL, "jobs-client-ttl", c.JobsClientTTL, "client inactivity TTL for jobs when no client is connected (0 to disable)")
+	flag.StringVar(&c.JobsStore, "jobs-store", c.JobsStore, "durable job store: directory path, file:// URL or redis:// URL (empty keeps jobs in memory)")
+	flag.StringVar(&c.JobsRecoveryPolicy, "jobs-recovery-policy", c.JobsRecoveryPolicy, "on restart, requeue or fail jobs that were in flight (requeue, fail)")
+	flag.DurationVar(&c.JobsPriorityAging, "jobs-priority-aging", c.JobsPriorityAging, "raise a queued job's priority by one per interval waited (0 to disable)")
+	flag.DurationVar(&c.JobsLeaseTTL, "jobs-lease-ttl", c.JobsLeaseTTL, "lease granted on claim and extended by worker updates or heartbeats; expired jobs are requeued (0 to disable)")
+	flag.IntVar(&c.JobsMaxAttempts, "jobs-max-attempts", c.JobsMaxAttempts, "claims allowed before a job whose lease expires is failed (0 for unlimited)")
+	flag.DurationVar(&c.JobsRetention, "jobs-retention", c.JobsRetention, "how long finished jobs are kept in memory and in the job store (0 keeps them forever)")
+	flag.IntVar(&c.JobsPartialHistory, "jobs-partial-history", c.JobsPartialHistory, "partial results buffered per job for SSE replay")
+	flag.StringVar(&c.JobsResultDir, "jobs-result-dir", c.JobsResultDir, "directory spooling results of jobs created with retain_results (empty disables retention)")
+	flag.DurationVar(&c.JobsResultTTL, "jobs-result-ttl", c.JobsResultTTL, "how long retained job results are kept")
+	flag.Int64Var(&c.JobsResultMaxBytes, "jobs-result-max-bytes", c.JobsResultMaxBytes, "maximum size of a single retained job result")
+	flag.Int64Var(&c.JobsSpoolMaxBytes, "jobs-spool-max-bytes", c.JobsSpoolMaxBytes, "maximum total size of retained job results")
+	flag.StringVar(&c.TransferSpoolDir, "transfer-spool-dir", c.TransferSpoolDir, "directory holding the bodies of buffered transfer channels (empty for the system temp directory)")
+	flag.Int64Var(&c.TransferMaxBytes, "transfer-max-bytes", c.TransferMaxBytes, "maximum size of a buffered transfer body")
+	flag.DurationVar(&c.TransferTokenTTL, "transfer-token-ttl", c.TransferTokenTTL, "validity of the per-channel reader and writer tokens")
 	flag.DurationVar(&c.DrainTimeout, "drain-timeout", c.DrainTimeout, "time to wait for in-flight requests on shutdown (-1 to wait indefinitely, 0 to exit immediately)")
 	flag.Func("allowed-origins", "comma separated list of allowed CORS origins", func(v string) error {
 		c.AllowedOrigins = splitComma(v)
 		return nil
 	})
+	flag.Func("jobs-webhook-allowed-networks", "comma separated IPs or CIDR ranges that job webhooks may reach even though they are loopback, private or link-local", func(v string) error {
+		c.JobsWebhookAllowedNetworks = splitComma(v)
+		return nil
+	})
 }
 
 func splitComma(v string) []string {
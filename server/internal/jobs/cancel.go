@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gaspardpetit/nfrx/server/internal/transfer"
+)
+
+// Acknowledgement states of a job canceled while a worker held it. The worker
+// is told through its claim stream and acknowledges by reporting the job as
+// canceled once it has stopped.
+const (
+	CancelRequested    = "requested"
+	CancelAcknowledged = "acknowledged"
+)
+
+// workerStream is an open claim stream. Besides new jobs, it carries cancel
+// notices for jobs claimed through it or by the same worker.
+type workerStream struct {
+	workerID string
+	cancels  chan string
+}
+
+func (r *Registry) openStream(workerID string) *workerStream {
+	s := &workerStream{workerID: workerID, cancels: make(chan string, 16)}
+	r.mu.Lock()
+	r.streams[s] = struct{}{}
+	r.mu.Unlock()
+	return s
+}
+
+func (r *Registry) closeStream(s *workerStream) {
+	r.mu.Lock()
+	delete(r.streams, s)
+	r.mu.Unlock()
+}
+
+// cancelInFlightLocked stops the work on a job that is being canceled while a
+// worker holds it: its transfer channels are closed and the worker's claim
+// streams are told. A worker that misses the notice, because it polls or its
+// stream is backed up, still finds out from its next status update.
+func (r *Registry) cancelInFlightLocked(job *Job) {
+	job.CancelState = CancelRequested
+	for _, infos := range []map[string]*TransferInfo{job.Payloads, job.Results} {
+		for _, info := range infos {
+			r.transfer.Close(info.ChannelID, transfer.ErrCanceled)
+		}
+	}
+	for s := range r.streams {
+		if s != job.stream && (job.ClaimedWorkerID == "" || s.workerID != job.ClaimedWorkerID) {
+			continue
+		}
+		select {
+		case s.cancels <- job.ID:
+		default:
+		}
+	}
+}
+
+// acknowledgeCancel records that the worker of a canceled job has stopped
+// working on it.
+func (r *Registry) acknowledgeCancel(w http.ResponseWriter, jobID, claimToken, state string) {
+	r.mu.Lock()
+	job := r.jobs[jobID]
+	if job == nil || state != StatusCanceled || job.CancelState != CancelRequested {
+		r.mu.Unlock()
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "invalid_state"})
+		return
+	}
+	if !r.holdsClaimLocked(job, claimToken) {
+		r.mu.Unlock()
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "stale_claim"})
+		return
+	}
+	job.CancelState = CancelAcknowledged
+	job.CancelAckAt = time.Now()
+	r.saveLocked(job)
+	view := r.viewLocked(job)
+	r.mu.Unlock()
+	r.publish(jobID, Event{Type: "status", Data: view})
+	writeJSON(w, http.StatusOK, map[string]any{"status": view.Status})
+}
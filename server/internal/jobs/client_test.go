@@ -0,0 +1,374 @@
+package jobs_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	jobsclient "github.com/gaspardpetit/nfrx/sdk/client/jobs"
+	"github.com/gaspardpetit/nfrx/server/internal/jobs"
+	"github.com/gaspardpetit/nfrx/server/internal/transfer"
+)
+
+func newClientTestServer(t *testing.T) *jobsclient.Client {
+	t.Helper()
+	transferReg := transfer.NewRegistry(0)
+	reg := jobs.NewRegistry(transferReg, 0, 0)
+	router := chi.NewRouter()
+	router.Route("/api", func(api chi.Router) {
+		reg.RegisterRoutes(api)
+		api.Get("/transfer/{channel_id}", func(w http.ResponseWriter, r *http.Request) {
+			transferReg.HandleReader(w, r, chi.URLParam(r, "channel_id"))
+		})
+		api.Post("/transfer/{channel_id}", func(w http.ResponseWriter, r *http.Request) {
+			transferReg.HandleWriter(w, r, chi.URLParam(r, "channel_id"))
+		})
+	})
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return jobsclient.New(srv.URL, "")
+}
+
+func TestClientAndWorkerRunJobEndToEnd(t *testing.T) {
+	c := newClientTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	created, err := c.Create(ctx, jobsclient.CreateRequest{Type: "upper", Metadata: map[string]any{"lang": "en"}})
+	if err != nil || created.Status != jobsclient.StatusQueued {
+		t.Fatalf("create: %+v %v", created, err)
+	}
+
+	ready := make(chan struct{})
+	done := make(chan error, 1)
+	var result, final string
+	go func() {
+		done <- c.Events(ctx, created.JobID, func(ev jobsclient.Event) error {
+			switch ev.Type {
+			case "status":
+				var job jobsclient.Job
+				if err := ev.Decode(&job); err != nil {
+					return err
+				}
+				if job.Status == jobsclient.StatusQueued {
+					close(ready)
+				}
+				final = job.Status
+			case "payload":
+				var info jobsclient.TransferInfo
+				if err := ev.Decode(&info); err != nil {
+					return err
+				}
+				return c.Upload(ctx, info.URL, strings.NewReader("hello"))
+			case "result":
+				var info jobsclient.TransferInfo
+				if err := ev.Decode(&info); err != nil {
+					return err
+				}
+				body, err := c.Download(ctx, info.URL)
+				if err != nil {
+					return err
+				}
+				defer body.Close()
+				b, err := io.ReadAll(body)
+				result = string(b)
+				return err
+			}
+			return nil
+		})
+	}()
+	<-ready
+
+	w := &jobsclient.Worker{
+		Client:  c,
+		Types:   []string{"upper"},
+		MaxWait: time.Second,
+		Handler: func(_ context.Context, job *jobsclient.Claim, payload io.Reader, out io.Writer) error {
+			if job.Metadata["lang"] != "en" {
+				t.Errorf("unexpected metadata %v", job.Metadata)
+			}
+			b, err := io.ReadAll(payload)
+			if err != nil {
+				return err
+			}
+			_, err = out.Write([]byte(strings.ToUpper(string(b))))
+			return err
+		},
+	}
+	claimed, err := w.RunOnce(ctx)
+	if err != nil || !claimed {
+		t.Fatalf("run once: claimed=%v err=%v", claimed, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	if result != "HELLO" || final != jobsclient.StatusCompleted {
+		t.Fatalf("expected HELLO and completed, got %q and %q", result, final)
+	}
+
+	// Nothing left to claim.
+	if claim, err := c.Claim(ctx, jobsclient.ClaimRequest{Types: []string{"upper"}}); claim != nil || err != nil {
+		t.Fatalf("expected no job, got %+v %v", claim, err)
+	}
+}
+
+func TestWorkerReportsHandlerErrors(t *testing.T) {
+	c := newClientTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	created, err := c.Create(ctx, jobsclient.CreateRequest{Type: "check"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	w := &jobsclient.Worker{
+		Client:    c,
+		Types:     []string{"check"},
+		NoPayload: true,
+		Handler: func(context.Context, *jobsclient.Claim, io.Reader, io.Writer) error {
+			return &jobsclient.JobError{Code: "bad_input", Message: "nope"}
+		},
+	}
+	if claimed, err := w.RunOnce(ctx); err != nil || !claimed {
+		t.Fatalf("run once: claimed=%v err=%v", claimed, err)
+	}
+	job, err := c.Get(ctx, created.JobID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if job.Status != jobsclient.StatusFailed || job.Error == nil || job.Error.Code != "bad_input" {
+		t.Fatalf("expected failed job with bad_input, got %+v", job)
+	}
+
+	var apiErr *jobsclient.Error
+	if _, err := c.Get(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Code != "not_found" {
+		t.Fatalf("expected not_found error, got %v", err)
+	}
+}
+
+func TestWorkerStreamsResultWhileHandlerRuns(t *testing.T) {
+	c := newClientTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	created, err := c.Create(ctx, jobsclient.CreateRequest{Type: "stream"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	firstRead := make(chan struct{})
+	done := make(chan error, 1)
+	var result string
+	go func() {
+		done <- c.Events(ctx, created.JobID, func(ev jobsclient.Event) error {
+			if ev.Type != "result" {
+				return nil
+			}
+			var info jobsclient.TransferInfo
+			if err := ev.Decode(&info); err != nil {
+				return err
+			}
+			body, err := c.Download(ctx, info.URL)
+			if err != nil {
+				return err
+			}
+			defer body.Close()
+			first := make([]byte, len("first "))
+			if _, err := io.ReadFull(body, first); err != nil {
+				return err
+			}
+			close(firstRead)
+			rest, err := io.ReadAll(body)
+			result = string(first) + string(rest)
+			return err
+		})
+	}()
+
+	w := &jobsclient.Worker{
+		Client:    c,
+		Types:     []string{"stream"},
+		NoPayload: true,
+		Handler: func(ctx context.Context, _ *jobsclient.Claim, _ io.Reader, out io.Writer) error {
+			if _, err := io.WriteString(out, "first "); err != nil {
+				return err
+			}
+			// The client only sees the first part if it was sent before the
+			// handler returned.
+			select {
+			case <-firstRead:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			_, err := io.WriteString(out, "second")
+			return err
+		},
+	}
+	if claimed, err := w.RunOnce(ctx); err != nil || !claimed {
+		t.Fatalf("run once: claimed=%v err=%v", claimed, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	if result != "first second" {
+		t.Fatalf("result = %q", result)
+	}
+}
+
+func TestClientStreamDeliversClaims(t *testing.T) {
+	c := newClientTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	created, err := c.Create(ctx, jobsclient.CreateRequest{Type: "stream"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	streamCtx, stop := context.WithCancel(ctx)
+	var got *jobsclient.Claim
+	err = c.Stream(streamCtx, jobsclient.ClaimRequest{Types: []string{"stream"}, WorkerID: "w1"}, func(claim *jobsclient.Claim) error {
+		got = claim
+		stop()
+		return nil
+	}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected stream to end with cancellation, got %v", err)
+	}
+	if got == nil || got.JobID != created.JobID || got.ClaimedWorkerID != "w1" {
+		t.Fatalf("unexpected claim %+v", got)
+	}
+}
+
+func TestCancelReachesStreamingWorker(t *testing.T) {
+	c := newClientTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, tc := range []struct {
+		name      string
+		noPayload bool
+		status    string
+	}{
+		// The worker waits for a payload that never comes; canceling closes
+		// the transfer channel.
+		{name: "payload", status: jobsclient.StatusAwaitingPayload},
+		// The handler runs until the cancel event from the stream stops it.
+		{name: "handler", noPayload: true, status: jobsclient.StatusRunning},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			created, err := c.Create(ctx, jobsclient.CreateRequest{Type: tc.name})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			w := &jobsclient.Worker{
+				Client:    c,
+				NoPayload: tc.noPayload,
+				Handler: func(ctx context.Context, _ *jobsclient.Claim, _ io.Reader, _ io.Writer) error {
+					<-ctx.Done()
+					if !errors.Is(context.Cause(ctx), jobsclient.ErrJobCanceled) {
+						t.Errorf("unexpected cause %v", context.Cause(ctx))
+					}
+					return ctx.Err()
+				},
+			}
+			streamCtx, stop := context.WithCancel(ctx)
+			defer stop()
+			processed := make(chan error, 1)
+			notified := make(chan string, 1)
+			go func() {
+				_ = c.Stream(streamCtx, jobsclient.ClaimRequest{Types: []string{tc.name}, WorkerID: "w-" + tc.name}, func(claim *jobsclient.Claim) error {
+					go func() { processed <- w.Process(ctx, claim) }()
+					return nil
+				}, func(id string) {
+					notified <- id
+					w.Cancel(id)
+				})
+			}()
+
+			waitForJob(t, c, created.JobID, func(job *jobsclient.Job) bool { return job.Status == tc.status })
+			if status, err := c.Cancel(ctx, created.JobID); err != nil || status != jobsclient.StatusCanceled {
+				t.Fatalf("cancel: %q %v", status, err)
+			}
+			if id := <-notified; id != created.JobID {
+				t.Fatalf("cancel event for %q, want %q", id, created.JobID)
+			}
+			if err := <-processed; err != nil {
+				t.Fatalf("process: %v", err)
+			}
+			job := waitForJob(t, c, created.JobID, func(job *jobsclient.Job) bool {
+				return job.CancelState == jobsclient.CancelAcknowledged
+			})
+			if job.Status != jobsclient.StatusCanceled || job.CancelAckAt == "" {
+				t.Fatalf("unexpected job after acknowledgement: %+v", job)
+			}
+		})
+	}
+}
+
+func TestClientPassesContentEncodingThrough(t *testing.T) {
+	c := newClientTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	created, err := c.Create(ctx, jobsclient.CreateRequest{Type: "embed"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := c.Claim(ctx, jobsclient.ClaimRequest{Types: []string{"embed"}}); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	ch, err := c.RequestResult(ctx, created.JobID, jobsclient.TransferRequest{ContentEncoding: "gzip"})
+	if err != nil {
+		t.Fatalf("request result: %v", err)
+	}
+	job := waitForJob(t, c, created.JobID, func(j *jobsclient.Job) bool { return j.Results["result"] != nil })
+	info := job.Results["result"]
+	if info.ContentEncoding != "gzip" {
+		t.Fatalf("result info encoding = %q", info.ContentEncoding)
+	}
+
+	plain := strings.Repeat(`[0.5,0.25],`, 100)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(plain))
+	_ = zw.Close()
+	sent := make(chan error, 1)
+	go func() { sent <- c.UploadEncoded(ctx, ch.WriterURL, bytes.NewReader(gz.Bytes()), "gzip") }()
+	body, err := c.Download(ctx, info.URL)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	defer body.Close()
+	got, err := io.ReadAll(body)
+	if err != nil || string(got) != plain {
+		t.Fatalf("download of %d bytes, err %v", len(got), err)
+	}
+	if err := <-sent; err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+}
+
+func waitForJob(t *testing.T, c *jobsclient.Client, id string, cond func(*jobsclient.Job) bool) *jobsclient.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := c.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if cond(job) {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for job, last state %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileStore implements Store as one JSON file per job under a local directory.
+// Files are replaced atomically so a crash never leaves a partial record.
+type fileStore struct {
+	dir string
+}
+
+// NewFileStore returns a Store rooted at dir, creating it if needed.
+func NewFileStore(dir string) (*fileStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("jobs: empty store directory")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "jobs"), 0o700); err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+func (f *fileStore) SaveJob(rec Record) error {
+	path, err := f.jobPath(rec.ID)
+	if err != nil {
+		return err
+	}
+	return writeJSONFile(path, rec)
+}
+
+func (f *fileStore) DeleteJob(id string) error {
+	path, err := f.jobPath(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (f *fileStore) LoadJobs() ([]Record, error) {
+	entries, err := os.ReadDir(filepath.Join(f.dir, "jobs"))
+	if err != nil {
+		return nil, err
+	}
+	recs := make([]Record, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(f.dir, "jobs", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var rec Record
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return nil, fmt.Errorf("jobs: decode %s: %w", e.Name(), err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+func (f *fileStore) SaveStats(stats Stats) error {
+	return writeJSONFile(filepath.Join(f.dir, "stats.json"), stats)
+}
+
+func (f *fileStore) LoadStats() (Stats, error) {
+	var stats Stats
+	b, err := os.ReadFile(filepath.Join(f.dir, "stats.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+	err = json.Unmarshal(b, &stats)
+	return stats, err
+}
+
+func (f *fileStore) jobPath(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("jobs: invalid job id %q", id)
+	}
+	return filepath.Join(f.dir, "jobs", id+".json"), nil
+}
+
+func writeJSONFile(path string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
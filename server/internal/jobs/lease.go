@@ -0,0 +1,153 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/gaspardpetit/nfrx/core/logx"
+	"github.com/gaspardpetit/nfrx/server/internal/transfer"
+)
+
+// SetLease makes every claim grant a lease of ttl that the worker extends via
+// status updates, transfer requests or heartbeats. When a lease expires the
+// job is re-queued, or failed with lease_expired once it has been claimed
+// maxAttempts times. Jobs with a retry policy follow that policy instead. A
+// zero ttl disables leases; maxAttempts <= 0 means unlimited attempts.
+func (r *Registry) SetLease(ttl time.Duration, maxAttempts int) {
+	r.mu.Lock()
+	r.leaseTTL = ttl
+	r.maxAttempts = maxAttempts
+	r.mu.Unlock()
+}
+
+// HeartbeatRequest names the claim whose lease a heartbeat extends.
+type HeartbeatRequest struct {
+	ClaimToken string `json:"claim_token"`
+}
+
+// HandleHeartbeat extends the lease of an in-flight job.
+func (r *Registry) HandleHeartbeat(w http.ResponseWriter, req *http.Request) {
+	jobID := chi.URLParam(req, "job_id")
+	var body HeartbeatRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_request"})
+		return
+	}
+	r.mu.Lock()
+	job := r.jobs[jobID]
+	if job == nil {
+		r.mu.Unlock()
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not_found"})
+		return
+	}
+	if !canRequestTransfer(job.Status) {
+		r.mu.Unlock()
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "invalid_state"})
+		return
+	}
+	if !r.holdsClaimLocked(job, body.ClaimToken) {
+		r.mu.Unlock()
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "stale_claim"})
+		return
+	}
+	r.extendLeaseLocked(job, time.Now())
+	resp := map[string]any{"status": job.Status}
+	if !job.LeaseExpiresAt.IsZero() {
+		resp["lease_expires_at"] = job.LeaseExpiresAt.UTC().Format(time.RFC3339)
+	}
+	r.mu.Unlock()
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// extendLeaseLocked pushes the lease deadline of an in-flight job out by the
+// lease TTL, arming the expiry timer if needed.
+func (r *Registry) extendLeaseLocked(job *Job, now time.Time) {
+	if r.leaseTTL <= 0 || !canRequestTransfer(job.Status) {
+		return
+	}
+	job.LeaseExpiresAt = now.Add(r.leaseTTL)
+	if r.leaseTimers[job.ID] == nil {
+		id := job.ID
+		r.leaseTimers[id] = time.AfterFunc(r.leaseTTL, func() { r.expireLease(id) })
+	}
+}
+
+func (r *Registry) clearLeaseLocked(job *Job) {
+	job.LeaseExpiresAt = time.Time{}
+	if timer := r.leaseTimers[job.ID]; timer != nil {
+		timer.Stop()
+		delete(r.leaseTimers, job.ID)
+	}
+}
+
+// expireLease runs when a lease timer fires. A lease extended since the timer
+// was armed re-arms it for the remaining time instead.
+func (r *Registry) expireLease(jobID string) {
+	r.mu.Lock()
+	job := r.jobs[jobID]
+	if job == nil || !canRequestTransfer(job.Status) || job.LeaseExpiresAt.IsZero() {
+		delete(r.leaseTimers, jobID)
+		r.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	if remaining := job.LeaseExpiresAt.Sub(now); remaining > 0 {
+		if timer := r.leaseTimers[jobID]; timer != nil {
+			timer.Reset(remaining)
+		}
+		r.mu.Unlock()
+		return
+	}
+	delete(r.leaseTimers, jobID)
+	jobErr := &JobError{Code: "lease_expired", Message: fmt.Sprintf("worker lease expired after %d attempts", job.Attempts)}
+	var requeue bool
+	if job.Retry != nil {
+		// The job's own policy decides, exactly as for a failure reported
+		// by the worker.
+		requeue = r.retryLocked(job, jobErr, now)
+	} else if requeue = r.maxAttempts <= 0 || job.Attempts < r.maxAttempts; requeue {
+		r.requeueLocked(job, now)
+	} else {
+		job.DeadLetteredAt = now
+	}
+	if !requeue {
+		r.setJobStatusLocked(job, StatusFailed, now)
+		job.Error = jobErr
+	}
+	r.saveLocked(job)
+	view := r.viewLocked(job)
+	r.mu.Unlock()
+
+	logx.Log.Warn().Str("job_id", jobID).Int("attempts", job.Attempts).Bool("requeued", requeue).Msg("job lease expired")
+	if requeue {
+		r.signal()
+	} else {
+		r.clearClientTimer(jobID)
+	}
+	r.publish(jobID, Event{Type: "status", Data: view})
+}
+
+// requeueLocked returns an in-flight job to the back of the queue, dropping
+// the claim and closing any transfer channels of the previous attempt.
+func (r *Registry) requeueLocked(job *Job, now time.Time) {
+	r.setJobStatusLocked(job, StatusQueued, now)
+	job.ClaimedWorkerID = ""
+	job.ClaimedWorkerGroup = ""
+	job.ClaimToken = ""
+	job.ClaimedAt = time.Time{}
+	job.NotBefore = time.Time{}
+	for _, infos := range []map[string]*TransferInfo{job.Payloads, job.Results} {
+		for _, info := range infos {
+			r.transfer.Close(info.ChannelID, transfer.ErrClosed)
+		}
+	}
+	job.Payloads = nil
+	job.Results = nil
+	r.queue = append(r.queue, job.ID)
+}
@@ -0,0 +1,228 @@
+package jobs
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// listFilter holds the parsed query of GET /jobs.
+type listFilter struct {
+	types         []string
+	statuses      []string
+	workerID      string
+	workerGroup   string
+	metadata      map[string]string
+	createdAfter  time.Time
+	createdBefore time.Time
+	owner         string
+}
+
+func (f *listFilter) matches(job *Job) bool {
+	if len(f.types) > 0 && !contains(f.types, job.Type) {
+		return false
+	}
+	if len(f.statuses) > 0 && !contains(f.statuses, job.Status) {
+		return false
+	}
+	if f.workerID != "" && job.WorkerID != f.workerID && job.ClaimedWorkerID != f.workerID {
+		return false
+	}
+	if f.workerGroup != "" && job.WorkerGroup != f.workerGroup && job.ClaimedWorkerGroup != f.workerGroup {
+		return false
+	}
+	for k, v := range f.metadata {
+		got, ok := job.Metadata[k]
+		if !ok || fmt.Sprint(got) != v {
+			return false
+		}
+	}
+	if !f.createdAfter.IsZero() && !job.CreatedAt.After(f.createdAfter) {
+		return false
+	}
+	if !f.createdBefore.IsZero() && !job.CreatedAt.Before(f.createdBefore) {
+		return false
+	}
+	if f.owner != "" && job.Owner != f.owner {
+		return false
+	}
+	return true
+}
+
+// listSort orders jobs by one key, breaking ties by ID so cursors are stable.
+type listSort struct {
+	field string
+	desc  bool
+}
+
+func parseListSort(v string) (listSort, bool) {
+	s := listSort{field: "created_at", desc: true}
+	if v == "" {
+		return s, true
+	}
+	s.desc = strings.HasPrefix(v, "-")
+	s.field = strings.TrimPrefix(v, "-")
+	switch s.field {
+	case "created_at", "updated_at", "priority":
+		return s, true
+	}
+	return s, false
+}
+
+func (s listSort) key(job *Job) int64 {
+	switch s.field {
+	case "updated_at":
+		return job.UpdatedAt.UnixNano()
+	case "priority":
+		return int64(job.Priority)
+	}
+	return job.CreatedAt.UnixNano()
+}
+
+// before reports whether a job with sort key ka and ID ida sorts ahead of one
+// with kb and idb.
+func (s listSort) before(ka int64, ida string, kb int64, idb string) bool {
+	if ka != kb {
+		return (ka < kb) != s.desc
+	}
+	return ida < idb
+}
+
+// listCursor marks the last job of a page; the next page starts after it.
+type listCursor struct {
+	key int64
+	id  string
+}
+
+func (c listCursor) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(c.key, 10) + ":" + c.id))
+}
+
+func parseListCursor(v string) (listCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return listCursor{}, err
+	}
+	k, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return listCursor{}, fmt.Errorf("malformed cursor")
+	}
+	key, err := strconv.ParseInt(k, 10, 64)
+	if err != nil {
+		return listCursor{}, err
+	}
+	return listCursor{key: key, id: id}, nil
+}
+
+// SetIdentity installs a function that returns the identity of the client
+// making a request, such as the name of its API key. When it returns a
+// non-empty identity, new jobs record it as their owner and listings only
+// include the caller's own jobs.
+func (r *Registry) SetIdentity(identify func(*http.Request) string) {
+	r.mu.Lock()
+	r.identify = identify
+	r.mu.Unlock()
+}
+
+func (r *Registry) ownerLocked(req *http.Request) string {
+	if r.identify == nil {
+		return ""
+	}
+	return r.identify(req)
+}
+
+// HandleListJobs lists jobs matching the query filters, one page at a time.
+func (r *Registry) HandleListJobs(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	filter := listFilter{
+		types:       parseListQuery(q["type"]),
+		statuses:    parseListQuery(q["status"]),
+		workerID:    strings.TrimSpace(q.Get("worker_id")),
+		workerGroup: strings.TrimSpace(q.Get("worker_group")),
+	}
+	for _, kv := range q["metadata"] {
+		k, v, ok := strings.Cut(kv, ":")
+		if !ok || k == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_metadata_filter"})
+			return
+		}
+		if filter.metadata == nil {
+			filter.metadata = make(map[string]string)
+		}
+		filter.metadata[k] = v
+	}
+	for name, dst := range map[string]*time.Time{"created_after": &filter.createdAfter, "created_before": &filter.createdBefore} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_" + name})
+				return
+			}
+			*dst = t
+		}
+	}
+	order, ok := parseListSort(q.Get("sort"))
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_sort"})
+		return
+	}
+	limit := defaultListLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_limit"})
+			return
+		}
+		limit = minInt(n, maxListLimit)
+	}
+	var after *listCursor
+	if v := q.Get("cursor"); v != "" {
+		c, err := parseListCursor(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_cursor"})
+			return
+		}
+		after = &c
+	}
+
+	r.mu.Lock()
+	filter.owner = r.ownerLocked(req)
+	var matched []*Job
+	for _, job := range r.jobs {
+		if !filter.matches(job) {
+			continue
+		}
+		if after != nil && !order.before(after.key, after.id, order.key(job), job.ID) {
+			continue
+		}
+		matched = append(matched, job)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return order.before(order.key(matched[i]), matched[i].ID, order.key(matched[j]), matched[j].ID)
+	})
+	resp := map[string]any{}
+	if len(matched) > limit {
+		matched = matched[:limit]
+		last := matched[limit-1]
+		resp["next_cursor"] = listCursor{key: order.key(last), id: last.ID}.encode()
+	}
+	now := time.Now()
+	views := make([]JobView, 0, len(matched))
+	for _, job := range matched {
+		view := r.viewLocked(job)
+		view.QueuePosition = r.queuePositionLocked(job.ID, now)
+		views = append(views, view)
+	}
+	r.mu.Unlock()
+	resp["jobs"] = views
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,141 @@
+package jobs
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// DefaultPartialHistory is the number of partial results kept per job for
+// replay to subscribers that connect late or reconnect.
+const DefaultPartialHistory = 256
+
+// Partial is an incremental result, such as a transcript segment or a log
+// line, appended by a worker while a job runs. Sequence numbers start at 1
+// and increase by one per job.
+type Partial struct {
+	Seq       int64     `json:"seq"`
+	Data      any       `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SetPartialHistory sets how many partial results are buffered per job; the
+// oldest are dropped first. Non-positive values restore the default.
+func (r *Registry) SetPartialHistory(n int) {
+	if n <= 0 {
+		n = DefaultPartialHistory
+	}
+	r.mu.Lock()
+	r.partialMax = n
+	r.mu.Unlock()
+}
+
+func partialEvent(p Partial) Event {
+	return Event{Type: "partial", ID: strconv.FormatInt(p.Seq, 10), Data: p}
+}
+
+// HandleAppendPartial appends a partial result to an in-flight job and fans
+// it out to the job's event subscribers. Like status updates, it is refused
+// to workers that no longer hold the job's claim.
+func (r *Registry) HandleAppendPartial(w http.ResponseWriter, req *http.Request) {
+	jobID := chi.URLParam(req, "job_id")
+	var body struct {
+		ClaimToken string          `json:"claim_token"`
+		Data       json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_request"})
+		return
+	}
+	if len(body.Data) == 0 || string(body.Data) == "null" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "missing_data"})
+		return
+	}
+	r.mu.Lock()
+	job := r.jobs[jobID]
+	if job == nil {
+		r.mu.Unlock()
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not_found"})
+		return
+	}
+	if !canRequestTransfer(job.Status) {
+		r.mu.Unlock()
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "invalid_state"})
+		return
+	}
+	if !r.holdsClaimLocked(job, body.ClaimToken) {
+		r.mu.Unlock()
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "stale_claim"})
+		return
+	}
+	now := time.Now()
+	job.partialSeq++
+	p := Partial{Seq: job.partialSeq, Data: body.Data, CreatedAt: now}
+	job.partials = append(job.partials, p)
+	if n := len(job.partials); n > r.partialMax {
+		job.partials = append([]Partial(nil), job.partials[n-r.partialMax:]...)
+	}
+	r.extendLeaseLocked(job, now)
+	r.saveLocked(job)
+	r.publishLocked(jobID, partialEvent(p))
+	r.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{"seq": p.Seq})
+}
+
+// partialsAfter returns the buffered partial results of a job with a sequence
+// above after, up to and including until when it is positive. gapTo is the
+// last sequence that was requested but is no longer buffered, because it was
+// dropped or lost in a restart, or zero.
+func (r *Registry) partialsAfter(jobID string, after, until int64) (out []Partial, gapTo int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := r.jobs[jobID]
+	if job == nil {
+		return nil, 0
+	}
+	first := job.partialSeq + 1
+	if len(job.partials) > 0 {
+		first = job.partials[0].Seq
+	}
+	if first > after+1 {
+		gapTo = first - 1
+	}
+	for _, p := range job.partials {
+		if p.Seq > after && (until <= 0 || p.Seq <= until) {
+			out = append(out, p)
+		}
+	}
+	return out, gapTo
+}
+
+// lastEventID returns the sequence a reconnecting client has seen, from the
+// Last-Event-ID header or, for clients that cannot set headers, the
+// last_event_id query parameter.
+func lastEventID(req *http.Request) int64 {
+	v := req.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = req.URL.Query().Get("last_event_id")
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// writePartials sends the partial results after *last, preceded by a
+// partial_gap event when some were already dropped, and advances *last.
+func (r *Registry) writePartials(w http.ResponseWriter, jobID string, last *int64, until int64) {
+	partials, gapTo := r.partialsAfter(jobID, *last, until)
+	if gapTo > 0 {
+		r.writeEvent(w, Event{Type: "partial_gap", Data: map[string]any{"from": *last + 1, "to": gapTo}})
+	}
+	for _, p := range partials {
+		r.writeEvent(w, partialEvent(p))
+		*last = p.Seq
+	}
+}
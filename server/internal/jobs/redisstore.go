@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/gaspardpetit/nfrx/server/internal/serverstate"
+)
+
+const (
+	redisJobsKey  = "nfrx:jobs:records"
+	redisStatsKey = "nfrx:jobs:stats"
+)
+
+// redisStore implements Store with a Redis hash of job records keyed by ID.
+type redisStore struct {
+	client redis.UniversalClient
+	ctx    context.Context
+}
+
+// NewRedisStore connects to the given Redis URL and returns a Store.
+func NewRedisStore(addr string) (*redisStore, error) {
+	c, err := serverstate.NewRedisClient(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &redisStore{client: c, ctx: context.Background()}, nil
+}
+
+func (r *redisStore) SaveJob(rec Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return r.client.HSet(r.ctx, redisJobsKey, rec.ID, b).Err()
+}
+
+func (r *redisStore) DeleteJob(id string) error {
+	return r.client.HDel(r.ctx, redisJobsKey, id).Err()
+}
+
+func (r *redisStore) LoadJobs() ([]Record, error) {
+	all, err := r.client.HGetAll(r.ctx, redisJobsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	recs := make([]Record, 0, len(all))
+	for id, raw := range all {
+		var rec Record
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			return nil, fmt.Errorf("jobs: decode %s: %w", id, err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+func (r *redisStore) SaveStats(stats Stats) error {
+	b, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(r.ctx, redisStatsKey, b, 0).Err()
+}
+
+func (r *redisStore) LoadStats() (Stats, error) {
+	var stats Stats
+	b, err := r.client.Get(r.ctx, redisStatsKey).Bytes()
+	if err == redis.Nil {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+	err = json.Unmarshal(b, &stats)
+	return stats, err
+}
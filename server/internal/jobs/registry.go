@@ -14,10 +14,13 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/gaspardpetit/nfrx/core/logx"
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
 	"github.com/gaspardpetit/nfrx/server/internal/transfer"
 )
 
 const (
+	StatusScheduled       = "scheduled"
+	StatusBlocked         = "blocked"
 	StatusQueued          = "queued"
 	StatusClaimed         = "claimed"
 	StatusAwaitingPayload = "awaiting_payload"
@@ -43,25 +46,73 @@ type Registry struct {
 	clientTTL     time.Duration
 	clientTimers  map[string]*time.Timer
 	workers       map[string]*WorkerActivity
-	stats         jobStats
+	stats         Stats
+	store         Store
+	priorityAging time.Duration
+	jobRetention  time.Duration
+	pruneTimer    *time.Timer
+	leaseTTL      time.Duration
+	maxAttempts   int
+	leaseTimers   map[string]*time.Timer
+	schedTimers   map[string]*time.Timer
+	children      map[string][]string
+	identify      func(*http.Request) string
+	results       *ResultSpool
+	webhooks      map[string]*webhookQueue
+	hookBackoff   time.Duration
+	hookClient    *http.Client
+	streams       map[*workerStream]struct{}
+	partialMax    int
+	relays        []spi.TransferRelay
 }
 
 type Job struct {
 	ID                 string
 	Type               string
 	Status             string
+	Priority           int
 	Metadata           map[string]any
 	WorkerID           string
 	WorkerGroup        string
 	ClaimedWorkerID    string
 	ClaimedWorkerGroup string
+	LastWorkerID       string
+	LastWorkerGroup    string
+	Attempts           int
+	ClaimToken         string
+	LeaseExpiresAt     time.Time
+	Retry              *RetryPolicy
+	NotBefore          time.Time
+	DeadLetteredAt     time.Time
+	Schedule           string
+	ScheduleID         string
+	LastJobID          string
+	DependsOn          []string
+	WorkflowID         string
+	Owner              string
+	RetainResults      bool
+	Webhook            *Webhook
+	WebhookDeliveries  []WebhookDelivery
+	CancelState        string
+	CancelAckAt        time.Time
+	Tracked            bool
 	Progress           map[string]any
 	Error              *JobError
 	Payloads           map[string]*TransferInfo
 	Results            map[string]*TransferInfo
+	History            []Transition
 	CreatedAt          time.Time
 	ClaimedAt          time.Time
 	UpdatedAt          time.Time
+
+	// stream is the claim stream the job was handed out on, if any.
+	stream *workerStream
+	// onCancel stops the work behind a tracked job.
+	onCancel func()
+	// partials buffers the latest partial results for replay. Only the
+	// sequence is persisted, so partials lost in a restart replay as a gap.
+	partials   []Partial
+	partialSeq int64
 }
 
 type JobError struct {
@@ -81,20 +132,21 @@ type WorkerActivity struct {
 	StreamCount   int
 }
 
-type jobStats struct {
-	CompletedJobs uint64
-	FailedJobs    uint64
-	CanceledJobs  uint64
+// Stats holds lifetime job outcome counters and duration totals.
+type Stats struct {
+	CompletedJobs uint64 `json:"completed_jobs"`
+	FailedJobs    uint64 `json:"failed_jobs"`
+	CanceledJobs  uint64 `json:"canceled_jobs"`
 
-	QueueWaitTotal   time.Duration
-	QueueWaitSamples uint64
-	ServiceTotal     time.Duration
-	ServiceSamples   uint64
-	EndToEndTotal    time.Duration
-	EndToEndSamples  uint64
-	LastCompletedAt  time.Time
-	LastFailedAt     time.Time
-	LastCanceledAt   time.Time
+	QueueWaitTotal   time.Duration `json:"queue_wait_total"`
+	QueueWaitSamples uint64        `json:"queue_wait_samples"`
+	ServiceTotal     time.Duration `json:"service_total"`
+	ServiceSamples   uint64        `json:"service_samples"`
+	EndToEndTotal    time.Duration `json:"end_to_end_total"`
+	EndToEndSamples  uint64        `json:"end_to_end_samples"`
+	LastCompletedAt  time.Time     `json:"last_completed_at"`
+	LastFailedAt     time.Time     `json:"last_failed_at"`
+	LastCanceledAt   time.Time     `json:"last_canceled_at"`
 }
 
 type TransferInfo struct {
@@ -103,11 +155,25 @@ type TransferInfo struct {
 	URL        string         `json:"url"`
 	ExpiresAt  string         `json:"expires_at"`
 	Key        string         `json:"key,omitempty"`
+	Mode       string         `json:"mode,omitempty"`
 	Properties map[string]any `json:"properties,omitempty"`
+	// Size, ContentType and SHA256 are what the channel's creator declared,
+	// or what the server measured for retained results.
+	Size            int64  `json:"size,omitempty"`
+	ContentType     string `json:"content_type,omitempty"`
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	SHA256          string `json:"sha256,omitempty"`
+	MaxBytes        int64  `json:"max_bytes,omitempty"`
+	// Token lets the client use the channel without the API keys, for
+	// instance from a third party it hands the URL to.
+	Token          string `json:"token,omitempty"`
+	TokenExpiresAt string `json:"token_expires_at,omitempty"`
 }
 
 type Event struct {
 	Type string
+	// ID is sent as the SSE event id; partial results carry their sequence.
+	ID   string
 	Data any
 }
 
@@ -117,6 +183,16 @@ type CreateJobRequest struct {
 	Metadata    map[string]any `json:"metadata,omitempty"`
 	WorkerID    string         `json:"worker_id,omitempty"`
 	WorkerGroup string         `json:"worker_group,omitempty"`
+	Retry       *RetryPolicy   `json:"retry,omitempty"`
+	NotBefore   *time.Time     `json:"not_before,omitempty"`
+	Schedule    string         `json:"schedule,omitempty"`
+	DependsOn   []string       `json:"depends_on,omitempty"`
+	WorkflowID  string         `json:"workflow_id,omitempty"`
+	// RetainResults stores results in the server's result spool so they can
+	// be downloaded after the worker has finished.
+	RetainResults bool `json:"retain_results,omitempty"`
+	// Webhook receives a signed notification for each status change.
+	Webhook *Webhook `json:"webhook,omitempty"`
 }
 
 type ClaimRequest struct {
@@ -127,25 +203,57 @@ type ClaimRequest struct {
 }
 
 type StatusUpdateRequest struct {
-	State    string         `json:"state"`
-	Progress map[string]any `json:"progress,omitempty"`
-	Error    *JobError      `json:"error,omitempty"`
+	ClaimToken string         `json:"claim_token"`
+	State      string         `json:"state"`
+	Progress   map[string]any `json:"progress,omitempty"`
+	Error      *JobError      `json:"error,omitempty"`
 }
 
 type TransferRequest struct {
 	Key        *string        `json:"key,omitempty"`
+	Mode       string         `json:"mode,omitempty"`
+	Readers    int            `json:"readers,omitempty"`
 	Properties map[string]any `json:"properties,omitempty"`
+	// Size, ContentType, SHA256 (hex) and MaxBytes are checked by the
+	// transfer channel against what the writer sends.
+	Size        int64  `json:"size,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	SHA256      string `json:"sha256,omitempty"`
+	MaxBytes    int64  `json:"max_bytes,omitempty"`
+	// ContentEncoding declares the encoding of the body, passed on to
+	// readers as Content-Encoding.
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	// Relay has the server move the body to or from an agent's backend
+	// instead of the worker.
+	Relay *RelayTarget `json:"relay,omitempty"`
 }
 
 type JobView struct {
 	ID                 string                   `json:"id"`
 	Type               string                   `json:"type"`
 	Status             string                   `json:"status"`
+	Priority           int                      `json:"priority"`
 	Metadata           map[string]any           `json:"metadata,omitempty"`
 	WorkerID           string                   `json:"worker_id,omitempty"`
 	WorkerGroup        string                   `json:"worker_group,omitempty"`
 	ClaimedWorkerID    string                   `json:"claimed_worker_id,omitempty"`
 	ClaimedWorkerGroup string                   `json:"claimed_worker_group,omitempty"`
+	LastWorkerID       string                   `json:"last_worker_id,omitempty"`
+	LastWorkerGroup    string                   `json:"last_worker_group,omitempty"`
+	Attempts           int                      `json:"attempts,omitempty"`
+	LeaseExpiresAt     string                   `json:"lease_expires_at,omitempty"`
+	Retry              *RetryPolicy             `json:"retry,omitempty"`
+	NotBefore          string                   `json:"not_before,omitempty"`
+	DeadLetteredAt     string                   `json:"dead_lettered_at,omitempty"`
+	Schedule           string                   `json:"schedule,omitempty"`
+	ScheduleID         string                   `json:"schedule_id,omitempty"`
+	LastJobID          string                   `json:"last_job_id,omitempty"`
+	DependsOn          []string                 `json:"depends_on,omitempty"`
+	WorkflowID         string                   `json:"workflow_id,omitempty"`
+	RetainResults      bool                     `json:"retain_results,omitempty"`
+	Webhook            *WebhookView             `json:"webhook,omitempty"`
+	CancelState        string                   `json:"cancel_state,omitempty"`
+	CancelAckAt        string                   `json:"cancel_acknowledged_at,omitempty"`
 	Progress           map[string]any           `json:"progress,omitempty"`
 	Error              *JobError                `json:"error,omitempty"`
 	Payloads           map[string]*TransferInfo `json:"payloads,omitempty"`
@@ -173,6 +281,8 @@ type JobsSummary struct {
 	QueuedJobs            int    `json:"queued_jobs"`
 	ClaimedJobs           int    `json:"claimed_jobs"`
 	RunningJobs           int    `json:"running_jobs"`
+	ScheduledJobs         int    `json:"scheduled_jobs"`
+	BlockedJobs           int    `json:"blocked_jobs"`
 	AwaitingTransfers     int    `json:"awaiting_transfers"`
 	TerminalJobs          int    `json:"terminal_jobs"`
 	ActiveWorkers         int    `json:"active_workers"`
@@ -208,9 +318,77 @@ func NewRegistry(tr *transfer.Registry, sseCloseDelay time.Duration, clientTTL t
 		clientTTL:     clientTTL,
 		clientTimers:  make(map[string]*time.Timer),
 		workers:       make(map[string]*WorkerActivity),
+		store:         nopStore{},
+		leaseTimers:   make(map[string]*time.Timer),
+		schedTimers:   make(map[string]*time.Timer),
+		children:      make(map[string][]string),
+		webhooks:      make(map[string]*webhookQueue),
+		hookBackoff:   time.Second,
+		hookClient:    newWebhookClient(nil),
+		streams:       make(map[*workerStream]struct{}),
+		partialMax:    DefaultPartialHistory,
+	}
+}
+
+// SetPriorityAging raises the effective priority of a queued job by one for
+// every interval it has waited since creation so low-priority jobs eventually
+// run. Zero disables aging.
+func (r *Registry) SetPriorityAging(interval time.Duration) {
+	r.mu.Lock()
+	r.priorityAging = interval
+	r.mu.Unlock()
+}
+
+// SetJobRetention removes terminal jobs from the registry and the store once
+// they have not changed for d. Zero keeps them forever.
+func (r *Registry) SetJobRetention(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobRetention = d
+	if r.pruneTimer != nil {
+		r.pruneTimer.Stop()
+		r.pruneTimer = nil
+	}
+	if d > 0 {
+		r.pruneTimer = time.AfterFunc(min(d, time.Minute), r.pruneJobs)
 	}
 }
 
+func (r *Registry) pruneJobs() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneJobsLocked(time.Now())
+	if r.jobRetention > 0 {
+		r.pruneTimer = time.AfterFunc(min(r.jobRetention, time.Minute), r.pruneJobs)
+	}
+}
+
+// pruneJobsLocked drops terminal jobs older than the retention. Jobs that are
+// still followed, have webhook deliveries pending or have dependents waiting
+// on them are kept until those finish.
+func (r *Registry) pruneJobsLocked(now time.Time) {
+	for id, job := range r.jobs {
+		if !isTerminalStatus(job.Status) || now.Sub(job.UpdatedAt) < r.jobRetention {
+			continue
+		}
+		if len(r.subs[id]) > 0 || r.webhooks[id] != nil || r.hasPendingChildrenLocked(id) {
+			continue
+		}
+		delete(r.jobs, id)
+		delete(r.children, id)
+		r.deleteLocked(id)
+	}
+}
+
+func (r *Registry) hasPendingChildrenLocked(jobID string) bool {
+	for _, id := range r.children[jobID] {
+		if child := r.jobs[id]; child != nil && !isTerminalStatus(child.Status) {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Registry) RegisterRoutes(router chi.Router) {
 	r.RegisterClientRoutes(router)
 	r.RegisterWorkerRoutes(router)
@@ -218,9 +396,16 @@ func (r *Registry) RegisterRoutes(router chi.Router) {
 
 func (r *Registry) RegisterClientRoutes(router chi.Router) {
 	router.Post("/jobs", r.HandleCreateJob)
+	router.Get("/jobs", r.HandleListJobs)
+	router.Get("/jobs/dead-letter", r.HandleListDeadLetter)
+	router.Post("/jobs/dead-letter/{job_id}/redrive", r.HandleRedriveDeadLetter)
+	router.Get("/jobs/workflows/{workflow_id}", r.HandleGetWorkflow)
+	router.Get("/jobs/workflows/{workflow_id}/events", r.HandleWorkflowEvents)
 	router.Get("/jobs/{job_id}", r.HandleGetJob)
 	router.Get("/jobs/{job_id}/events", r.HandleJobEvents)
+	router.Get("/jobs/{job_id}/results/{key}", r.HandleGetResult)
 	router.Post("/jobs/{job_id}/cancel", r.HandleCancelJob)
+	router.Post("/jobs/{job_id}/webhook/test", r.HandleTestWebhook)
 }
 
 func (r *Registry) RegisterWorkerRoutes(router chi.Router) {
@@ -229,6 +414,8 @@ func (r *Registry) RegisterWorkerRoutes(router chi.Router) {
 	router.Post("/jobs/{job_id}/payload", r.HandlePayloadRequest)
 	router.Post("/jobs/{job_id}/result", r.HandleResultRequest)
 	router.Post("/jobs/{job_id}/status", r.HandleStatusUpdate)
+	router.Post("/jobs/{job_id}/heartbeat", r.HandleHeartbeat)
+	router.Post("/jobs/{job_id}/partial", r.HandleAppendPartial)
 }
 
 func (r *Registry) HandleCreateJob(w http.ResponseWriter, req *http.Request) {
@@ -241,25 +428,118 @@ func (r *Registry) HandleCreateJob(w http.ResponseWriter, req *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "missing_type"})
 		return
 	}
-	job := &Job{
-		ID:          uuid.NewString(),
-		Type:        body.Type,
-		Status:      StatusQueued,
-		Metadata:    body.Metadata,
-		WorkerID:    strings.TrimSpace(body.WorkerID),
-		WorkerGroup: strings.TrimSpace(body.WorkerGroup),
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	if body.Retry != nil && !body.Retry.valid() {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_retry"})
+		return
+	}
+	if body.Webhook != nil && !body.Webhook.valid() {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_webhook"})
+		return
+	}
+	now := time.Now()
+	status := StatusQueued
+	var notBefore time.Time
+	if body.NotBefore != nil {
+		notBefore = *body.NotBefore
+	}
+	schedule := strings.TrimSpace(body.Schedule)
+	if schedule != "" {
+		spec, err := parseCron(schedule)
+		start := now
+		if notBefore.After(start) {
+			start = notBefore
+		}
+		if err == nil {
+			notBefore = spec.next(start.Add(-time.Nanosecond))
+		}
+		if err != nil || notBefore.IsZero() {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_schedule"})
+			return
+		}
+		status = StatusScheduled
 	}
+	dependsOn := parseListQuery(body.DependsOn)
+	if len(dependsOn) > 0 && status == StatusScheduled {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_dependencies"})
+		return
+	}
+	workflowID := strings.TrimSpace(body.WorkflowID)
 	r.mu.Lock()
+	if body.RetainResults && r.results == nil {
+		r.mu.Unlock()
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "result_retention_disabled"})
+		return
+	}
+	for _, id := range dependsOn {
+		parent := r.jobs[id]
+		if parent == nil || parent.Status == StatusScheduled {
+			r.mu.Unlock()
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_dependencies"})
+			return
+		}
+		if workflowID == "" {
+			workflowID = parent.WorkflowID
+		}
+	}
+	job := &Job{
+		ID:            uuid.NewString(),
+		Type:          body.Type,
+		Status:        status,
+		Priority:      body.Priority,
+		Metadata:      body.Metadata,
+		WorkerID:      strings.TrimSpace(body.WorkerID),
+		WorkerGroup:   strings.TrimSpace(body.WorkerGroup),
+		Retry:         copyRetry(body.Retry),
+		NotBefore:     notBefore,
+		Schedule:      schedule,
+		DependsOn:     dependsOn,
+		WorkflowID:    workflowID,
+		Owner:         r.ownerLocked(req),
+		RetainResults: body.RetainResults,
+		Webhook:       copyWebhook(body.Webhook),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if len(dependsOn) > 0 {
+		switch r.dependencyStateLocked(job) {
+		case dependenciesPending:
+			job.Status = StatusBlocked
+		case dependenciesFailed:
+			job.Status = StatusCanceled
+			job.Error = &JobError{Code: "dependency_failed", Message: "a dependency failed or was canceled"}
+			r.recordTerminalLocked(job, StatusCanceled, now)
+		}
+	}
+	job.History = []Transition{{Status: job.Status, At: now}}
+	delayed := job.NotBefore.After(now)
 	r.jobs[job.ID] = job
-	r.queue = append(r.queue, job.ID)
+	r.addDependentsLocked(job)
+	switch job.Status {
+	case StatusScheduled:
+		r.armScheduleLocked(job)
+	case StatusQueued:
+		r.queue = append(r.queue, job.ID)
+	}
+	r.saveLocked(job)
 	view := r.viewLocked(job)
 	r.mu.Unlock()
 	r.publish(job.ID, Event{Type: "status", Data: view})
-	r.startClientTimerIfIdle(job.ID)
-	r.signal()
-	writeJSON(w, http.StatusOK, map[string]any{"job_id": job.ID, "status": job.Status})
+	// Delayed and recurring jobs are submitted ahead of time, and workflow
+	// jobs are followed through the workflow, so they are not tied to a
+	// connected client.
+	if job.Status == StatusQueued && !delayed {
+		if workflowID == "" {
+			r.startClientTimerIfIdle(job.ID)
+		}
+		r.signal()
+	} else if job.Status == StatusQueued {
+		r.wakeAt(job.NotBefore)
+	}
+	resp := map[string]any{"job_id": job.ID, "status": job.Status}
+	if view.NotBefore != "" {
+		resp["not_before"] = view.NotBefore
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func (r *Registry) HandleGetJob(w http.ResponseWriter, req *http.Request) {
@@ -292,8 +572,11 @@ func (r *Registry) HandleJobEvents(w http.ResponseWriter, req *http.Request) {
 	defer r.unsubscribe(jobID, ch)
 	r.onClientConnect(jobID)
 
-	// send initial status
+	// send initial status, then the buffered partial results the client has
+	// not seen yet
 	r.writeEvent(w, Event{Type: "status", Data: view})
+	lastPartial := lastEventID(req)
+	r.writePartials(w, jobID, &lastPartial, 0)
 	flusher.Flush()
 
 	ctx := req.Context()
@@ -306,6 +589,15 @@ func (r *Registry) HandleJobEvents(w http.ResponseWriter, req *http.Request) {
 		case <-closeCh:
 			return
 		case ev := <-ch:
+			if p, ok := ev.Data.(Partial); ok && ev.Type == "partial" {
+				// Partials are sent in sequence order without duplicates,
+				// filling in any the subscription missed from the buffer.
+				if p.Seq > lastPartial {
+					r.writePartials(w, jobID, &lastPartial, p.Seq)
+					flusher.Flush()
+				}
+				continue
+			}
 			r.writeEvent(w, ev)
 			flusher.Flush()
 			if r.sseCloseDelay >= 0 && shouldCloseAfter(ev) {
@@ -350,6 +642,18 @@ func isTerminalStatus(status string) bool {
 	}
 }
 
+// isWorkerStatus reports whether a worker holding a claim may move the job to
+// status. Queued, blocked and scheduled are managed by the server, and a
+// worker only reports canceled to acknowledge a cancellation.
+func isWorkerStatus(status string) bool {
+	switch status {
+	case StatusClaimed, StatusRunning, StatusCompleted, StatusFailed:
+		return true
+	default:
+		return false
+	}
+}
+
 func (r *Registry) HandleCancelJob(w http.ResponseWriter, req *http.Request) {
 	jobID := chi.URLParam(req, "job_id")
 	var view JobView
@@ -369,6 +673,7 @@ func (r *Registry) HandleCancelJob(w http.ResponseWriter, req *http.Request) {
 			r.removeFromQueue(jobID)
 		}
 		r.setJobStatusLocked(job, StatusCanceled, time.Now())
+		r.saveLocked(job)
 		status = job.Status
 		view = r.viewLocked(job)
 	}
@@ -430,6 +735,8 @@ func (r *Registry) HandleClaimStream(w http.ResponseWriter, req *http.Request) {
 	workerGroup := strings.TrimSpace(req.URL.Query().Get("worker_group"))
 	r.addWorkerStream(workerID, workerGroup, types)
 	defer r.removeWorkerStream(workerID, workerGroup)
+	stream := r.openStream(workerID)
+	defer r.closeStream(stream)
 	keepAlive := time.NewTicker(15 * time.Second)
 	defer keepAlive.Stop()
 
@@ -438,17 +745,28 @@ func (r *Registry) HandleClaimStream(w http.ResponseWriter, req *http.Request) {
 		select {
 		case <-ctx.Done():
 			return
+		case id := <-stream.cancels:
+			r.writeEvent(w, Event{Type: "cancel", Data: map[string]any{"job_id": id}})
+			flusher.Flush()
+			continue
 		default:
 		}
 		if job := r.claimNext(types, workerID, workerGroup); job != nil {
+			r.mu.Lock()
+			job.stream = stream
+			resp := r.claimResponse(job)
+			r.mu.Unlock()
 			r.recordWorkerSeen(workerID, workerGroup, "stream", types, job.ID)
-			r.writeEvent(w, Event{Type: "job", Data: r.claimResponse(job)})
+			r.writeEvent(w, Event{Type: "job", Data: resp})
 			flusher.Flush()
 			continue
 		}
 		select {
 		case <-ctx.Done():
 			return
+		case id := <-stream.cancels:
+			r.writeEvent(w, Event{Type: "cancel", Data: map[string]any{"job_id": id}})
+			flusher.Flush()
 		case <-r.notify:
 			r.recordWorkerSeen(workerID, workerGroup, "stream", types, "")
 		case <-keepAlive.C:
@@ -484,17 +802,48 @@ func (r *Registry) HandlePayloadRequest(w http.ResponseWriter, req *http.Request
 	if body.Key != nil {
 		key = strings.TrimSpace(*body.Key)
 	}
-	channelID, expires := r.transfer.Create()
+	opts, err := transfer.OptionsFor(body.Mode, body.Readers)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_mode"})
+		return
+	}
+	opts.Size, opts.ContentType, opts.SHA256, opts.MaxBytes = body.Size, body.ContentType, body.SHA256, body.MaxBytes
+	opts.ContentEncoding = body.ContentEncoding
+	opts.JobID = jobID
+	relay, ok := r.checkRelay(w, body, opts)
+	if !ok {
+		return
+	}
+	channelID, expires, err := r.transfer.CreateWith(opts)
+	if errors.Is(err, transfer.ErrInvalidOptions) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_transfer_options"})
+		return
+	}
+	if err != nil {
+		logx.Log.Warn().Err(err).Str("job_id", jobID).Msg("create transfer channel")
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": "transfer_spool_unavailable"})
+		return
+	}
 	readerURL := "/api/transfer/" + channelID
 	writerURL := "/api/transfer/" + channelID
 
+	clientToken, tokenExpires := r.transfer.Token(channelID, transfer.Writer)
+	workerToken, _ := r.transfer.Token(channelID, transfer.Reader)
 	info := &TransferInfo{
-		ChannelID:  channelID,
-		Method:     http.MethodPost,
-		URL:        writerURL,
-		ExpiresAt:  expires.UTC().Format(time.RFC3339),
-		Key:        key,
-		Properties: copyMap(body.Properties),
+		ChannelID:       channelID,
+		Method:          http.MethodPost,
+		URL:             writerURL,
+		ExpiresAt:       expires.UTC().Format(time.RFC3339),
+		Key:             key,
+		Mode:            opts.Mode(),
+		Properties:      copyMap(body.Properties),
+		Size:            body.Size,
+		ContentType:     body.ContentType,
+		ContentEncoding: body.ContentEncoding,
+		SHA256:          body.SHA256,
+		MaxBytes:        body.MaxBytes,
+		Token:           clientToken,
+		TokenExpiresAt:  tokenExpires.UTC().Format(time.RFC3339),
 	}
 	r.mu.Lock()
 	if job.Payloads == nil {
@@ -502,6 +851,8 @@ func (r *Registry) HandlePayloadRequest(w http.ResponseWriter, req *http.Request
 	}
 	job.Payloads[key] = info
 	r.setJobStatusLocked(job, StatusAwaitingPayload, time.Now())
+	r.extendLeaseLocked(job, time.Now())
+	r.saveLocked(job)
 	view = r.viewLocked(job)
 	r.mu.Unlock()
 
@@ -510,8 +861,14 @@ func (r *Registry) HandlePayloadRequest(w http.ResponseWriter, req *http.Request
 	resp := map[string]any{
 		"key":        key,
 		"channel_id": channelID,
-		"reader_url": readerURL,
 		"expires_at": expires.UTC().Format(time.RFC3339),
+		"mode":       opts.Mode(),
+	}
+	if relay != nil {
+		go r.relayPayload(relay, jobID, channelID, *body.Relay, body.ContentType, body.ContentEncoding)
+		resp["relay"] = body.Relay
+	} else {
+		resp["reader_url"], resp["token"] = readerURL, workerToken
 	}
 	if props := copyMap(body.Properties); props != nil {
 		resp["properties"] = props
@@ -544,34 +901,87 @@ func (r *Registry) HandleResultRequest(w http.ResponseWriter, req *http.Request)
 	if body.Key != nil {
 		key = strings.TrimSpace(*body.Key)
 	}
-	channelID, expires := r.transfer.Create()
+	opts, err := transfer.OptionsFor(body.Mode, body.Readers)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_mode"})
+		return
+	}
+	opts.Size, opts.ContentType, opts.SHA256, opts.MaxBytes = body.Size, body.ContentType, body.SHA256, body.MaxBytes
+	opts.ContentEncoding = body.ContentEncoding
+	opts.JobID = jobID
+	relay, ok := r.checkRelay(w, body, opts)
+	if !ok {
+		return
+	}
+	channelID, expires, err := r.transfer.CreateWith(opts)
+	if errors.Is(err, transfer.ErrInvalidOptions) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_transfer_options"})
+		return
+	}
+	if err != nil {
+		logx.Log.Warn().Err(err).Str("job_id", jobID).Msg("create transfer channel")
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": "transfer_spool_unavailable"})
+		return
+	}
 	readerURL := "/api/transfer/" + channelID
 	writerURL := "/api/transfer/" + channelID
 
+	clientToken, tokenExpires := r.transfer.Token(channelID, transfer.Reader)
+	workerToken, _ := r.transfer.Token(channelID, transfer.Writer)
 	info := &TransferInfo{
-		ChannelID:  channelID,
-		Method:     http.MethodGet,
-		URL:        readerURL,
-		ExpiresAt:  expires.UTC().Format(time.RFC3339),
-		Key:        key,
-		Properties: copyMap(body.Properties),
+		ChannelID:       channelID,
+		Method:          http.MethodGet,
+		URL:             readerURL,
+		ExpiresAt:       expires.UTC().Format(time.RFC3339),
+		Key:             key,
+		Mode:            opts.Mode(),
+		Properties:      copyMap(body.Properties),
+		Size:            body.Size,
+		ContentType:     body.ContentType,
+		ContentEncoding: body.ContentEncoding,
+		SHA256:          body.SHA256,
+		MaxBytes:        body.MaxBytes,
+		Token:           clientToken,
+		TokenExpiresAt:  tokenExpires.UTC().Format(time.RFC3339),
 	}
 	r.mu.Lock()
-	if job.Results == nil {
-		job.Results = make(map[string]*TransferInfo)
+	retained := job.RetainResults && r.results != nil
+	if retained {
+		if err := r.retainResultLocked(job, info); err != nil {
+			r.mu.Unlock()
+			r.transfer.Close(channelID, err)
+			logx.Log.Warn().Err(err).Str("job_id", jobID).Msg("create result spool entry")
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": "result_spool_unavailable"})
+			return
+		}
+	} else {
+		if job.Results == nil {
+			job.Results = make(map[string]*TransferInfo)
+		}
+		job.Results[key] = info
 	}
-	job.Results[key] = info
 	r.setJobStatusLocked(job, StatusAwaitingResult, time.Now())
+	r.extendLeaseLocked(job, time.Now())
+	r.saveLocked(job)
 	view = r.viewLocked(job)
 	r.mu.Unlock()
 
-	r.publish(jobID, Event{Type: "result", Data: info})
+	// Retained results are announced once they have been stored.
+	if !retained {
+		r.publish(jobID, Event{Type: "result", Data: info})
+	}
 	r.publish(jobID, Event{Type: "status", Data: view})
 	resp := map[string]any{
 		"key":        key,
 		"channel_id": channelID,
-		"writer_url": writerURL,
 		"expires_at": expires.UTC().Format(time.RFC3339),
+		"mode":       opts.Mode(),
+	}
+	if relay != nil {
+		go r.relayResult(relay, jobID, channelID, *body.Relay)
+		resp["relay"] = body.Relay
+	} else {
+		resp["writer_url"], resp["token"] = writerURL, workerToken
 	}
 	if props := copyMap(body.Properties); props != nil {
 		resp["properties"] = props
@@ -589,7 +999,9 @@ func (r *Registry) HandleStatusUpdate(w http.ResponseWriter, req *http.Request)
 		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not_found"})
 		return
 	}
-	if isTerminalStatus(job.Status) {
+	// A worker acknowledges a cancellation by reporting the job canceled.
+	awaitingAck := job.CancelState == CancelRequested
+	if !awaitingAck && (isTerminalStatus(job.Status) || job.Status == StatusScheduled || job.Status == StatusBlocked || job.Status == StatusQueued) {
 		r.mu.Unlock()
 		writeJSON(w, http.StatusConflict, map[string]any{"error": "invalid_state"})
 		return
@@ -605,32 +1017,57 @@ func (r *Registry) HandleStatusUpdate(w http.ResponseWriter, req *http.Request)
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "missing_state"})
 		return
 	}
+	if awaitingAck {
+		r.acknowledgeCancel(w, jobID, body.ClaimToken, state)
+		return
+	}
+	if !isWorkerStatus(state) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "unsupported_state"})
+		return
+	}
 	r.mu.Lock()
-	if isTerminalStatus(job.Status) {
+	if isTerminalStatus(job.Status) || job.Status == StatusScheduled || job.Status == StatusBlocked || job.Status == StatusQueued {
 		r.mu.Unlock()
 		writeJSON(w, http.StatusConflict, map[string]any{"error": "invalid_state"})
 		return
 	}
-	r.setJobStatusLocked(job, state, time.Now())
+	if !r.holdsClaimLocked(job, body.ClaimToken) {
+		r.mu.Unlock()
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "stale_claim"})
+		return
+	}
+	now := time.Now()
+	retried := state == StatusFailed && r.retryLocked(job, body.Error, now)
+	if !retried {
+		r.setJobStatusLocked(job, state, now)
+		r.extendLeaseLocked(job, now)
+		if body.Error != nil {
+			job.Error = body.Error
+		}
+	}
 	if body.Progress != nil {
 		job.Progress = body.Progress
 	}
-	if body.Error != nil {
-		job.Error = body.Error
-	}
+	r.saveLocked(job)
 	view = r.viewLocked(job)
 	r.mu.Unlock()
 
-	if isTerminalStatus(state) {
+	if retried {
+		r.signal()
+	} else if isTerminalStatus(state) {
 		r.clearClientTimer(jobID)
 	}
 	r.publish(jobID, Event{Type: "status", Data: view})
-	writeJSON(w, http.StatusOK, map[string]any{"status": job.Status})
+	writeJSON(w, http.StatusOK, map[string]any{"status": view.Status})
 }
 
+// claimNext hands the highest-priority compatible queued job to a worker,
+// in FIFO order among jobs of equal effective priority.
 func (r *Registry) claimNext(types []string, workerID, workerGroup string) *Job {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	now := time.Now()
+	best, bestPriority := -1, 0
 	for i, id := range r.queue {
 		job := r.jobs[id]
 		if job == nil {
@@ -642,14 +1079,65 @@ func (r *Registry) claimNext(types []string, workerID, workerGroup string) *Job
 		if !isCompatibleClaim(job, workerID, workerGroup) {
 			continue
 		}
-		r.queue = append(r.queue[:i], r.queue[i+1:]...)
-		r.setJobStatusLocked(job, StatusClaimed, time.Now())
-		job.ClaimedWorkerID = workerID
-		job.ClaimedWorkerGroup = workerGroup
-		r.publishLocked(job.ID, Event{Type: "status", Data: r.viewLocked(job)})
-		return job
+		if now.Before(job.NotBefore) {
+			continue
+		}
+		if p := r.effectivePriorityLocked(job, now); best < 0 || p > bestPriority {
+			best, bestPriority = i, p
+		}
 	}
-	return nil
+	if best < 0 {
+		return nil
+	}
+	job := r.jobs[r.queue[best]]
+	r.queue = append(r.queue[:best], r.queue[best+1:]...)
+	r.setJobStatusLocked(job, StatusClaimed, now)
+	job.ClaimedWorkerID = workerID
+	job.ClaimedWorkerGroup = workerGroup
+	job.LastWorkerID = workerID
+	job.LastWorkerGroup = workerGroup
+	job.Attempts++
+	job.ClaimToken = uuid.NewString()
+	r.extendLeaseLocked(job, now)
+	r.saveLocked(job)
+	r.publishLocked(job.ID, Event{Type: "status", Data: r.viewLocked(job)})
+	return job
+}
+
+func (r *Registry) effectivePriorityLocked(job *Job, now time.Time) int {
+	p := job.Priority
+	if r.priorityAging > 0 && now.After(job.CreatedAt) {
+		p += int(now.Sub(job.CreatedAt) / r.priorityAging)
+	}
+	return p
+}
+
+// queuePositionLocked returns the 1-based position at which jobID would be
+// claimed by a worker accepting any queued job, or 0 if it is not queued.
+func (r *Registry) queuePositionLocked(jobID string, now time.Time) int {
+	job := r.jobs[jobID]
+	if job == nil || job.Status != StatusQueued {
+		return 0
+	}
+	p := r.effectivePriorityLocked(job, now)
+	pos, found := 1, false
+	for _, id := range r.queue {
+		if id == jobID {
+			found = true
+			continue
+		}
+		other := r.jobs[id]
+		if other == nil {
+			continue
+		}
+		if op := r.effectivePriorityLocked(other, now); op > p || (op == p && !found) {
+			pos++
+		}
+	}
+	if !found {
+		return 0
+	}
+	return pos
 }
 
 func (r *Registry) removeFromQueue(jobID string) {
@@ -705,6 +1193,21 @@ func (r *Registry) publishLocked(jobID string, ev Event) {
 		default:
 		}
 	}
+	job := r.jobs[jobID]
+	if job == nil || ev.Type != "status" {
+		return
+	}
+	if job.WorkflowID != "" {
+		for ch := range r.subs[workflowKey(job.WorkflowID)] {
+			select {
+			case ch <- ev:
+			default:
+			}
+		}
+	}
+	if view, ok := ev.Data.(JobView); ok {
+		r.notifyWebhookLocked(job, view, false)
+	}
 }
 
 func (r *Registry) onClientConnect(jobID string) {
@@ -733,6 +1236,11 @@ func (r *Registry) startClientTimerIfIdle(jobID string) {
 		r.mu.Unlock()
 		return
 	}
+	// Tracked jobs do not depend on a client following them.
+	if job := r.jobs[jobID]; job != nil && job.Tracked {
+		r.mu.Unlock()
+		return
+	}
 	if r.clientTimers[jobID] != nil {
 		r.mu.Unlock()
 		return
@@ -780,6 +1288,7 @@ func (r *Registry) expireJobIfIdle(jobID string) {
 	}
 	r.setJobStatusLocked(job, StatusCanceled, time.Now())
 	job.Error = &JobError{Code: "client_inactive", Message: "client inactive"}
+	r.saveLocked(job)
 	view := r.viewLocked(job)
 	r.mu.Unlock()
 
@@ -793,6 +1302,9 @@ func (r *Registry) writeEvent(w http.ResponseWriter, ev Event) {
 		logx.Log.Warn().Err(err).Str("event", ev.Type).Msg("serialize job event")
 		return
 	}
+	if ev.ID != "" {
+		_, _ = w.Write([]byte("id: " + ev.ID + "\n"))
+	}
 	if ev.Type != "" {
 		_, _ = w.Write([]byte("event: " + ev.Type + "\n"))
 	}
@@ -808,16 +1320,7 @@ func (r *Registry) snapshot(jobID string) (JobView, int, bool) {
 	if job == nil {
 		return JobView{}, 0, false
 	}
-	pos := 0
-	if job.Status == StatusQueued {
-		for i, id := range r.queue {
-			if id == jobID {
-				pos = i + 1
-				break
-			}
-		}
-	}
-	return r.viewLocked(job), pos, true
+	return r.viewLocked(job), r.queuePositionLocked(jobID, time.Now()), true
 }
 
 func (r *Registry) viewLocked(job *Job) JobView {
@@ -828,11 +1331,28 @@ func (r *Registry) viewLocked(job *Job) JobView {
 		ID:                 job.ID,
 		Type:               job.Type,
 		Status:             job.Status,
+		Priority:           job.Priority,
 		Metadata:           copyMap(job.Metadata),
 		WorkerID:           job.WorkerID,
 		WorkerGroup:        job.WorkerGroup,
 		ClaimedWorkerID:    job.ClaimedWorkerID,
 		ClaimedWorkerGroup: job.ClaimedWorkerGroup,
+		LastWorkerID:       job.LastWorkerID,
+		LastWorkerGroup:    job.LastWorkerGroup,
+		Attempts:           job.Attempts,
+		LeaseExpiresAt:     formatOptionalTime(job.LeaseExpiresAt),
+		Retry:              copyRetry(job.Retry),
+		NotBefore:          formatOptionalTime(job.NotBefore),
+		DeadLetteredAt:     formatOptionalTime(job.DeadLetteredAt),
+		Schedule:           job.Schedule,
+		ScheduleID:         job.ScheduleID,
+		LastJobID:          job.LastJobID,
+		DependsOn:          append([]string(nil), job.DependsOn...),
+		WorkflowID:         job.WorkflowID,
+		RetainResults:      job.RetainResults,
+		Webhook:            webhookView(job),
+		CancelState:        job.CancelState,
+		CancelAckAt:        formatOptionalTime(job.CancelAckAt),
 		Progress:           copyMap(job.Progress),
 		Error:              copyError(job.Error),
 		Payloads:           copyTransfers(job.Payloads),
@@ -846,15 +1366,22 @@ func (r *Registry) claimResponse(job *Job) map[string]any {
 	if job == nil {
 		return map[string]any{}
 	}
-	return map[string]any{
+	resp := map[string]any{
 		"job_id":               job.ID,
 		"type":                 job.Type,
+		"priority":             job.Priority,
 		"metadata":             copyMap(job.Metadata),
 		"worker_id":            job.WorkerID,
 		"worker_group":         job.WorkerGroup,
 		"claimed_worker_id":    job.ClaimedWorkerID,
 		"claimed_worker_group": job.ClaimedWorkerGroup,
+		"attempt":              job.Attempts,
+		"claim_token":          job.ClaimToken,
 	}
+	if !job.LeaseExpiresAt.IsZero() {
+		resp["lease_expires_at"] = job.LeaseExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return resp
 }
 
 func (r *Registry) setJobStatusLocked(job *Job, status string, now time.Time) {
@@ -868,8 +1395,28 @@ func (r *Registry) setJobStatusLocked(job *Job, status string, now time.Time) {
 	if !isTerminalStatus(prev) && isTerminalStatus(status) {
 		r.recordTerminalLocked(job, status, now)
 	}
+	if status != prev {
+		job.History = append(job.History, Transition{Status: status, At: now})
+	}
+	if status == StatusQueued || isTerminalStatus(status) {
+		r.clearLeaseLocked(job)
+	}
+	if status == StatusQueued {
+		job.stream = nil
+	}
+	if status == StatusCanceled && job.onCancel != nil {
+		go job.onCancel()
+	} else if status == StatusCanceled && canRequestTransfer(prev) {
+		r.cancelInFlightLocked(job)
+	}
+	if isTerminalStatus(status) {
+		r.stopScheduleLocked(job.ID)
+	}
 	job.Status = status
 	job.UpdatedAt = now
+	if !isTerminalStatus(prev) && isTerminalStatus(status) {
+		r.resolveDependentsLocked(job, now)
+	}
 }
 
 func (r *Registry) recordTerminalLocked(job *Job, status string, now time.Time) {
@@ -1006,7 +1553,12 @@ func (r *Registry) StateSnapshot() StateView {
 			continue
 		}
 		state.Summary.TotalJobs++
+		if job.Status == StatusScheduled || (job.Status == StatusQueued && job.NotBefore.After(now)) {
+			state.Summary.ScheduledJobs++
+		}
 		switch job.Status {
+		case StatusBlocked:
+			state.Summary.BlockedJobs++
 		case StatusQueued:
 			state.Summary.QueuedJobs++
 			if age := ageSeconds(now, job.CreatedAt); age > state.Summary.OldestQueuedSeconds {
@@ -1032,14 +1584,7 @@ func (r *Registry) StateSnapshot() StateView {
 		}
 		if len(state.Jobs) < maxStateJobs {
 			view := r.viewLocked(job)
-			if job.Status == StatusQueued {
-				for i, qid := range r.queue {
-					if qid == id {
-						view.QueuePosition = i + 1
-						break
-					}
-				}
-			}
+			view.QueuePosition = r.queuePositionLocked(id, now)
 			state.Jobs = append(state.Jobs, view)
 		}
 	}
@@ -1114,10 +1659,15 @@ func (r *Registry) StateHTML() string {
       if (m < 60) return m.toFixed(m < 10 ? 1 : 0) + 'm';
       return (m / 60).toFixed(1) + 'h';
     }
+    function due(ts){
+      if (!ts) return '';
+      var d = Math.round((new Date(ts).getTime() - Date.now())/1000);
+      return d > 0 ? 'in ' + secs(d) : 'now';
+    }
     function statusTone(s){
       s = String(s || '').toLowerCase();
       if (s === 'running' || s === 'claimed') return 'live';
-      if (s === 'awaiting_payload' || s === 'awaiting_result' || s === 'queued') return 'warn';
+      if (s === 'awaiting_payload' || s === 'awaiting_result' || s === 'queued' || s === 'scheduled' || s === 'blocked') return 'warn';
       return '';
     }
     function render(state, container){
@@ -1132,6 +1682,8 @@ func (r *Registry) StateHTML() string {
           ['Queued Users', summary.queued_jobs || 0, 'Clients waiting for a worker claim'],
           ['Oldest Queue', secs(summary.oldest_queued_seconds), 'Age of the oldest queued job'],
           ['Running', summary.running_jobs || 0, 'Currently processing'],
+          ['Scheduled', summary.scheduled_jobs || 0, 'Recurring or not yet due'],
+          ['Blocked', summary.blocked_jobs || 0, 'Waiting on dependencies'],
           ['Awaiting Transfer', summary.awaiting_transfers || 0, 'Payload or result handoff'],
           ['Completed', summary.completed_jobs || 0, 'Successful jobs since boot'],
           ['Failed', summary.failed_jobs || 0, 'Failed jobs since boot'],
@@ -1164,15 +1716,17 @@ func (r *Registry) StateHTML() string {
         if (!jobs.length) {
           jobsHost.innerHTML = '<div class="jobs-empty">No jobs recorded yet.</div>';
         } else {
-          jobsHost.innerHTML = '<table class="jobs-table"><thead><tr><th>Status</th><th>Type</th><th>Affinity</th><th>Claimed By</th><th>Updated</th></tr></thead><tbody>' +
+          jobsHost.innerHTML = '<table class="jobs-table"><thead><tr><th>Status</th><th>Type</th><th>Priority</th><th>Affinity</th><th>Claimed By</th><th>Updated</th></tr></thead><tbody>' +
             jobs.map(function(j){
               var affinity = (j.worker_id || '-') + ' / ' + (j.worker_group || '-');
               var claimed = (j.claimed_worker_id || '-') + ' / ' + (j.claimed_worker_group || '-');
               var status = j.status || '';
               var extra = j.queue_position ? ' #' + j.queue_position : '';
+              if (j.not_before && (status === 'scheduled' || status === 'queued')) extra += ' next ' + due(j.not_before);
               return '<tr>' +
                 '<td><span class="jobs-pill '+statusTone(status)+'">'+esc(status + extra)+'</span></td>' +
                 '<td><div>'+esc(j.type || '')+'</div><code>'+esc(j.id || '')+'</code></td>' +
+                '<td>'+esc(j.priority || 0)+'</td>' +
                 '<td>'+esc(affinity)+'</td>' +
                 '<td>'+esc(claimed)+'</td>' +
                 '<td>'+esc(rel(j.updated_at))+'</td>' +
@@ -1335,6 +1889,18 @@ func contains(list []string, v string) bool {
 	return false
 }
 
+// holdsClaimLocked reports whether a worker presenting token may act on the
+// job's current claim. Workers that predate claim tokens send none; that is
+// accepted while it cannot be confused with an earlier claim, that is when
+// leases are disabled or the job is on its first attempt. A token that does
+// not match the current claim is always refused.
+func (r *Registry) holdsClaimLocked(job *Job, token string) bool {
+	if token == "" {
+		return r.leaseTTL <= 0 || job.Attempts <= 1
+	}
+	return token == job.ClaimToken
+}
+
 func canRequestTransfer(state string) bool {
 	switch state {
 	case StatusClaimed, StatusRunning, StatusAwaitingPayload, StatusAwaitingResult:
@@ -4,13 +4,19 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/go-chi/chi/v5"
 
 	"github.com/gaspardpetit/nfrx/server/internal/transfer"
@@ -517,6 +523,52 @@ func TestHandlePayloadRequestOmitsPropertiesWhenUnset(t *testing.T) {
 	}
 }
 
+func TestHandleResultRequestOpensBufferedChannel(t *testing.T) {
+	tr := transfer.NewRegistry(0)
+	tr.SetSpool(t.TempDir(), 0)
+	reg := NewRegistry(tr, 0, 0)
+	job := &Job{
+		ID:        "job-result-buffered",
+		Type:      "test",
+		Status:    StatusRunning,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	reg.mu.Lock()
+	reg.jobs[job.ID] = job
+	reg.mu.Unlock()
+
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+
+	req := httptest.NewRequest(http.MethodPost, "/jobs/"+job.ID+"/result", strings.NewReader(`{"mode":"fanout"}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest || !strings.Contains(resp.Body.String(), "invalid_mode") {
+		t.Fatalf("unknown mode: %d %s", resp.Code, resp.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/jobs/"+job.ID+"/result", strings.NewReader(`{"mode":"buffered","readers":3}`))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("result status = %d, want %d", resp.Code, http.StatusOK)
+	}
+	var out map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode result response: %v", err)
+	}
+	if out["mode"] != "buffered" {
+		t.Fatalf("mode = %v, want buffered", out["mode"])
+	}
+	reg.mu.Lock()
+	info := job.Results["result"]
+	reg.mu.Unlock()
+	if info == nil || info.Mode != "buffered" {
+		t.Fatalf("result info = %#v", info)
+	}
+}
+
 func TestHandleCancelJobIsIdempotentForTerminalJobs(t *testing.T) {
 	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
 	job := &Job{
@@ -665,16 +717,1414 @@ func TestStateSnapshotTracksOldestQueueAndInflightAge(t *testing.T) {
 	}
 }
 
-func expectEventType(t *testing.T, ch chan Event, typ string) Event {
-	t.Helper()
-	select {
-	case ev := <-ch:
-		if ev.Type != typ {
-			t.Fatalf("event type = %q, want %q", ev.Type, typ)
+func TestRestoreRequeuesJobsAfterRestart(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	first := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	first.SetStore(store)
+	router := chi.NewRouter()
+	first.RegisterRoutes(router)
+
+	claimedID := createTestJob(t, router, `{"type":"test","metadata":{"k":"v"}}`)
+	queuedID := createTestJob(t, router, `{"type":"test"}`)
+	doneID := createTestJob(t, router, `{"type":"other"}`)
+	if job := first.claimNext([]string{"test"}, "worker-a", ""); job == nil || job.ID != claimedID {
+		t.Fatalf("expected to claim %s", claimedID)
+	}
+	if job := first.claimNext([]string{"other"}, "worker-a", ""); job == nil || job.ID != doneID {
+		t.Fatalf("expected to claim %s", doneID)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/jobs/"+doneID+"/status", strings.NewReader(`{"claim_token":"`+claimToken(first, doneID)+`","state":"completed"}`))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	second := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	second.SetStore(store)
+	n, err := second.Restore(RecoveryRequeue)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("restored %d jobs, want 3", n)
+	}
+	if !reflect.DeepEqual(second.queue, []string{claimedID, queuedID}) {
+		t.Fatalf("queue = %v, want [%s %s]", second.queue, claimedID, queuedID)
+	}
+	job := second.jobs[claimedID]
+	if job.Status != StatusQueued || job.ClaimedWorkerID != "" || job.Metadata["k"] != "v" {
+		t.Fatalf("unexpected requeued job %#v", job)
+	}
+	var history []string
+	for _, tr := range job.History {
+		history = append(history, tr.Status)
+	}
+	if !reflect.DeepEqual(history, []string{StatusQueued, StatusClaimed, StatusQueued}) {
+		t.Fatalf("history = %v", history)
+	}
+	if second.jobs[doneID].Status != StatusCompleted || second.stats.CompletedJobs != 1 {
+		t.Fatalf("expected completed job and stats to be restored")
+	}
+	if job := second.claimNext([]string{"test"}, "worker-b", ""); job == nil || job.ID != claimedID {
+		t.Fatalf("expected restored job to be claimable")
+	}
+}
+
+func TestRestoreFailsInFlightJobsFromRedis(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis: %v", err)
+	}
+	defer mr.Close()
+	store, err := OpenStore("redis://" + mr.Addr())
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	first := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	first.SetStore(store)
+	router := chi.NewRouter()
+	first.RegisterRoutes(router)
+	runningID := createTestJob(t, router, `{"type":"test"}`)
+	queuedID := createTestJob(t, router, `{"type":"test"}`)
+	first.claimNext(nil, "worker-a", "")
+	req := httptest.NewRequest(http.MethodPost, "/jobs/"+runningID+"/status", strings.NewReader(`{"claim_token":"`+claimToken(first, runningID)+`","state":"running"}`))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	second := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	second.SetStore(store)
+	if _, err := second.Restore(RecoveryFail); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	job := second.jobs[runningID]
+	if job.Status != StatusFailed || job.Error == nil || job.Error.Code != "server_restart" {
+		t.Fatalf("expected in-flight job to fail, got %#v", job)
+	}
+	if !reflect.DeepEqual(second.queue, []string{queuedID}) {
+		t.Fatalf("queue = %v, want [%s]", second.queue, queuedID)
+	}
+	if second.stats.FailedJobs != 1 {
+		t.Fatalf("failed jobs = %d, want 1", second.stats.FailedJobs)
+	}
+	recs, err := store.LoadJobs()
+	if err != nil {
+		t.Fatalf("LoadJobs: %v", err)
+	}
+	for _, rec := range recs {
+		if rec.ID == runningID && rec.Status != StatusFailed {
+			t.Fatalf("expected failure to be persisted, got %q", rec.Status)
 		}
-		return ev
+	}
+}
+
+func TestClaimNextPrefersHigherPriorityWithAging(t *testing.T) {
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+
+	lowID := createTestJob(t, router, `{"type":"test","priority":1}`)
+	highA := createTestJob(t, router, `{"type":"test","priority":5}`)
+	highB := createTestJob(t, router, `{"type":"test","priority":5}`)
+
+	view, pos, ok := reg.snapshot(highB)
+	if !ok || pos != 2 || view.Priority != 5 {
+		t.Fatalf("snapshot = %+v pos %d, want priority 5 at position 2", view, pos)
+	}
+	if _, pos, _ := reg.snapshot(lowID); pos != 3 {
+		t.Fatalf("low priority position = %d, want 3", pos)
+	}
+	for _, want := range []string{highA, highB, lowID} {
+		if job := reg.claimNext(nil, "worker", ""); job == nil || job.ID != want {
+			t.Fatalf("expected to claim %s", want)
+		}
+	}
+
+	// With aging, a job queued long enough overtakes newer higher-priority work.
+	reg.SetPriorityAging(time.Minute)
+	oldID := createTestJob(t, router, `{"type":"test"}`)
+	newID := createTestJob(t, router, `{"type":"test","priority":3}`)
+	reg.mu.Lock()
+	reg.jobs[oldID].CreatedAt = time.Now().Add(-5 * time.Minute)
+	reg.mu.Unlock()
+	if job := reg.claimNext(nil, "worker", ""); job == nil || job.ID != oldID {
+		t.Fatalf("expected aged job %s to be claimed first", oldID)
+	}
+	if job := reg.claimNext(nil, "worker", ""); job == nil || job.ID != newID {
+		t.Fatalf("expected to claim %s", newID)
+	}
+}
+
+func TestLeaseExpiryRequeuesThenFails(t *testing.T) {
+	tr := transfer.NewRegistry(0)
+	reg := NewRegistry(tr, 0, 0)
+	reg.SetLease(50*time.Millisecond, 2)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+	jobID := createTestJob(t, router, `{"type":"test"}`)
+	events := reg.subscribe(jobID)
+	defer reg.unsubscribe(jobID, events)
+
+	if job := reg.claimNext(nil, "worker-a", "group-a"); job == nil || job.ID != jobID {
+		t.Fatalf("expected to claim %s", jobID)
+	}
+	expectEventType(t, events, "status")
+
+	// Heartbeats keep the lease alive past its original deadline.
+	firstClaim := claimToken(reg, jobID)
+	heartbeat := `{"claim_token":"` + firstClaim + `"}`
+	for i := 0; i < 3; i++ {
+		time.Sleep(25 * time.Millisecond)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/heartbeat", strings.NewReader(heartbeat)))
+		if resp.Code != http.StatusOK {
+			t.Fatalf("heartbeat status = %d, want %d", resp.Code, http.StatusOK)
+		}
+	}
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/payload", strings.NewReader(`{}`)))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("payload status = %d, want %d", resp.Code, http.StatusOK)
+	}
+	expectEventType(t, events, "payload")
+	expectEventType(t, events, "status")
+	if view, _, _ := reg.snapshot(jobID); view.Status != StatusAwaitingPayload {
+		t.Fatalf("status after heartbeats = %q, want %q", view.Status, StatusAwaitingPayload)
+	}
+
+	ev := expectEventType(t, events, "status")
+	view := ev.Data.(JobView)
+	if view.Status != StatusQueued || view.Attempts != 1 || view.LastWorkerID != "worker-a" || view.ClaimedWorkerID != "" {
+		t.Fatalf("unexpected view after first expiry: %+v", view)
+	}
+
+	if n := tr.StateSnapshot().Summary.ActiveChannels; n != 0 {
+		t.Fatalf("active channels after requeue = %d, want 0", n)
+	}
+
+	// The first worker's claim ended with its lease.
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/heartbeat", strings.NewReader(heartbeat)))
+	if resp.Code != http.StatusConflict {
+		t.Fatalf("heartbeat on requeued job = %d, want %d", resp.Code, http.StatusConflict)
+	}
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/status", strings.NewReader(`{"claim_token":"`+claimToken(reg, jobID)+`","state":"running"}`)))
+	if resp.Code != http.StatusConflict {
+		t.Fatalf("status update on requeued job = %d, want %d", resp.Code, http.StatusConflict)
+	}
+
+	if job := reg.claimNext(nil, "worker-b", ""); job == nil || job.ID != jobID {
+		t.Fatalf("expected to reclaim %s", jobID)
+	}
+	expectEventType(t, events, "status")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/status", strings.NewReader(`{"claim_token":"`+firstClaim+`","state":"completed"}`)))
+	if resp.Code != http.StatusConflict {
+		t.Fatalf("stale claim status update = %d, want %d", resp.Code, http.StatusConflict)
+	}
+	ev = expectEventType(t, events, "status")
+	view = ev.Data.(JobView)
+	if view.Status != StatusFailed || view.Error == nil || view.Error.Code != "lease_expired" || view.Attempts != 2 || view.LastWorkerID != "worker-b" {
+		t.Fatalf("unexpected view after final expiry: %+v", view)
+	}
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/heartbeat", strings.NewReader(`{"claim_token":"`+claimToken(reg, jobID)+`"}`)))
+	if resp.Code != http.StatusConflict {
+		t.Fatalf("heartbeat on failed job = %d, want %d", resp.Code, http.StatusConflict)
+	}
+}
+
+func TestLeaseExpiryFollowsJobRetryPolicy(t *testing.T) {
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	reg.SetLease(20*time.Millisecond, 5)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+	jobID := createTestJob(t, router, `{"type":"test","retry":{"max_attempts":2,"backoff_seconds":60}}`)
+	events := reg.subscribe(jobID)
+	defer reg.unsubscribe(jobID, events)
+
+	reg.claimNext(nil, "worker-a", "")
+	expectEventType(t, events, "status")
+	view := expectEventType(t, events, "status").Data.(JobView)
+	if view.Status != StatusQueued || view.Error == nil || view.Error.Code != "lease_expired" || view.NotBefore == "" {
+		t.Fatalf("expected the expired job to be retried after its backoff, got %+v", view)
+	}
+
+	reg.mu.Lock()
+	reg.jobs[jobID].NotBefore = time.Time{}
+	reg.mu.Unlock()
+	if job := reg.claimNext(nil, "worker-b", ""); job == nil || job.ID != jobID {
+		t.Fatalf("expected to reclaim %s", jobID)
+	}
+	expectEventType(t, events, "status")
+	view = expectEventType(t, events, "status").Data.(JobView)
+	if view.Status != StatusFailed || view.Error == nil || view.Error.Code != "lease_expired" || view.DeadLetteredAt == "" {
+		t.Fatalf("expected the job to be dead-lettered after its own max_attempts, got %+v", view)
+	}
+}
+
+func TestMissingClaimTokenIsAcceptedUntilTheJobIsReclaimed(t *testing.T) {
+	post := func(router http.Handler, path, body string) int {
+		t.Helper()
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return resp.Code
+	}
+
+	// Without leases, workers that do not send a token keep working.
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+	jobID := createTestJob(t, router, `{"type":"test"}`)
+	reg.claimNext(nil, "worker-a", "")
+	if code := post(router, "/jobs/"+jobID+"/status", `{"state":"running"}`); code != http.StatusOK {
+		t.Fatalf("status without token = %d, want %d", code, http.StatusOK)
+	}
+	if code := post(router, "/jobs/"+jobID+"/status", `{"claim_token":"other","state":"completed"}`); code != http.StatusConflict {
+		t.Fatalf("status with a wrong token = %d, want %d", code, http.StatusConflict)
+	}
+
+	// With leases, a missing token is accepted on the first attempt only.
+	reg = NewRegistry(transfer.NewRegistry(0), 0, 0)
+	reg.SetLease(50*time.Millisecond, 2)
+	router = chi.NewRouter()
+	reg.RegisterRoutes(router)
+	jobID = createTestJob(t, router, `{"type":"test"}`)
+	reg.claimNext(nil, "worker-a", "")
+	if code := post(router, "/jobs/"+jobID+"/heartbeat", `{}`); code != http.StatusOK {
+		t.Fatalf("heartbeat without token = %d, want %d", code, http.StatusOK)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for reg.claimNext(nil, "worker-b", "") == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("job was not requeued after its lease expired")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if code := post(router, "/jobs/"+jobID+"/status", `{"state":"completed"}`); code != http.StatusConflict {
+		t.Fatalf("status without token after reclaim = %d, want %d", code, http.StatusConflict)
+	}
+	if code := post(router, "/jobs/"+jobID+"/status", `{"claim_token":"`+claimToken(reg, jobID)+`","state":"completed"}`); code != http.StatusOK {
+		t.Fatalf("status with the current token = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestFailedJobsRetryWithBackoffThenDeadLetter(t *testing.T) {
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+	fail := func(jobID, code string) map[string]any {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/status", strings.NewReader(`{"claim_token":"`+claimToken(reg, jobID)+`","state":"failed","error":{"code":"`+code+`","message":"boom"}}`))
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		var body map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return body
+	}
+
+	jobID := createTestJob(t, router, `{"type":"test","retry":{"max_attempts":2,"backoff_seconds":0.05,"retry_on":["transient"]}}`)
+	reg.claimNext(nil, "worker-a", "")
+	if body := fail(jobID, "transient"); body["status"] != StatusQueued {
+		t.Fatalf("retryable failure status = %v, want %q", body["status"], StatusQueued)
+	}
+	view, _, _ := reg.snapshot(jobID)
+	if view.NotBefore == "" || view.Error == nil || view.Error.Code != "transient" {
+		t.Fatalf("expected backoff and last error on requeued job, got %+v", view)
+	}
+	if job := reg.claimNext(nil, "worker-a", ""); job != nil {
+		t.Fatalf("job claimed before backoff elapsed")
+	}
+	<-reg.notify // consume the pending signal from creation and re-queue
+	select {
+	case <-reg.notify:
 	case <-time.After(2 * time.Second):
-		t.Fatalf("timed out waiting for event %q", typ)
-		return Event{}
+		t.Fatalf("expected workers to be signaled when backoff elapsed")
+	}
+	if job := reg.claimNext(nil, "worker-b", ""); job == nil || job.ID != jobID {
+		t.Fatalf("expected retry to be claimable after backoff")
+	}
+	if body := fail(jobID, "transient"); body["status"] != StatusFailed {
+		t.Fatalf("exhausted failure status = %v, want %q", body["status"], StatusFailed)
+	}
+
+	otherID := createTestJob(t, router, `{"type":"test","retry":{"max_attempts":5,"retry_on":["transient"]}}`)
+	reg.claimNext(nil, "worker-a", "")
+	if body := fail(otherID, "fatal"); body["status"] != StatusFailed {
+		t.Fatalf("non-retryable failure status = %v, want %q", body["status"], StatusFailed)
+	}
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/jobs/dead-letter", nil))
+	var list struct {
+		Jobs []JobView `json:"jobs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("decode dead letter: %v", err)
+	}
+	if len(list.Jobs) != 1 || list.Jobs[0].ID != jobID || list.Jobs[0].Attempts != 2 || list.Jobs[0].DeadLetteredAt == "" {
+		t.Fatalf("unexpected dead letter list %+v", list.Jobs)
+	}
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/dead-letter/"+otherID+"/redrive", nil))
+	if resp.Code != http.StatusNotFound {
+		t.Fatalf("redrive of non dead-lettered job = %d, want %d", resp.Code, http.StatusNotFound)
+	}
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/dead-letter/"+jobID+"/redrive", nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("redrive status = %d, want %d", resp.Code, http.StatusOK)
+	}
+	view, pos, _ := reg.snapshot(jobID)
+	if view.Status != StatusQueued || view.Attempts != 0 || view.DeadLetteredAt != "" || pos != 1 {
+		t.Fatalf("unexpected redriven job %+v at %d", view, pos)
+	}
+}
+
+func TestStatusUpdateRejectsServerManagedStates(t *testing.T) {
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+	jobID := createTestJob(t, router, `{"type":"test"}`)
+	reg.claimNext(nil, "worker", "")
+	for _, state := range []string{StatusQueued, StatusBlocked, StatusScheduled, StatusAwaitingResult, StatusCanceled, "bogus"} {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/status", strings.NewReader(`{"claim_token":"`+claimToken(reg, jobID)+`","state":"`+state+`"}`)))
+		if resp.Code != http.StatusBadRequest {
+			t.Fatalf("status %q = %d, want %d", state, resp.Code, http.StatusBadRequest)
+		}
+	}
+	if view, pos, _ := reg.snapshot(jobID); view.Status != StatusClaimed || pos != 0 {
+		t.Fatalf("rejected updates must leave the claim alone, got %s at %d", view.Status, pos)
+	}
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/status", strings.NewReader(`{"claim_token":"`+claimToken(reg, jobID)+`","state":"running"}`)))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("running status = %d, want %d", resp.Code, http.StatusOK)
+	}
+}
+
+func TestRedriveRevivesCanceledDependentsAndRearmsClientTimer(t *testing.T) {
+	reg := NewRegistry(transfer.NewRegistry(0), 0, time.Hour)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+	fail := func(jobID string) {
+		t.Helper()
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/status", strings.NewReader(`{"claim_token":"`+claimToken(reg, jobID)+`","state":"failed"}`)))
+		if resp.Code != http.StatusOK {
+			t.Fatalf("fail status = %d", resp.Code)
+		}
+	}
+	status := func(jobID string) string {
+		t.Helper()
+		view, _, _ := reg.snapshot(jobID)
+		return view.Status
+	}
+
+	root := createTestJob(t, router, `{"type":"test","retry":{"max_attempts":1}}`)
+	other := createTestJob(t, router, `{"type":"test"}`)
+	child := createTestJob(t, router, `{"type":"test","depends_on":["`+root+`"]}`)
+	grandchild := createTestJob(t, router, `{"type":"test","depends_on":["`+child+`"]}`)
+	both := createTestJob(t, router, `{"type":"test","depends_on":["`+root+`","`+other+`"]}`)
+	if job := reg.claimNext(nil, "worker", ""); job == nil || job.ID != root {
+		t.Fatalf("expected to claim %s, got %+v", root, job)
+	}
+	fail(root)
+	if job := reg.claimNext(nil, "worker", ""); job == nil || job.ID != other {
+		t.Fatalf("expected to claim %s, got %+v", other, job)
+	}
+	fail(other)
+	for _, id := range []string{child, grandchild, both} {
+		if got := status(id); got != StatusCanceled {
+			t.Fatalf("expected %s to be canceled by the failure, got %s", id, got)
+		}
+	}
+	reg.mu.Lock()
+	armed := reg.clientTimers[root] != nil
+	reg.mu.Unlock()
+	if armed {
+		t.Fatalf("expected the client timer to stop when the job failed")
+	}
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/dead-letter/"+root+"/redrive", nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("redrive status = %d, want %d", resp.Code, http.StatusOK)
+	}
+	for _, id := range []string{child, grandchild} {
+		if view, _, _ := reg.snapshot(id); view.Status != StatusBlocked || view.Error != nil {
+			t.Fatalf("expected %s to be blocked on the redriven job again, got %+v", id, view)
+		}
+	}
+	if got := status(both); got != StatusCanceled {
+		t.Fatalf("a dependent with another failed parent must stay canceled, got %s", got)
+	}
+	reg.mu.Lock()
+	armed = reg.clientTimers[root] != nil
+	reg.mu.Unlock()
+	if !armed {
+		t.Fatalf("expected redrive to re-arm the client timer")
+	}
+
+	if job := reg.claimNext(nil, "worker", ""); job == nil || job.ID != root {
+		t.Fatalf("expected to claim the redriven job, got %+v", job)
+	}
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+root+"/status", strings.NewReader(`{"claim_token":"`+claimToken(reg, root)+`","state":"completed"}`)))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("complete status = %d", resp.Code)
+	}
+	if got := status(child); got != StatusQueued {
+		t.Fatalf("expected %s to be released by the completed parent, got %s", child, got)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	base := time.Date(2026, time.January, 30, 10, 17, 30, 0, time.UTC) // a Friday
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 1, 30, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 1, 30, 10, 30, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2026, 1, 31, 2, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2026, 2, 2, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 15 * 0", time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 1, 30, 11, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		spec, err := parseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", tc.expr, err)
+		}
+		if got := spec.next(base); !got.Equal(tc.want) {
+			t.Fatalf("next(%q) = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+	for _, bad := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := parseCron(bad); err == nil {
+			t.Fatalf("parseCron(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestDelayedJobIsNotClaimableUntilDue(t *testing.T) {
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 20*time.Millisecond)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+	due := time.Now().Add(100 * time.Millisecond).UTC().Format(time.RFC3339Nano)
+	jobID := createTestJob(t, router, `{"type":"test","not_before":"`+due+`"}`)
+	if job := reg.claimNext(nil, "worker", ""); job != nil {
+		t.Fatalf("delayed job claimed before it was due")
+	}
+	if summary := reg.StateSnapshot().Summary; summary.ScheduledJobs != 1 {
+		t.Fatalf("scheduled jobs = %d, want 1", summary.ScheduledJobs)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if job := reg.claimNext(nil, "worker", ""); job == nil || job.ID != jobID {
+		t.Fatalf("expected delayed job to be claimable once due (and not expired for client inactivity)")
+	}
+}
+
+func TestRecurringScheduleQueuesInstancesUntilCanceled(t *testing.T) {
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"type":"test","schedule":"bogus"}`)))
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("invalid schedule status = %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+
+	defID := createTestJob(t, router, `{"type":"nightly","priority":2,"metadata":{"k":"v"},"schedule":"0 2 * * *"}`)
+	view, _, _ := reg.snapshot(defID)
+	next, err := time.Parse(time.RFC3339, view.NotBefore)
+	if err != nil || view.Status != StatusScheduled || next.Hour() != 2 || next.Minute() != 0 || !next.After(time.Now()) {
+		t.Fatalf("unexpected definition %+v", view)
+	}
+	if job := reg.claimNext(nil, "worker", ""); job != nil {
+		t.Fatalf("schedule definition must not be claimable")
+	}
+
+	reg.runSchedule(defID)
+	view, _, _ = reg.snapshot(defID)
+	if view.LastJobID == "" || view.Status != StatusScheduled {
+		t.Fatalf("expected an instance to be recorded on %+v", view)
+	}
+	job := reg.claimNext(nil, "worker", "")
+	if job == nil || job.ID != view.LastJobID || job.ScheduleID != defID || job.Type != "nightly" || job.Priority != 2 || job.Metadata["k"] != "v" {
+		t.Fatalf("unexpected instance %+v", job)
+	}
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+defID+"/cancel", nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("cancel status = %d", resp.Code)
+	}
+	reg.mu.Lock()
+	_, armed := reg.schedTimers[defID]
+	reg.mu.Unlock()
+	if armed {
+		t.Fatalf("expected canceled schedule timer to be stopped")
+	}
+	reg.runSchedule(defID)
+	if after, _, _ := reg.snapshot(defID); after.LastJobID != view.LastJobID {
+		t.Fatalf("canceled schedule must not queue new instances")
+	}
+}
+
+func TestDependenciesReleaseOrCancelWorkflowJobs(t *testing.T) {
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"type":"test","depends_on":["missing"]}`)))
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("unknown dependency status = %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+
+	root := createTestJob(t, router, `{"type":"extract","workflow_id":"wf-1"}`)
+	left := createTestJob(t, router, `{"type":"transform","depends_on":["`+root+`"]}`)
+	right := createTestJob(t, router, `{"type":"index","depends_on":["`+root+`"]}`)
+	leaf := createTestJob(t, router, `{"type":"publish","depends_on":["`+left+`","`+right+`"]}`)
+	for _, id := range []string{left, right, leaf} {
+		if view, _, _ := reg.snapshot(id); view.Status != StatusBlocked || view.WorkflowID != "wf-1" {
+			t.Fatalf("expected blocked job in wf-1, got %+v", view)
+		}
+	}
+	events := reg.subscribe(workflowKey("wf-1"))
+	defer reg.unsubscribe(workflowKey("wf-1"), events)
+
+	if job := reg.claimNext(nil, "worker", ""); job == nil || job.ID != root {
+		t.Fatalf("expected only the root job to be claimable, got %+v", job)
+	}
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+root+"/status", strings.NewReader(`{"claim_token":"`+claimToken(reg, root)+`","state":"completed"}`)))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("complete status = %d", resp.Code)
+	}
+	expectEventType(t, events, "status")
+	for _, id := range []string{left, right} {
+		if view, _, _ := reg.snapshot(id); view.Status != StatusQueued {
+			t.Fatalf("expected %s to be released, got %s", id, view.Status)
+		}
+	}
+	if view, _, _ := reg.snapshot(leaf); view.Status != StatusBlocked {
+		t.Fatalf("leaf must wait for both parents, got %s", view.Status)
+	}
+
+	if job := reg.claimNext([]string{"transform"}, "worker", ""); job == nil || job.ID != left {
+		t.Fatalf("expected to claim %s, got %+v", left, job)
+	}
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+left+"/status", strings.NewReader(`{"claim_token":"`+claimToken(reg, left)+`","state":"failed"}`)))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("fail status = %d", resp.Code)
+	}
+	view, _, _ := reg.snapshot(leaf)
+	if view.Status != StatusCanceled || view.Error == nil || view.Error.Code != "dependency_failed" {
+		t.Fatalf("expected leaf to be canceled by its failed parent, got %+v", view)
+	}
+
+	workflow := func() WorkflowView {
+		t.Helper()
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/jobs/workflows/wf-1", nil))
+		if resp.Code != http.StatusOK {
+			t.Fatalf("workflow status = %d", resp.Code)
+		}
+		var wf WorkflowView
+		if err := json.NewDecoder(resp.Body).Decode(&wf); err != nil {
+			t.Fatalf("decode workflow: %v", err)
+		}
+		return wf
+	}
+	if wf := workflow(); wf.Status != StatusRunning {
+		t.Fatalf("workflow must keep running while %s is queued, got %s", right, wf.Status)
+	}
+
+	if job := reg.claimNext([]string{"index"}, "worker", ""); job == nil || job.ID != right {
+		t.Fatalf("expected to claim %s, got %+v", right, job)
+	}
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+right+"/status", strings.NewReader(`{"claim_token":"`+claimToken(reg, right)+`","state":"completed"}`)))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("complete status = %d", resp.Code)
+	}
+	if wf := workflow(); wf.Status != StatusFailed || len(wf.Jobs) != 4 || wf.Jobs[0].ID != root || len(wf.Jobs[3].DependsOn) != 2 {
+		t.Fatalf("unexpected workflow %+v", wf)
+	}
+}
+
+func TestListJobsFiltersAndPaginates(t *testing.T) {
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	reg.SetIdentity(func(req *http.Request) string { return req.Header.Get("X-Test-Identity") })
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		body := fmt.Sprintf(`{"type":"asr","priority":%d,"metadata":{"batch":"b%d"}}`, i, i%2)
+		req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(body))
+		req.Header.Set("X-Test-Identity", "alice")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		var created struct {
+			JobID string `json:"job_id"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+			t.Fatalf("decode create: %v", err)
+		}
+		ids = append(ids, created.JobID)
+	}
+	createTestJob(t, router, `{"type":"ocr","metadata":{"batch":"b0"}}`)
+
+	list := func(query string, identity string) ([]JobView, string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/jobs?"+query, nil)
+		req.Header.Set("X-Test-Identity", identity)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("list %q status = %d", query, resp.Code)
+		}
+		var page struct {
+			Jobs       []JobView `json:"jobs"`
+			NextCursor string    `json:"next_cursor"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatalf("decode list: %v", err)
+		}
+		return page.Jobs, page.NextCursor
+	}
+
+	if jobs, _ := list("", ""); len(jobs) != 6 {
+		t.Fatalf("unfiltered list returned %d jobs, want 6", len(jobs))
+	}
+	if jobs, _ := list("type=asr&metadata=batch:b0", "alice"); len(jobs) != 3 {
+		t.Fatalf("filtered list returned %d jobs, want 3", len(jobs))
+	}
+	if jobs, _ := list("", "bob"); len(jobs) != 0 {
+		t.Fatalf("expected no jobs for another identity, got %d", len(jobs))
+	}
+
+	var got []string
+	cursor := ""
+	for page := 0; page < 3; page++ {
+		jobs, next := list("type=asr&sort=-priority&limit=2&cursor="+cursor, "alice")
+		for _, job := range jobs {
+			got = append(got, job.ID)
+		}
+		cursor = next
+		if cursor == "" {
+			break
+		}
+	}
+	want := []string{ids[4], ids[3], ids[2], ids[1], ids[0]}
+	if strings.Join(got, ",") != strings.Join(want, ",") || cursor != "" {
+		t.Fatalf("paginated ids = %v, want %v (cursor %q)", got, want, cursor)
+	}
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/jobs?sort=bogus", nil))
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("invalid sort status = %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+}
+
+func TestRetainedResultsCanBeFetchedLater(t *testing.T) {
+	tr := transfer.NewRegistry(0)
+	reg := NewRegistry(tr, 0, 0)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"type":"test","retain_results":true}`)))
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("retention without spool status = %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+
+	spool, err := NewResultSpool(t.TempDir(), time.Hour, 8, 0)
+	if err != nil {
+		t.Fatalf("NewResultSpool: %v", err)
+	}
+	reg.SetResultSpool(spool)
+	jobID := createTestJob(t, router, `{"type":"test","retain_results":true}`)
+	events := reg.subscribe(jobID)
+	defer reg.unsubscribe(jobID, events)
+	if job := reg.claimNext(nil, "worker", ""); job == nil {
+		t.Fatalf("expected job to be claimed")
+	}
+
+	upload := func(key, body string) int {
+		t.Helper()
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/result", strings.NewReader(`{"key":"`+key+`"}`)))
+		if resp.Code != http.StatusOK {
+			t.Fatalf("result request status = %d", resp.Code)
+		}
+		var created struct {
+			ChannelID string `json:"channel_id"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+			t.Fatalf("decode result request: %v", err)
+		}
+		resp = httptest.NewRecorder()
+		tr.HandleWriter(resp, httptest.NewRequest(http.MethodPost, "/api/transfer/"+created.ChannelID, strings.NewReader(body)), created.ChannelID)
+		return resp.Code
+	}
+
+	if code := upload("out", "hello"); code != http.StatusOK {
+		t.Fatalf("upload status = %d", code)
+	}
+	expectEventType(t, events, "status") // claimed
+	expectEventType(t, events, "status") // awaiting_result
+	ev := expectEventType(t, events, "result")
+	if info := ev.Data.(*TransferInfo); info.URL != "/api/jobs/"+jobID+"/results/out" || info.Size != 5 ||
+		info.SHA256 != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Fatalf("unexpected result event %+v", ev.Data)
+	}
+	if code := upload("big", "more than eight bytes"); code != http.StatusBadGateway {
+		t.Fatalf("oversized upload status = %d, want %d", code, http.StatusBadGateway)
+	}
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/jobs/"+jobID+"/results/out", nil))
+	if resp.Code != http.StatusOK || resp.Body.String() != "hello" {
+		t.Fatalf("fetch result = %d %q", resp.Code, resp.Body.String())
+	}
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/jobs/"+jobID+"/results/big", nil))
+	if resp.Code != http.StatusNotFound {
+		t.Fatalf("oversized result status = %d, want %d", resp.Code, http.StatusNotFound)
+	}
+}
+
+func TestWebhookDeliversSignedStatusChanges(t *testing.T) {
+	type delivery struct {
+		event string
+		body  []byte
+	}
+	received := make(chan delivery, 16)
+	var calls int32
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		want := "sha256=" + signWebhook("s3cret", req.Header.Get("X-Nfrx-Timestamp"), body)
+		if req.Header.Get("X-Nfrx-Signature") != want {
+			t.Errorf("bad signature %q", req.Header.Get("X-Nfrx-Signature"))
+		}
+		received <- delivery{event: req.Header.Get("X-Nfrx-Event"), body: body}
+	}))
+	defer hook.Close()
+
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	reg.SetWebhookBackoff(time.Millisecond)
+	if err := reg.SetWebhookAllowedNetworks([]string{"127.0.0.0/8"}); err != nil {
+		t.Fatalf("SetWebhookAllowedNetworks: %v", err)
+	}
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"type":"test","webhook":{"url":"ftp://x"}}`)))
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("invalid webhook status = %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+
+	jobID := createTestJob(t, router, `{"type":"test","webhook":{"url":"`+hook.URL+`","secret":"s3cret"}}`)
+	if job := reg.claimNext(nil, "worker", ""); job == nil {
+		t.Fatalf("expected job to be claimed")
+	}
+	for _, state := range []string{"running", "running", "completed"} {
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/status", strings.NewReader(`{"claim_token":"`+claimToken(reg, jobID)+`","state":"`+state+`"}`)))
+		if resp.Code != http.StatusOK {
+			t.Fatalf("status update %s = %d", state, resp.Code)
+		}
+	}
+
+	for _, want := range []string{"job.queued", "job.claimed", "job.running", "job.completed"} {
+		select {
+		case d := <-received:
+			if d.event != want {
+				t.Fatalf("webhook event = %q, want %q", d.event, want)
+			}
+			var payload struct {
+				Job JobView `json:"job"`
+			}
+			if err := json.Unmarshal(d.body, &payload); err != nil || payload.Job.ID != jobID {
+				t.Fatalf("unexpected webhook body %s", d.body)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/jobs/"+jobID+"/webhook/test", nil))
+	if resp.Code != http.StatusAccepted {
+		t.Fatalf("test webhook status = %d", resp.Code)
+	}
+	select {
+	case d := <-received:
+		if d.event != "job.completed" {
+			t.Fatalf("re-sent event = %q", d.event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for re-sent webhook")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		view, _, _ := reg.snapshot(jobID)
+		ds := view.Webhook.Deliveries
+		if len(ds) == 5 && ds[4].Status == "delivered" {
+			if ds[0].Attempts != 2 || ds[0].ResponseCode != http.StatusOK {
+				t.Fatalf("expected first delivery to succeed on retry, got %+v", ds[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected deliveries %+v", ds)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebhookRefusesNonPublicAddresses(t *testing.T) {
+	var calls int32
+	hook := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer hook.Close()
+
+	for _, addr := range []string{"127.0.0.1", "10.1.2.3", "169.254.169.254", "100.64.0.1", "::1", "fd00:ec2::254"} {
+		if webhookAddrAllowed(net.ParseIP(addr), nil) {
+			t.Fatalf("expected %s to be refused", addr)
+		}
+	}
+	if !webhookAddrAllowed(net.ParseIP("93.184.216.34"), nil) {
+		t.Fatalf("expected a public address to be allowed")
+	}
+	if err := NewRegistry(transfer.NewRegistry(0), 0, 0).SetWebhookAllowedNetworks([]string{"not-an-ip"}); err == nil {
+		t.Fatalf("expected an invalid network to be rejected")
+	}
+
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	reg.SetWebhookBackoff(time.Millisecond)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+	jobID := createTestJob(t, router, `{"type":"test","webhook":{"url":"`+hook.URL+`"}}`)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		view, _, _ := reg.snapshot(jobID)
+		if ds := view.Webhook.Deliveries; len(ds) == 1 && ds[0].Status == "failed" {
+			if ds[0].Attempts != 1 || !strings.Contains(ds[0].Error, errWebhookDestination.Error()) {
+				t.Fatalf("expected a single refused attempt, got %+v", ds[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected deliveries %+v", view.Webhook.Deliveries)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Fatalf("webhook server received %d requests, want 0", n)
+	}
+}
+
+func TestRestoreResumesPendingWebhookDeliveries(t *testing.T) {
+	received := make(chan string, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		received <- req.Header.Get("X-Nfrx-Delivery")
+	}))
+	defer hook.Close()
+
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	now := time.Now()
+	rec := Record{
+		ID:                "job-hook",
+		Type:              "test",
+		Status:            StatusCompleted,
+		Webhook:           &Webhook{URL: hook.URL},
+		WebhookDeliveries: []WebhookDelivery{{ID: "d-1", Event: "job.completed", Status: "pending"}},
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := store.SaveJob(rec); err != nil {
+		t.Fatalf("SaveJob: %v", err)
+	}
+
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	reg.SetStore(store)
+	if err := reg.SetWebhookAllowedNetworks([]string{"127.0.0.1"}); err != nil {
+		t.Fatalf("SetWebhookAllowedNetworks: %v", err)
+	}
+	if _, err := reg.Restore(RecoveryRequeue); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	select {
+	case id := <-received:
+		if id != "d-1" {
+			t.Fatalf("delivery id = %q, want d-1", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for the resumed delivery")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		view, _, _ := reg.snapshot("job-hook")
+		if ds := view.Webhook.Deliveries; len(ds) == 1 && ds[0].Status == "delivered" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected deliveries %+v", view.Webhook.Deliveries)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPartialResultsReplayFromLastEventID(t *testing.T) {
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	reg.SetPartialHistory(3)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+	srv := httptest.NewServer(router)
+	// Registered first so it runs after the event streams are closed.
+	t.Cleanup(srv.Close)
+
+	id := createTestJob(t, router, `{"type":"asr"}`)
+	appendPartial := func(text string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/jobs/"+id+"/partial", strings.NewReader(`{"data":{"text":"`+text+`"}}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := appendPartial("early"); code != http.StatusConflict {
+		t.Fatalf("append to queued job = %d, want %d", code, http.StatusConflict)
+	}
+	if job := reg.claimNext(nil, "", ""); job == nil || job.ID != id {
+		t.Fatalf("claim failed: %#v", job)
+	}
+	for i := 1; i <= 5; i++ {
+		if code := appendPartial(fmt.Sprint("segment ", i)); code != http.StatusOK {
+			t.Fatalf("append %d = %d", i, code)
+		}
+	}
+
+	open := func(lastEventID string) *bufio.Reader {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/jobs/"+id+"/events", nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("events: %v", err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		r := bufio.NewReader(resp.Body)
+		if ev := readSSE(t, r); ev.Type != "status" {
+			t.Fatalf("first event = %+v, want status", ev)
+		}
+		return r
+	}
+
+	// Resuming after 3 replays only what the client has not seen.
+	resumed := open("3")
+	for _, want := range []string{"4", "5"} {
+		if ev := readSSE(t, resumed); ev.Type != "partial" || ev.ID != want {
+			t.Fatalf("replayed %+v, want partial %s", ev, want)
+		}
+	}
+
+	// A new subscriber gets the bounded buffer and learns what was dropped.
+	fresh := open("")
+	if ev := readSSE(t, fresh); ev.Type != "partial_gap" || ev.Data != `{"from":1,"to":2}` {
+		t.Fatalf("expected gap for 1-2, got %+v", ev)
+	}
+	for _, want := range []string{"3", "4", "5"} {
+		if ev := readSSE(t, fresh); ev.ID != want {
+			t.Fatalf("replayed %+v, want partial %s", ev, want)
+		}
+	}
+
+	// Live partials follow the replay in order.
+	if code := appendPartial("live"); code != http.StatusOK {
+		t.Fatalf("append live = %d", code)
+	}
+	for _, r := range []*bufio.Reader{resumed, fresh} {
+		ev := readSSE(t, r)
+		var p Partial
+		if err := json.Unmarshal([]byte(ev.Data), &p); err != nil || ev.ID != "6" || p.Seq != 6 {
+			t.Fatalf("live event %+v (%v)", ev, err)
+		}
+		if data, _ := json.Marshal(p.Data); string(data) != `{"text":"live"}` {
+			t.Fatalf("live data = %s", data)
+		}
+	}
+}
+
+func TestPartialFromStaleClaimIsRefused(t *testing.T) {
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	reg.SetLease(50*time.Millisecond, 3)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+	id := createTestJob(t, router, `{"type":"asr"}`)
+	reg.claimNext(nil, "worker-a", "")
+	first := claimToken(reg, id)
+	appendPartial := func(token string) int {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/jobs/"+id+"/partial", strings.NewReader(`{"claim_token":"`+token+`","data":"x"}`)))
+		return w.Code
+	}
+	if code := appendPartial(first); code != http.StatusOK {
+		t.Fatalf("append with the current token = %d, want %d", code, http.StatusOK)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for reg.claimNext(nil, "worker-b", "") == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("job was not requeued after its lease expired")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	reg.mu.Lock()
+	lease := reg.jobs[id].LeaseExpiresAt
+	reg.mu.Unlock()
+	if code := appendPartial(first); code != http.StatusConflict {
+		t.Fatalf("append from the earlier claim = %d, want %d", code, http.StatusConflict)
+	}
+	if code := appendPartial(""); code != http.StatusConflict {
+		t.Fatalf("append without token after reclaim = %d, want %d", code, http.StatusConflict)
+	}
+	reg.mu.Lock()
+	extended := !reg.jobs[id].LeaseExpiresAt.Equal(lease)
+	reg.mu.Unlock()
+	if extended {
+		t.Fatalf("a refused append must not extend the lease")
+	}
+	if code := appendPartial(claimToken(reg, id)); code != http.StatusOK {
+		t.Fatalf("append from the new claim = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestPartialSequenceSurvivesRestore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	appendPartial := func(router chi.Router, id string) int64 {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/jobs/"+id+"/partial", strings.NewReader(`{"data":"x"}`)))
+		var body struct {
+			Seq int64 `json:"seq"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("append = %d (%v)", w.Code, err)
+		}
+		return body.Seq
+	}
+
+	first := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	first.SetStore(store)
+	router := chi.NewRouter()
+	first.RegisterRoutes(router)
+	id := createTestJob(t, router, `{"type":"asr"}`)
+	first.claimNext(nil, "worker", "")
+	appendPartial(router, id)
+	appendPartial(router, id)
+
+	second := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	second.SetStore(store)
+	if _, err := second.Restore(RecoveryRequeue); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	router = chi.NewRouter()
+	second.RegisterRoutes(router)
+
+	// A client that saw partial 1 learns that partial 2 was lost.
+	if partials, gapTo := second.partialsAfter(id, 1, 0); len(partials) != 0 || gapTo != 2 {
+		t.Fatalf("partialsAfter = %v, gap to %d; want a gap to 2", partials, gapTo)
+	}
+	second.claimNext(nil, "worker", "")
+	if seq := appendPartial(router, id); seq != 3 {
+		t.Fatalf("seq after restore = %d, want 3", seq)
+	}
+}
+
+func TestTrackedJobsReportProgressAndCancel(t *testing.T) {
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 10*time.Millisecond)
+	router := chi.NewRouter()
+	reg.RegisterClientRoutes(router)
+	get := func(id string) JobView {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/"+id, nil))
+		var view JobView
+		if err := json.NewDecoder(w.Body).Decode(&view); err != nil {
+			t.Fatalf("decode job: %v", err)
+		}
+		return view
+	}
+
+	done := reg.Track("batch", map[string]any{"batch_id": "b1"}, func() { t.Error("finished job was canceled") })
+	reg.Progress(done, map[string]any{"completed": 1})
+	if view := get(done); view.Status != StatusRunning || view.Progress["completed"] != float64(1) {
+		t.Fatalf("unexpected tracked job %+v", view)
+	}
+	if claimed := reg.claimNext([]string{"batch"}, "w1", ""); claimed != nil {
+		t.Fatalf("tracked job was claimed: %+v", claimed)
+	}
+	reg.Finish(done, StatusFailed, "batch_expired", "too slow")
+	if view := get(done); view.Status != StatusFailed || view.Error == nil || view.Error.Code != "batch_expired" {
+		t.Fatalf("unexpected finished job %+v", view)
+	}
+
+	canceled := make(chan struct{})
+	id := reg.Track("batch", nil, func() { close(canceled) })
+	// Tracked jobs are not tied to a client following their events.
+	reg.onClientDisconnect(id)
+	time.Sleep(30 * time.Millisecond)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/jobs/"+id+"/cancel", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("cancel status = %d", w.Code)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("cancel callback not called")
+	}
+	reg.Finish(id, StatusCompleted, "", "")
+	if view := get(id); view.Status != StatusCanceled || view.Error != nil || view.CancelState != "" {
+		t.Fatalf("unexpected canceled job %+v", view)
+	}
+}
+
+type sseEvent struct {
+	ID   string
+	Type string
+	Data string
+}
+
+func readSSE(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if ev.Type != "" || ev.Data != "" {
+				return ev
+			}
+		case strings.HasPrefix(line, "id: "):
+			ev.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.Type = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.Data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func createTestJob(t *testing.T, router http.Handler, body string) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("create status = %d, want %d", resp.Code, http.StatusOK)
+	}
+	var created struct {
+		JobID string `json:"job_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("decode create: %v", err)
+	}
+	return created.JobID
+}
+
+// claimToken returns the token of the current claim on jobID.
+func claimToken(reg *Registry, jobID string) string {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return reg.jobs[jobID].ClaimToken
+}
+
+func expectEventType(t *testing.T, ch chan Event, typ string) Event {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		if ev.Type != typ {
+			t.Fatalf("event type = %q, want %q", ev.Type, typ)
+		}
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for event %q", typ)
+		return Event{}
+	}
+}
+
+type fakeRelay struct {
+	mu      sync.Mutex
+	method  string
+	path    string
+	headers map[string]string
+	body    []byte
+	result  string
+}
+
+func (f *fakeRelay) HasWorker(id string) bool { return id == "agent-1" }
+
+func (f *fakeRelay) Relay(ctx context.Context, workerID, method, path string, headers map[string]string, body io.Reader, dst io.Writer) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = io.ReadAll(body); err != nil {
+			return err
+		}
+	}
+	f.mu.Lock()
+	f.method, f.path, f.headers, f.body = method, path, headers, data
+	f.mu.Unlock()
+	_, err := io.WriteString(dst, f.result)
+	return err
+}
+
+func TestTransferRequestsRelayThroughAgent(t *testing.T) {
+	tr := transfer.NewRegistry(0)
+	reg := NewRegistry(tr, 0, 0)
+	relay := &fakeRelay{result: "from the agent"}
+	reg.AddTransferRelay(relay)
+	job := &Job{
+		ID:        "job-relay",
+		Type:      "test",
+		Status:    StatusClaimed,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	reg.mu.Lock()
+	reg.jobs[job.ID] = job
+	reg.mu.Unlock()
+
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+	request := func(kind, body string) (int, map[string]any) {
+		req := httptest.NewRequest(http.MethodPost, "/jobs/"+job.ID+"/"+kind, strings.NewReader(body))
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		var out map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.Code, out
+	}
+
+	if code, out := request("payload", `{"relay":{"worker_id":"agent-2","path":"/ingest"}}`); code != http.StatusNotFound || out["error"] != "agent_not_connected" {
+		t.Fatalf("unknown agent: %d %v", code, out)
+	}
+	if code, out := request("payload", `{"relay":{"worker_id":"agent-1","path":"ingest"}}`); code != http.StatusBadRequest || out["error"] != "invalid_relay" {
+		t.Fatalf("relative path: %d %v", code, out)
+	}
+
+	code, out := request("payload", `{"content_type":"text/plain","content_encoding":"zstd","relay":{"worker_id":"agent-1","path":"/ingest","headers":{"X-Job":"job-relay"}}}`)
+	if code != http.StatusOK || out["relay"] == nil || out["reader_url"] != nil || out["token"] != nil {
+		t.Fatalf("payload relay: %d %v", code, out)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tr.Send(ctx, out["channel_id"].(string), strings.NewReader("to the agent")); err != nil {
+		t.Fatalf("send payload: %v", err)
+	}
+	relay.mu.Lock()
+	if relay.method != http.MethodPost || relay.path != "/ingest" || string(relay.body) != "to the agent" ||
+		relay.headers["Content-Type"] != "text/plain" || relay.headers["Content-Encoding"] != "zstd" || relay.headers["X-Job"] != "job-relay" {
+		t.Fatalf("relayed payload = %s %s %v %q", relay.method, relay.path, relay.headers, relay.body)
+	}
+	relay.mu.Unlock()
+
+	code, out = request("result", `{"relay":{"worker_id":"agent-1","path":"/output"}}`)
+	if code != http.StatusOK || out["writer_url"] != nil {
+		t.Fatalf("result relay: %d %v", code, out)
+	}
+	var got strings.Builder
+	if err := tr.Receive(ctx, out["channel_id"].(string), &got); err != nil {
+		t.Fatalf("receive result: %v", err)
+	}
+	if got.String() != "from the agent" {
+		t.Fatalf("relayed result = %q", got.String())
+	}
+	relay.mu.Lock()
+	defer relay.mu.Unlock()
+	if relay.method != http.MethodGet || relay.path != "/output" {
+		t.Fatalf("relayed result request = %s %s", relay.method, relay.path)
+	}
+}
+
+func TestJobRetentionPrunesTerminalJobsFromStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	reg := NewRegistry(transfer.NewRegistry(0), 0, 0)
+	wb := NewWriteBehindStore(store)
+	reg.SetStore(wb)
+	router := chi.NewRouter()
+	reg.RegisterRoutes(router)
+
+	root := createTestJob(t, router, `{"type":"test","workflow_id":"wf-prune"}`)
+	child := createTestJob(t, router, `{"type":"test","depends_on":["`+root+`"]}`)
+	other := createTestJob(t, router, `{"type":"other"}`)
+	reg.claimNext([]string{"test"}, "worker", "")
+	req := httptest.NewRequest(http.MethodPost, "/jobs/"+root+"/status", strings.NewReader(`{"claim_token":"`+claimToken(reg, root)+`","state":"completed"}`))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	reg.mu.Lock()
+	reg.jobRetention = time.Minute
+	reg.pruneJobsLocked(time.Now().Add(2 * time.Minute))
+	_, rootKept := reg.jobs[root]
+	reg.mu.Unlock()
+	if !rootKept {
+		t.Fatalf("a job with a queued dependent must not be pruned")
+	}
+
+	reg.claimNext([]string{"test"}, "worker", "")
+	req = httptest.NewRequest(http.MethodPost, "/jobs/"+child+"/status", strings.NewReader(`{"claim_token":"`+claimToken(reg, child)+`","state":"completed"}`))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	reg.mu.Lock()
+	reg.pruneJobsLocked(time.Now().Add(2 * time.Minute))
+	remaining := len(reg.jobs)
+	reg.mu.Unlock()
+	if remaining != 1 {
+		t.Fatalf("expected only the queued job to remain, got %d jobs", remaining)
+	}
+
+	wb.Flush()
+	recs, err := store.LoadJobs()
+	if err != nil {
+		t.Fatalf("LoadJobs: %v", err)
+	}
+	if len(recs) != 1 || recs[0].ID != other {
+		t.Fatalf("expected pruned jobs to be deleted from the store, got %+v", recs)
 	}
 }
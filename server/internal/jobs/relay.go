@@ -0,0 +1,185 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gaspardpetit/nfrx/core/logx"
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
+	"github.com/gaspardpetit/nfrx/server/internal/transfer"
+)
+
+// RelayTarget binds a transfer channel to a connected agent, for workers that
+// can only hold the agent's connection to the server. The server itself reads
+// a payload channel and POSTs the body to Path on the agent's backend, or
+// GETs Path there and writes the response into a result channel; the bytes
+// travel over the agent's control connection.
+type RelayTarget struct {
+	WorkerID string            `json:"worker_id"`
+	Path     string            `json:"path"`
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
+// AddTransferRelay registers the agents of a plugin as relay targets.
+func (r *Registry) AddTransferRelay(tr spi.TransferRelay) {
+	r.mu.Lock()
+	r.relays = append(r.relays, tr)
+	r.mu.Unlock()
+}
+
+// relayFor returns the relay whose agent workerID is connected, or nil.
+func (r *Registry) relayFor(target *RelayTarget) spi.TransferRelay {
+	r.mu.Lock()
+	relays := r.relays
+	r.mu.Unlock()
+	for _, tr := range relays {
+		if tr.HasWorker(target.WorkerID) {
+			return tr
+		}
+	}
+	return nil
+}
+
+// checkRelay finds the agent a transfer request asks to relay through,
+// answering the request itself when there is none to use. Only streaming
+// channels can be relayed.
+func (r *Registry) checkRelay(w http.ResponseWriter, body TransferRequest, opts transfer.Options) (spi.TransferRelay, bool) {
+	target := body.Relay
+	if target == nil {
+		return nil, true
+	}
+	if opts.Buffered || strings.TrimSpace(target.WorkerID) == "" || !strings.HasPrefix(target.Path, "/") {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_relay"})
+		return nil, false
+	}
+	tr := r.relayFor(target)
+	if tr == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "agent_not_connected"})
+		return nil, false
+	}
+	return tr, true
+}
+
+// relayPayload reads payload channel id and streams the body to the agent.
+func (r *Registry) relayPayload(tr spi.TransferRelay, jobID, id string, target RelayTarget, contentType, contentEncoding string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	headers := relayHeaders(target, contentType)
+	if contentEncoding != "" {
+		headers["Content-Encoding"] = contentEncoding
+	}
+	sink := &relaySink{start: func(body io.Reader) error {
+		return tr.Relay(ctx, target.WorkerID, http.MethodPost, target.Path, headers, body, io.Discard)
+	}}
+	if err := r.transfer.Receive(ctx, id, sink); err != nil {
+		sink.abort(err)
+		logx.Log.Warn().Err(err).Str("job_id", jobID).Str("channel_id", id).Str("worker_id", target.WorkerID).Msg("relay payload")
+	}
+}
+
+// relayResult fetches the result from the agent and writes it into result
+// channel id.
+func (r *Registry) relayResult(tr spi.TransferRelay, jobID, id string, target RelayTarget) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	headers := relayHeaders(target, "")
+	src := &relaySource{start: func(dst io.Writer) error {
+		return tr.Relay(ctx, target.WorkerID, http.MethodGet, target.Path, headers, nil, dst)
+	}}
+	defer func() { _ = src.Close() }()
+	if err := r.transfer.Send(ctx, id, src); err != nil {
+		logx.Log.Warn().Err(err).Str("job_id", jobID).Str("channel_id", id).Str("worker_id", target.WorkerID).Msg("relay result")
+	}
+}
+
+func relayHeaders(target RelayTarget, contentType string) map[string]string {
+	headers := make(map[string]string, len(target.Headers)+1)
+	for k, v := range target.Headers {
+		headers[k] = v
+	}
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+	return headers
+}
+
+// relaySink starts the relay on the first write, once a writer is streaming,
+// and feeds it the body through a pipe. Close ends the body and waits for the
+// relay, so the writer is only answered once the agent's backend has taken
+// the whole body.
+type relaySink struct {
+	start func(io.Reader) error
+	once  sync.Once
+	pw    *io.PipeWriter
+	done  chan error
+}
+
+func (s *relaySink) begin() {
+	s.once.Do(func() {
+		pr, pw := io.Pipe()
+		s.pw = pw
+		s.done = make(chan error, 1)
+		go func() {
+			err := s.start(pr)
+			if err == nil {
+				// The backend answered without reading the whole body.
+				_ = pr.CloseWithError(io.ErrClosedPipe)
+			} else {
+				_ = pr.CloseWithError(err)
+			}
+			s.done <- err
+		}()
+	})
+}
+
+func (s *relaySink) Write(p []byte) (int, error) {
+	s.begin()
+	return s.pw.Write(p)
+}
+
+func (s *relaySink) Close() error {
+	s.begin()
+	_ = s.pw.Close()
+	return <-s.done
+}
+
+// abort fails a relay whose channel did not complete.
+func (s *relaySink) abort(err error) {
+	if s.pw != nil {
+		_ = s.pw.CloseWithError(err)
+	}
+}
+
+// relaySource starts the relay on the first read, once a reader is attached,
+// so the agent is not asked for a result nobody is reading yet.
+type relaySource struct {
+	start func(io.Writer) error
+	once  sync.Once
+	pr    *io.PipeReader
+	mu    sync.Mutex
+}
+
+func (s *relaySource) Read(p []byte) (int, error) {
+	s.once.Do(func() {
+		pr, pw := io.Pipe()
+		s.mu.Lock()
+		s.pr = pr
+		s.mu.Unlock()
+		go func() { _ = pw.CloseWithError(s.start(pw)) }()
+	})
+	return s.pr.Read(p)
+}
+
+// Close interrupts the relay when the channel closes mid-copy.
+func (s *relaySource) Close() error {
+	s.mu.Lock()
+	pr := s.pr
+	s.mu.Unlock()
+	if pr != nil {
+		_ = pr.CloseWithError(io.ErrClosedPipe)
+	}
+	return nil
+}
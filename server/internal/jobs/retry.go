@@ -0,0 +1,134 @@
+package jobs
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RetryPolicy controls how a job that a worker marks failed is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of claims allowed, including the first.
+	MaxAttempts int `json:"max_attempts"`
+	// BackoffSeconds is the delay before the first retry.
+	BackoffSeconds float64 `json:"backoff_seconds,omitempty"`
+	// BackoffMultiplier scales the delay after each retry (default 2).
+	BackoffMultiplier float64 `json:"backoff_multiplier,omitempty"`
+	// MaxBackoffSeconds caps the delay; zero means no cap.
+	MaxBackoffSeconds float64 `json:"max_backoff_seconds,omitempty"`
+	// RetryOn lists the error codes that are retried; empty retries any error.
+	RetryOn []string `json:"retry_on,omitempty"`
+}
+
+func (p *RetryPolicy) valid() bool {
+	return p.MaxAttempts >= 1 && p.BackoffSeconds >= 0 && p.BackoffMultiplier >= 0 && p.MaxBackoffSeconds >= 0
+}
+
+func (p *RetryPolicy) retryable(err *JobError) bool {
+	if len(p.RetryOn) == 0 {
+		return true
+	}
+	return err != nil && contains(p.RetryOn, err.Code)
+}
+
+// backoff returns the delay before the retry following the given attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	mult := p.BackoffMultiplier
+	if mult == 0 {
+		mult = 2
+	}
+	secs := p.BackoffSeconds * math.Pow(mult, float64(max(attempt-1, 0)))
+	if p.MaxBackoffSeconds > 0 && secs > p.MaxBackoffSeconds {
+		secs = p.MaxBackoffSeconds
+	}
+	return time.Duration(secs * float64(time.Second))
+}
+
+func copyRetry(p *RetryPolicy) *RetryPolicy {
+	if p == nil {
+		return nil
+	}
+	cp := *p
+	cp.RetryOn = append([]string(nil), p.RetryOn...)
+	return &cp
+}
+
+// retryLocked handles a failure reported by a worker. It re-queues the job
+// after its backoff and returns true when the retry policy allows another
+// attempt; otherwise it marks exhausted jobs as dead-lettered and returns
+// false so the caller fails the job.
+func (r *Registry) retryLocked(job *Job, jobErr *JobError, now time.Time) bool {
+	p := job.Retry
+	if p == nil || !p.retryable(jobErr) {
+		return false
+	}
+	if job.Attempts >= p.MaxAttempts {
+		job.DeadLetteredAt = now
+		return false
+	}
+	r.requeueLocked(job, now)
+	job.Error = copyError(jobErr)
+	if delay := p.backoff(job.Attempts); delay > 0 {
+		job.NotBefore = now.Add(delay)
+		r.wakeAt(job.NotBefore)
+	}
+	return true
+}
+
+// wakeAt signals waiting workers once t is reached so jobs that become due
+// are claimed without waiting for another job to arrive.
+func (r *Registry) wakeAt(t time.Time) {
+	time.AfterFunc(time.Until(t), r.signal)
+}
+
+// HandleListDeadLetter lists jobs that failed after exhausting their attempts,
+// most recent first.
+func (r *Registry) HandleListDeadLetter(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	var dead []*Job
+	for _, job := range r.jobs {
+		if !job.DeadLetteredAt.IsZero() {
+			dead = append(dead, job)
+		}
+	}
+	sort.Slice(dead, func(i, j int) bool { return dead[i].DeadLetteredAt.After(dead[j].DeadLetteredAt) })
+	views := make([]JobView, 0, len(dead))
+	for _, job := range dead {
+		views = append(views, r.viewLocked(job))
+	}
+	r.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{"jobs": views})
+}
+
+// HandleRedriveDeadLetter re-queues a dead-lettered job with a fresh attempt
+// count. Workflow dependents its failure canceled are blocked on it again, and
+// the client-idle timers that stopped when the jobs ended are re-armed.
+func (r *Registry) HandleRedriveDeadLetter(w http.ResponseWriter, req *http.Request) {
+	jobID := chi.URLParam(req, "job_id")
+	r.mu.Lock()
+	job := r.jobs[jobID]
+	if job == nil || job.DeadLetteredAt.IsZero() {
+		r.mu.Unlock()
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not_found"})
+		return
+	}
+	now := time.Now()
+	r.requeueLocked(job, now)
+	job.DeadLetteredAt = time.Time{}
+	job.Attempts = 0
+	job.Error = nil
+	r.saveLocked(job)
+	view := r.viewLocked(job)
+	revived := r.reviveDependentsLocked(job, now)
+	r.mu.Unlock()
+
+	r.publish(jobID, Event{Type: "status", Data: view})
+	r.signal()
+	for _, id := range append([]string{jobID}, revived...) {
+		r.startClientTimerIfIdle(id)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"job_id": jobID, "status": view.Status})
+}
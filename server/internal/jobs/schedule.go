@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gaspardpetit/nfrx/core/logx"
+)
+
+// armScheduleLocked sets a timer that runs the recurring definition job at its
+// next run time, replacing any existing timer.
+func (r *Registry) armScheduleLocked(def *Job) {
+	r.stopScheduleLocked(def.ID)
+	if def.NotBefore.IsZero() {
+		return
+	}
+	id := def.ID
+	r.schedTimers[id] = time.AfterFunc(time.Until(def.NotBefore), func() { r.runSchedule(id) })
+}
+
+func (r *Registry) stopScheduleLocked(id string) {
+	if timer := r.schedTimers[id]; timer != nil {
+		timer.Stop()
+		delete(r.schedTimers, id)
+	}
+}
+
+// runSchedule queues a new instance of a recurring definition and advances
+// the definition to its next run time.
+func (r *Registry) runSchedule(id string) {
+	r.mu.Lock()
+	def := r.jobs[id]
+	if def == nil || def.Status != StatusScheduled {
+		delete(r.schedTimers, id)
+		r.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	inst := &Job{
+		ID:          uuid.NewString(),
+		Type:        def.Type,
+		Status:      StatusQueued,
+		Priority:    def.Priority,
+		Metadata:    copyMap(def.Metadata),
+		WorkerID:    def.WorkerID,
+		WorkerGroup: def.WorkerGroup,
+		Retry:       copyRetry(def.Retry),
+		ScheduleID:  def.ID,
+		History:     []Transition{{Status: StatusQueued, At: now}},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	r.jobs[inst.ID] = inst
+	r.queue = append(r.queue, inst.ID)
+	r.saveLocked(inst)
+
+	def.LastJobID = inst.ID
+	def.UpdatedAt = now
+	if spec, err := parseCron(def.Schedule); err == nil {
+		def.NotBefore = spec.next(now)
+	} else {
+		def.NotBefore = time.Time{}
+	}
+	r.armScheduleLocked(def)
+	r.saveLocked(def)
+	instView := r.viewLocked(inst)
+	defView := r.viewLocked(def)
+	r.mu.Unlock()
+
+	logx.Log.Debug().Str("schedule_id", id).Str("job_id", inst.ID).Msg("scheduled job instance queued")
+	r.publish(inst.ID, Event{Type: "status", Data: instView})
+	r.publish(id, Event{Type: "status", Data: defView})
+	r.signal()
+}
@@ -0,0 +1,333 @@
+package jobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/gaspardpetit/nfrx/core/logx"
+	"github.com/gaspardpetit/nfrx/server/internal/transfer"
+)
+
+var (
+	errResultTooLarge = errors.New("jobs: result exceeds the size limit")
+	errSpoolFull      = errors.New("jobs: result spool is full")
+)
+
+// ResultSpool keeps the results of jobs created with retain_results on local
+// disk so clients can download them after the worker has finished. Each
+// result is bounded by maxBytes, the whole spool by maxTotal, and results are
+// deleted ttl after they were written.
+type ResultSpool struct {
+	dir      string
+	ttl      time.Duration
+	maxBytes int64
+	maxTotal int64
+
+	mu      sync.Mutex
+	used    int64
+	entries map[string]*spoolEntry
+}
+
+type spoolEntry struct {
+	path      string
+	size      int64
+	expiresAt time.Time
+	timer     *time.Timer
+}
+
+// NewResultSpool opens a spool in dir, picking up results left by a previous
+// run and deleting those that have expired. Non-positive limits disable the
+// corresponding check.
+func NewResultSpool(dir string, ttl time.Duration, maxBytes, maxTotal int64) (*ResultSpool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &ResultSpool{dir: dir, ttl: ttl, maxBytes: maxBytes, maxTotal: maxTotal, entries: make(map[string]*spoolEntry)}
+	now := time.Now()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		jobID, name := filepath.Split(rel)
+		jobID = filepath.Clean(jobID)
+		key, decodeErr := hex.DecodeString(name)
+		expiresAt := s.expiry(info.ModTime())
+		if jobID != filepath.Base(jobID) || jobID == "." || decodeErr != nil || (!expiresAt.IsZero() && !expiresAt.After(now)) {
+			return os.Remove(path)
+		}
+		s.addLocked(jobID, string(key), path, info.Size(), expiresAt)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// expiry returns when a result written at t expires, or the zero time when
+// results are kept until restart.
+func (s *ResultSpool) expiry(t time.Time) time.Time {
+	if s.ttl <= 0 {
+		return time.Time{}
+	}
+	return t.Add(s.ttl)
+}
+
+func spoolKey(jobID, key string) string {
+	return jobID + "/" + key
+}
+
+func (s *ResultSpool) path(jobID, key string) (string, error) {
+	if jobID == "" || jobID != filepath.Base(jobID) || jobID == "." || jobID == ".." {
+		return "", fmt.Errorf("jobs: invalid job id %q", jobID)
+	}
+	return filepath.Join(s.dir, jobID, hex.EncodeToString([]byte(key))), nil
+}
+
+// addLocked records a stored result, replacing any earlier result with the
+// same key, and schedules its deletion.
+func (s *ResultSpool) addLocked(jobID, key, path string, size int64, expiresAt time.Time) {
+	k := spoolKey(jobID, key)
+	if old := s.entries[k]; old != nil {
+		s.used -= old.size
+		if old.timer != nil {
+			old.timer.Stop()
+		}
+	}
+	e := &spoolEntry{path: path, size: size, expiresAt: expiresAt}
+	if !expiresAt.IsZero() {
+		e.timer = time.AfterFunc(time.Until(expiresAt), func() { s.expire(k, e) })
+	}
+	s.entries[k] = e
+	s.used += size
+}
+
+func (s *ResultSpool) expire(k string, e *spoolEntry) {
+	s.mu.Lock()
+	if s.entries[k] != e {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.entries, k)
+	s.used -= e.size
+	s.mu.Unlock()
+	if err := os.Remove(e.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logx.Log.Warn().Err(err).Str("path", e.path).Msg("remove expired job result")
+	}
+}
+
+// reserve accounts n more bytes against the spool limit.
+func (s *ResultSpool) reserve(n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxTotal > 0 && s.used+n > s.maxTotal {
+		return errSpoolFull
+	}
+	s.used += n
+	return nil
+}
+
+func (s *ResultSpool) release(n int64) {
+	s.mu.Lock()
+	s.used -= n
+	s.mu.Unlock()
+}
+
+// Open returns the stored result of a job together with its expiry time.
+func (s *ResultSpool) Open(jobID, key string) (*os.File, time.Time, error) {
+	s.mu.Lock()
+	e := s.entries[spoolKey(jobID, key)]
+	s.mu.Unlock()
+	if e == nil {
+		return nil, time.Time{}, fs.ErrNotExist
+	}
+	f, err := os.Open(e.path)
+	return f, e.expiresAt, err
+}
+
+// create returns a writer that stores a result. Data goes to a temporary file
+// that becomes visible, and calls onCommit with its expiry, only once Close
+// succeeds.
+func (s *ResultSpool) create(jobID, key string, onCommit func(expiresAt time.Time, size int64, digest string)) (*spoolWriter, error) {
+	path, err := s.path(jobID, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".result-*")
+	if err != nil {
+		return nil, err
+	}
+	return &spoolWriter{spool: s, jobID: jobID, key: key, path: path, f: f, hash: sha256.New(), onCommit: onCommit}, nil
+}
+
+type spoolWriter struct {
+	spool    *ResultSpool
+	jobID    string
+	key      string
+	path     string
+	f        *os.File
+	size     int64
+	hash     hash.Hash
+	done     bool
+	onCommit func(expiresAt time.Time, size int64, digest string)
+}
+
+func (w *spoolWriter) Write(p []byte) (int, error) {
+	n := int64(len(p))
+	if w.spool.maxBytes > 0 && w.size+n > w.spool.maxBytes {
+		return 0, errResultTooLarge
+	}
+	if err := w.spool.reserve(n); err != nil {
+		return 0, err
+	}
+	written, err := w.f.Write(p)
+	w.hash.Write(p[:written])
+	w.size += int64(written)
+	w.spool.release(n - int64(written))
+	return written, err
+}
+
+// Close publishes the stored result.
+func (w *spoolWriter) Close() error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(w.f.Name(), w.path); err != nil {
+		return err
+	}
+	w.done = true
+	expiresAt := w.spool.expiry(time.Now())
+	w.spool.mu.Lock()
+	// The bytes were already reserved while writing.
+	w.spool.used -= w.size
+	w.spool.addLocked(w.jobID, w.key, w.path, w.size, expiresAt)
+	w.spool.mu.Unlock()
+	if w.onCommit != nil {
+		w.onCommit(expiresAt, w.size, hex.EncodeToString(w.hash.Sum(nil)))
+	}
+	return nil
+}
+
+// abort discards a result that was not committed.
+func (w *spoolWriter) abort() {
+	if w.done {
+		return
+	}
+	w.done = true
+	_ = w.f.Close()
+	_ = os.Remove(w.f.Name())
+	w.spool.release(w.size)
+}
+
+// SetResultSpool enables result retention for jobs created with
+// retain_results.
+func (r *Registry) SetResultSpool(s *ResultSpool) {
+	r.mu.Lock()
+	r.results = s
+	r.mu.Unlock()
+}
+
+// retainResultLocked makes the server itself the reader of a result channel,
+// storing what the worker writes in the spool. The result is added to the job
+// and announced to clients once it has been stored.
+func (r *Registry) retainResultLocked(job *Job, info *TransferInfo) error {
+	jobID, key, channelID := job.ID, info.Key, info.ChannelID
+	sink, err := r.results.create(jobID, key, func(expiresAt time.Time, size int64, digest string) {
+		stored := &TransferInfo{
+			ChannelID:       channelID,
+			Method:          http.MethodGet,
+			URL:             "/api/jobs/" + jobID + "/results/" + url.PathEscape(key),
+			ExpiresAt:       formatOptionalTime(expiresAt),
+			Key:             key,
+			Properties:      copyMap(info.Properties),
+			Size:            size,
+			ContentType:     info.ContentType,
+			ContentEncoding: info.ContentEncoding,
+			SHA256:          digest,
+		}
+		r.mu.Lock()
+		if job := r.jobs[jobID]; job != nil {
+			if job.Results == nil {
+				job.Results = make(map[string]*TransferInfo)
+			}
+			job.Results[key] = stored
+			r.saveLocked(job)
+		}
+		r.mu.Unlock()
+		r.publish(jobID, Event{Type: "result", Data: stored})
+	})
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := r.transfer.Receive(context.Background(), channelID, sink); err != nil {
+			sink.abort()
+			logx.Log.Warn().Err(err).Str("job_id", jobID).Str("key", key).Msg("result not retained")
+		}
+	}()
+	return nil
+}
+
+// HandleGetResult downloads a retained result. Range requests are supported
+// so interrupted downloads can resume.
+func (r *Registry) HandleGetResult(w http.ResponseWriter, req *http.Request) {
+	jobID, key := chi.URLParam(req, "job_id"), chi.URLParam(req, "key")
+	var stored TransferInfo
+	r.mu.Lock()
+	job, spool := r.jobs[jobID], r.results
+	visible := job != nil && (job.Owner == "" || job.Owner == r.ownerLocked(req))
+	if visible && job.Results[key] != nil {
+		stored = *job.Results[key]
+	}
+	r.mu.Unlock()
+	if !visible || spool == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not_found"})
+		return
+	}
+	f, expiresAt, err := spool.Open(jobID, key)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "result_not_found"})
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": "result_unavailable"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	if stored.ContentEncoding != "" {
+		w.Header().Set("Content-Encoding", stored.ContentEncoding)
+	}
+	if stored.SHA256 != "" {
+		w.Header().Set(transfer.TrailerSHA256, stored.SHA256)
+	}
+	if !expiresAt.IsZero() {
+		w.Header().Set("Expires", expiresAt.UTC().Format(http.TimeFormat))
+	}
+	http.ServeContent(w, req, "", info.ModTime(), f)
+}
@@ -0,0 +1,281 @@
+package jobs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gaspardpetit/nfrx/core/logx"
+)
+
+const (
+	// RecoveryRequeue returns jobs that were in flight at shutdown to the queue.
+	RecoveryRequeue = "requeue"
+	// RecoveryFail marks jobs that were in flight at shutdown as failed.
+	RecoveryFail = "fail"
+)
+
+// Store persists job metadata, state transitions and lifetime stats so queued
+// jobs survive a server restart. Payload and result data are never stored.
+type Store interface {
+	SaveJob(rec Record) error
+	DeleteJob(id string) error
+	LoadJobs() ([]Record, error)
+	SaveStats(stats Stats) error
+	LoadStats() (Stats, error)
+}
+
+// Record is the persisted form of a Job.
+type Record struct {
+	ID                 string            `json:"id"`
+	Type               string            `json:"type"`
+	Status             string            `json:"status"`
+	Priority           int               `json:"priority,omitempty"`
+	Metadata           map[string]any    `json:"metadata,omitempty"`
+	WorkerID           string            `json:"worker_id,omitempty"`
+	WorkerGroup        string            `json:"worker_group,omitempty"`
+	ClaimedWorkerID    string            `json:"claimed_worker_id,omitempty"`
+	ClaimedWorkerGroup string            `json:"claimed_worker_group,omitempty"`
+	LastWorkerID       string            `json:"last_worker_id,omitempty"`
+	LastWorkerGroup    string            `json:"last_worker_group,omitempty"`
+	Attempts           int               `json:"attempts,omitempty"`
+	Retry              *RetryPolicy      `json:"retry,omitempty"`
+	NotBefore          time.Time         `json:"not_before"`
+	DeadLetteredAt     time.Time         `json:"dead_lettered_at"`
+	Schedule           string            `json:"schedule,omitempty"`
+	ScheduleID         string            `json:"schedule_id,omitempty"`
+	LastJobID          string            `json:"last_job_id,omitempty"`
+	DependsOn          []string          `json:"depends_on,omitempty"`
+	WorkflowID         string            `json:"workflow_id,omitempty"`
+	Owner              string            `json:"owner,omitempty"`
+	RetainResults      bool              `json:"retain_results,omitempty"`
+	Webhook            *Webhook          `json:"webhook,omitempty"`
+	WebhookDeliveries  []WebhookDelivery `json:"webhook_deliveries,omitempty"`
+	CancelState        string            `json:"cancel_state,omitempty"`
+	CancelAckAt        time.Time         `json:"cancel_acknowledged_at"`
+	Tracked            bool              `json:"tracked,omitempty"`
+	Progress           map[string]any    `json:"progress,omitempty"`
+	PartialSeq         int64             `json:"partial_seq,omitempty"`
+	Error              *JobError         `json:"error,omitempty"`
+	History            []Transition      `json:"history,omitempty"`
+	CreatedAt          time.Time         `json:"created_at"`
+	ClaimedAt          time.Time         `json:"claimed_at"`
+	UpdatedAt          time.Time         `json:"updated_at"`
+}
+
+// Transition records a job entering a status.
+type Transition struct {
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
+}
+
+// SetStore makes the registry persist jobs to s; it must be called before
+// Restore. Registries start with a no-op store, so jobs only live in memory
+// unless a durable store is configured.
+func (r *Registry) SetStore(s Store) {
+	if s == nil {
+		s = nopStore{}
+	}
+	r.mu.Lock()
+	r.store = s
+	r.mu.Unlock()
+}
+
+// OpenStore opens the Store described by spec. A redis://, rediss://,
+// redis-sentinel:// or rediss-sentinel:// URL selects Redis; a file:// URL or
+// a plain path selects a local directory.
+func OpenStore(spec string) (Store, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "":
+		return nil, fmt.Errorf("jobs: empty store spec")
+	case strings.HasPrefix(spec, "redis://"), strings.HasPrefix(spec, "rediss://"),
+		strings.HasPrefix(spec, "redis-sentinel://"), strings.HasPrefix(spec, "rediss-sentinel://"):
+		return NewRedisStore(spec)
+	default:
+		return NewFileStore(strings.TrimPrefix(spec, "file://"))
+	}
+}
+
+type nopStore struct{}
+
+func (nopStore) SaveJob(Record) error        { return nil }
+func (nopStore) DeleteJob(string) error      { return nil }
+func (nopStore) LoadJobs() ([]Record, error) { return nil, nil }
+func (nopStore) SaveStats(Stats) error       { return nil }
+func (nopStore) LoadStats() (Stats, error)   { return Stats{}, nil }
+
+func recordFromJob(job *Job) Record {
+	return Record{
+		ID:                 job.ID,
+		Type:               job.Type,
+		Status:             job.Status,
+		Priority:           job.Priority,
+		Metadata:           copyMap(job.Metadata),
+		WorkerID:           job.WorkerID,
+		WorkerGroup:        job.WorkerGroup,
+		ClaimedWorkerID:    job.ClaimedWorkerID,
+		ClaimedWorkerGroup: job.ClaimedWorkerGroup,
+		LastWorkerID:       job.LastWorkerID,
+		LastWorkerGroup:    job.LastWorkerGroup,
+		Attempts:           job.Attempts,
+		Retry:              copyRetry(job.Retry),
+		NotBefore:          job.NotBefore,
+		DeadLetteredAt:     job.DeadLetteredAt,
+		Schedule:           job.Schedule,
+		ScheduleID:         job.ScheduleID,
+		LastJobID:          job.LastJobID,
+		DependsOn:          job.DependsOn,
+		WorkflowID:         job.WorkflowID,
+		Owner:              job.Owner,
+		RetainResults:      job.RetainResults,
+		Webhook:            copyWebhook(job.Webhook),
+		WebhookDeliveries:  append([]WebhookDelivery(nil), job.WebhookDeliveries...),
+		CancelState:        job.CancelState,
+		CancelAckAt:        job.CancelAckAt,
+		Tracked:            job.Tracked,
+		Progress:           copyMap(job.Progress),
+		PartialSeq:         job.partialSeq,
+		Error:              copyError(job.Error),
+		History:            append([]Transition(nil), job.History...),
+		CreatedAt:          job.CreatedAt,
+		ClaimedAt:          job.ClaimedAt,
+		UpdatedAt:          job.UpdatedAt,
+	}
+}
+
+func jobFromRecord(rec Record) *Job {
+	return &Job{
+		ID:                 rec.ID,
+		Type:               rec.Type,
+		Status:             rec.Status,
+		Priority:           rec.Priority,
+		Metadata:           rec.Metadata,
+		WorkerID:           rec.WorkerID,
+		WorkerGroup:        rec.WorkerGroup,
+		ClaimedWorkerID:    rec.ClaimedWorkerID,
+		ClaimedWorkerGroup: rec.ClaimedWorkerGroup,
+		LastWorkerID:       rec.LastWorkerID,
+		LastWorkerGroup:    rec.LastWorkerGroup,
+		Attempts:           rec.Attempts,
+		Retry:              rec.Retry,
+		NotBefore:          rec.NotBefore,
+		DeadLetteredAt:     rec.DeadLetteredAt,
+		Schedule:           rec.Schedule,
+		ScheduleID:         rec.ScheduleID,
+		LastJobID:          rec.LastJobID,
+		DependsOn:          rec.DependsOn,
+		WorkflowID:         rec.WorkflowID,
+		Owner:              rec.Owner,
+		RetainResults:      rec.RetainResults,
+		Webhook:            rec.Webhook,
+		WebhookDeliveries:  rec.WebhookDeliveries,
+		CancelState:        rec.CancelState,
+		CancelAckAt:        rec.CancelAckAt,
+		Tracked:            rec.Tracked,
+		Progress:           rec.Progress,
+		partialSeq:         rec.PartialSeq,
+		Error:              rec.Error,
+		History:            rec.History,
+		CreatedAt:          rec.CreatedAt,
+		ClaimedAt:          rec.ClaimedAt,
+		UpdatedAt:          rec.UpdatedAt,
+	}
+}
+
+// Restore loads persisted jobs and stats into the registry. Queued jobs are
+// re-queued in creation order; jobs that were in flight when the server
+// stopped are re-queued or failed according to policy, and pending webhook
+// deliveries are sent again. It returns the number of jobs restored.
+func (r *Registry) Restore(policy string) (int, error) {
+	switch policy {
+	case "", RecoveryRequeue, RecoveryFail:
+	default:
+		return 0, fmt.Errorf("jobs: unknown recovery policy %q", policy)
+	}
+	recs, err := r.store.LoadJobs()
+	if err != nil {
+		return 0, err
+	}
+	stats, err := r.store.LoadStats()
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].CreatedAt.Before(recs[j].CreatedAt) })
+	now := time.Now()
+	var pending []string
+	r.mu.Lock()
+	r.stats = stats
+	jobs := make([]*Job, 0, len(recs))
+	for _, rec := range recs {
+		job := jobFromRecord(rec)
+		r.jobs[job.ID] = job
+		r.addDependentsLocked(job)
+		jobs = append(jobs, job)
+	}
+	// Dependents are indexed before recovery so failing an in-flight job
+	// cancels the jobs blocked on it.
+	for _, job := range jobs {
+		if isTerminalStatus(job.Status) || job.Status == StatusBlocked {
+			continue
+		}
+		if job.Status == StatusScheduled {
+			r.armScheduleLocked(job)
+			continue
+		}
+		if job.Status != StatusQueued {
+			// Tracked jobs ran inside the previous process; nothing can
+			// resume them.
+			if policy == RecoveryFail || job.Tracked {
+				r.setJobStatusLocked(job, StatusFailed, now)
+				job.Error = &JobError{Code: "server_restart", Message: "server restarted while job was in flight"}
+				r.saveLocked(job)
+				continue
+			}
+			r.requeueLocked(job, now)
+			r.saveLocked(job)
+		} else {
+			r.queue = append(r.queue, job.ID)
+			if job.NotBefore.After(now) {
+				r.wakeAt(job.NotBefore)
+			}
+		}
+		// Delayed, scheduled and workflow jobs are not tied to a connected
+		// client.
+		if job.NotBefore.IsZero() && job.ScheduleID == "" && job.WorkflowID == "" {
+			pending = append(pending, job.ID)
+		}
+	}
+	for _, job := range jobs {
+		r.resumeWebhooksLocked(job)
+	}
+	queued := len(r.queue)
+	r.mu.Unlock()
+	for _, id := range pending {
+		r.startClientTimerIfIdle(id)
+	}
+	if queued > 0 {
+		r.signal()
+	}
+	return len(recs), nil
+}
+
+// deleteLocked removes a pruned job from the store.
+func (r *Registry) deleteLocked(jobID string) {
+	if err := r.store.DeleteJob(jobID); err != nil {
+		logx.Log.Warn().Err(err).Str("job_id", jobID).Msg("delete persisted job")
+	}
+}
+
+// saveLocked persists job and, once it is terminal, the lifetime stats.
+func (r *Registry) saveLocked(job *Job) {
+	if err := r.store.SaveJob(recordFromJob(job)); err != nil {
+		logx.Log.Warn().Err(err).Str("job_id", job.ID).Msg("persist job")
+	}
+	if isTerminalStatus(job.Status) {
+		if err := r.store.SaveStats(r.stats); err != nil {
+			logx.Log.Warn().Err(err).Msg("persist job stats")
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
+)
+
+var _ spi.JobTracker = (*Registry)(nil)
+
+// Track registers a job that runs inside the server, such as an LLM batch.
+// Tracked jobs start out running, are never offered to workers and only end
+// through Finish or a cancel, which calls cancel.
+func (r *Registry) Track(jobType string, metadata map[string]any, cancel func()) string {
+	now := time.Now()
+	job := &Job{
+		ID:        uuid.NewString(),
+		Type:      jobType,
+		Status:    StatusRunning,
+		Metadata:  metadata,
+		Tracked:   true,
+		History:   []Transition{{Status: StatusRunning, At: now}},
+		CreatedAt: now,
+		ClaimedAt: now,
+		UpdatedAt: now,
+		onCancel:  cancel,
+	}
+	r.mu.Lock()
+	r.jobs[job.ID] = job
+	r.saveLocked(job)
+	view := r.viewLocked(job)
+	r.mu.Unlock()
+	r.publish(job.ID, Event{Type: "status", Data: view})
+	return job.ID
+}
+
+// Progress replaces the progress of a tracked job that is still running.
+func (r *Registry) Progress(id string, progress map[string]any) {
+	r.mu.Lock()
+	job := r.jobs[id]
+	if job == nil || !job.Tracked || isTerminalStatus(job.Status) {
+		r.mu.Unlock()
+		return
+	}
+	job.Progress = copyMap(progress)
+	job.UpdatedAt = time.Now()
+	r.saveLocked(job)
+	view := r.viewLocked(job)
+	r.mu.Unlock()
+	r.publish(id, Event{Type: "status", Data: view})
+}
+
+// Finish ends a tracked job. It does nothing once the job is terminal, for
+// instance after it was canceled through the API.
+func (r *Registry) Finish(id, status, errCode, errMsg string) {
+	if !isTerminalStatus(status) {
+		return
+	}
+	r.mu.Lock()
+	job := r.jobs[id]
+	if job == nil || !job.Tracked || isTerminalStatus(job.Status) {
+		r.mu.Unlock()
+		return
+	}
+	job.onCancel = nil
+	r.setJobStatusLocked(job, status, time.Now())
+	if errCode != "" {
+		job.Error = &JobError{Code: errCode, Message: errMsg}
+	}
+	r.saveLocked(job)
+	view := r.viewLocked(job)
+	r.mu.Unlock()
+	r.publish(id, Event{Type: "status", Data: view})
+}
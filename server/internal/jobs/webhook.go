@@ -0,0 +1,374 @@
+package jobs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/gaspardpetit/nfrx/core/logx"
+)
+
+const (
+	webhookMaxAttempts   = 5
+	webhookMaxDeliveries = 10
+	webhookTimeout       = 10 * time.Second
+)
+
+// Webhook is a callback that receives a signed POST for each job status
+// change.
+type Webhook struct {
+	URL string `json:"url"`
+	// Secret signs deliveries with HMAC-SHA256; empty sends them unsigned.
+	Secret string `json:"secret,omitempty"`
+	// TerminalOnly limits deliveries to completed, failed and canceled.
+	TerminalOnly bool `json:"terminal_only,omitempty"`
+}
+
+func (h *Webhook) valid() bool {
+	u, err := url.Parse(h.URL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// WebhookDelivery records the outcome of one notification.
+type WebhookDelivery struct {
+	ID            string    `json:"id"`
+	Event         string    `json:"event"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	ResponseCode  int       `json:"response_code,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	LastAttemptAt time.Time `json:"last_attempt_at"`
+}
+
+// WebhookView is the client-visible webhook configuration; the secret is
+// never returned.
+type WebhookView struct {
+	URL          string            `json:"url"`
+	TerminalOnly bool              `json:"terminal_only,omitempty"`
+	Deliveries   []WebhookDelivery `json:"deliveries,omitempty"`
+}
+
+// webhookQueue delivers the notifications of one job in order.
+type webhookQueue struct {
+	pending    []webhookMessage
+	running    bool
+	lastStatus string
+}
+
+type webhookMessage struct {
+	id    string
+	event string
+	job   JobView
+}
+
+// errWebhookDestination rejects deliveries to addresses that are not public.
+var errWebhookDestination = errors.New("webhook destination not allowed")
+
+// reservedNetworks are non-public ranges not covered by the net.IP predicates
+// used in webhookAddrAllowed.
+var reservedNetworks = []*net.IPNet{
+	mustCIDR("0.0.0.0/8"),
+	mustCIDR("100.64.0.0/10"),
+	mustCIDR("192.0.0.0/24"),
+	mustCIDR("198.18.0.0/15"),
+	mustCIDR("240.0.0.0/4"),
+	mustCIDR("64:ff9b::/96"),
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// SetWebhookAllowedNetworks lists IPs or CIDR ranges that webhooks may reach
+// even though they are loopback, private or link-local. Every other
+// non-public address is refused so job creators cannot use webhooks to reach
+// internal services such as cloud metadata endpoints.
+func (r *Registry) SetWebhookAllowedNetworks(networks []string) error {
+	allowed := make([]*net.IPNet, 0, len(networks))
+	for _, s := range networks {
+		s = strings.TrimSpace(s)
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return fmt.Errorf("jobs: invalid webhook network %q", s)
+			}
+			bits := 128
+			if v4 := ip.To4(); v4 != nil {
+				ip, bits = v4, 32
+			}
+			allowed = append(allowed, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return fmt.Errorf("jobs: invalid webhook network %q", s)
+		}
+		allowed = append(allowed, n)
+	}
+	client := newWebhookClient(allowed)
+	r.mu.Lock()
+	r.hookClient = client
+	r.mu.Unlock()
+	return nil
+}
+
+// newWebhookClient returns the client shared by webhook deliveries. The
+// destination check runs on the resolved address of every connection,
+// redirects included, and no proxy is used so it cannot be bypassed.
+func newWebhookClient(allowed []*net.IPNet) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !webhookAddrAllowed(ip, allowed) {
+				return fmt.Errorf("%w: %s", errWebhookDestination, host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: webhookTimeout, Transport: transport}
+}
+
+func webhookAddrAllowed(ip net.IP, allowed []*net.IPNet) bool {
+	for _, n := range allowed {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, n := range reservedNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetWebhookBackoff sets the delay before the first webhook retry; it doubles
+// after each failed attempt.
+func (r *Registry) SetWebhookBackoff(d time.Duration) {
+	r.mu.Lock()
+	r.hookBackoff = d
+	r.mu.Unlock()
+}
+
+// notifyWebhookLocked queues a delivery for a status event of a job with a
+// webhook. Events that do not change the status, such as progress updates,
+// are skipped unless force is set.
+func (r *Registry) notifyWebhookLocked(job *Job, view JobView, force bool) string {
+	if job.Webhook == nil {
+		return ""
+	}
+	q := r.webhooks[job.ID]
+	if q == nil {
+		q = &webhookQueue{}
+		r.webhooks[job.ID] = q
+	}
+	if !force && (view.Status == q.lastStatus || (job.Webhook.TerminalOnly && !isTerminalStatus(view.Status))) {
+		return ""
+	}
+	q.lastStatus = view.Status
+	view.Webhook = nil
+	msg := webhookMessage{id: uuid.NewString(), event: "job." + view.Status, job: view}
+	q.pending = append(q.pending, msg)
+	r.recordDeliveryLocked(job, WebhookDelivery{ID: msg.id, Event: msg.event, Status: "pending"})
+	if !q.running {
+		q.running = true
+		go r.runWebhooks(job.ID)
+	}
+	return msg.id
+}
+
+// resumeWebhooksLocked re-queues the deliveries of a restored job that were
+// still pending when the server stopped. They carry the job's current view.
+func (r *Registry) resumeWebhooksLocked(job *Job) {
+	if job.Webhook == nil {
+		return
+	}
+	view := r.viewLocked(job)
+	view.Webhook = nil
+	var pending []webhookMessage
+	for _, d := range job.WebhookDeliveries {
+		if d.Status == "pending" {
+			pending = append(pending, webhookMessage{id: d.ID, event: d.Event, job: view})
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+	r.webhooks[job.ID] = &webhookQueue{pending: pending, running: true, lastStatus: job.Status}
+	go r.runWebhooks(job.ID)
+}
+
+// runWebhooks drains the queue of a job, sending one notification at a time
+// without holding the registry lock.
+func (r *Registry) runWebhooks(jobID string) {
+	for {
+		r.mu.Lock()
+		q := r.webhooks[jobID]
+		job := r.jobs[jobID]
+		if q == nil || len(q.pending) == 0 || job == nil || job.Webhook == nil {
+			if q != nil {
+				q.running = false
+			}
+			// Terminal jobs send no further notifications.
+			if job == nil || isTerminalStatus(job.Status) {
+				delete(r.webhooks, jobID)
+			}
+			r.mu.Unlock()
+			return
+		}
+		msg := q.pending[0]
+		q.pending = q.pending[1:]
+		hook := *job.Webhook
+		backoff := r.hookBackoff
+		client := r.hookClient
+		r.mu.Unlock()
+
+		d := deliverWebhook(client, hook, msg, backoff)
+
+		r.mu.Lock()
+		if job := r.jobs[jobID]; job != nil {
+			r.recordDeliveryLocked(job, d)
+			r.saveLocked(job)
+		}
+		r.mu.Unlock()
+	}
+}
+
+func deliverWebhook(client *http.Client, hook Webhook, msg webhookMessage, backoff time.Duration) WebhookDelivery {
+	d := WebhookDelivery{ID: msg.id, Event: msg.event}
+	body, err := json.Marshal(map[string]any{"id": msg.id, "event": msg.event, "job": msg.job})
+	if err != nil {
+		d.Status, d.Error = "failed", err.Error()
+		return d
+	}
+	for d.Attempts < webhookMaxAttempts {
+		if d.Attempts > 0 {
+			time.Sleep(backoff << (d.Attempts - 1))
+		}
+		d.Attempts++
+		d.LastAttemptAt = time.Now()
+		d.ResponseCode, err = postWebhook(client, hook, msg, body)
+		if err == nil {
+			d.Status, d.Error = "delivered", ""
+			return d
+		}
+		d.Error = err.Error()
+		if errors.Is(err, errWebhookDestination) {
+			break
+		}
+	}
+	d.Status = "failed"
+	logx.Log.Warn().Str("job_id", msg.job.ID).Str("url", hook.URL).Str("error", d.Error).Msg("job webhook delivery failed")
+	return d
+}
+
+func postWebhook(client *http.Client, hook Webhook, msg webhookMessage, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Nfrx-Event", msg.event)
+	req.Header.Set("X-Nfrx-Delivery", msg.id)
+	req.Header.Set("X-Nfrx-Timestamp", ts)
+	if hook.Secret != "" {
+		req.Header.Set("X-Nfrx-Signature", "sha256="+signWebhook(hook.Secret, ts, body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>".
+func signWebhook(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// recordDeliveryLocked adds or updates a delivery on the job, keeping the most
+// recent ones.
+func (r *Registry) recordDeliveryLocked(job *Job, d WebhookDelivery) {
+	for i := range job.WebhookDeliveries {
+		if job.WebhookDeliveries[i].ID == d.ID {
+			job.WebhookDeliveries[i] = d
+			return
+		}
+	}
+	job.WebhookDeliveries = append(job.WebhookDeliveries, d)
+	if n := len(job.WebhookDeliveries); n > webhookMaxDeliveries {
+		job.WebhookDeliveries = job.WebhookDeliveries[n-webhookMaxDeliveries:]
+	}
+}
+
+func webhookView(job *Job) *WebhookView {
+	if job.Webhook == nil {
+		return nil
+	}
+	return &WebhookView{
+		URL:          job.Webhook.URL,
+		TerminalOnly: job.Webhook.TerminalOnly,
+		Deliveries:   append([]WebhookDelivery(nil), job.WebhookDeliveries...),
+	}
+}
+
+// HandleTestWebhook re-sends the job's current status to its webhook.
+func (r *Registry) HandleTestWebhook(w http.ResponseWriter, req *http.Request) {
+	jobID := chi.URLParam(req, "job_id")
+	r.mu.Lock()
+	job := r.jobs[jobID]
+	if job == nil || job.Webhook == nil {
+		r.mu.Unlock()
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not_found"})
+		return
+	}
+	id := r.notifyWebhookLocked(job, r.viewLocked(job), true)
+	r.saveLocked(job)
+	r.mu.Unlock()
+	writeJSON(w, http.StatusAccepted, map[string]any{"delivery_id": id})
+}
+
+func copyWebhook(h *Webhook) *Webhook {
+	if h == nil {
+		return nil
+	}
+	cp := *h
+	return &cp
+}
@@ -0,0 +1,215 @@
+package jobs
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type dependencyState int
+
+const (
+	dependenciesPending dependencyState = iota
+	dependenciesCompleted
+	dependenciesFailed
+)
+
+// WorkflowView is the full graph of jobs sharing a workflow ID.
+type WorkflowView struct {
+	WorkflowID string    `json:"workflow_id"`
+	Status     string    `json:"status"`
+	Jobs       []JobView `json:"jobs"`
+}
+
+// dependencyStateLocked reports whether all parents of job completed, any of
+// them failed or was canceled, or some are still pending. Unknown parents
+// count as failed.
+func (r *Registry) dependencyStateLocked(job *Job) dependencyState {
+	state := dependenciesCompleted
+	for _, id := range job.DependsOn {
+		parent := r.jobs[id]
+		switch {
+		case parent == nil, parent.Status == StatusFailed, parent.Status == StatusCanceled:
+			return dependenciesFailed
+		case parent.Status != StatusCompleted:
+			state = dependenciesPending
+		}
+	}
+	return state
+}
+
+// resolveDependentsLocked runs after parent reaches a terminal status. Blocked
+// dependents whose parents all completed are queued; dependents of a failed or
+// canceled parent are canceled, which cascades further down the graph.
+func (r *Registry) resolveDependentsLocked(parent *Job, now time.Time) {
+	for _, id := range r.children[parent.ID] {
+		child := r.jobs[id]
+		if child == nil || child.Status != StatusBlocked {
+			continue
+		}
+		switch r.dependencyStateLocked(child) {
+		case dependenciesCompleted:
+			r.setJobStatusLocked(child, StatusQueued, now)
+			r.queue = append(r.queue, child.ID)
+			if child.NotBefore.After(now) {
+				r.wakeAt(child.NotBefore)
+			}
+			r.signal()
+		case dependenciesFailed:
+			r.setJobStatusLocked(child, StatusCanceled, now)
+			child.Error = &JobError{Code: "dependency_failed", Message: "dependency " + parent.ID + " " + parent.Status}
+		default:
+			continue
+		}
+		r.saveLocked(child)
+		r.publishLocked(child.ID, Event{Type: "status", Data: r.viewLocked(child)})
+	}
+}
+
+// reviveDependentsLocked runs after a failed parent is re-driven. Dependents
+// that its failure canceled return to blocked once none of their parents has
+// failed any more, and the revival cascades down the graph like the
+// cancellation did. It returns the IDs of the revived jobs.
+func (r *Registry) reviveDependentsLocked(parent *Job, now time.Time) []string {
+	var revived []string
+	for _, id := range r.children[parent.ID] {
+		child := r.jobs[id]
+		if child == nil || child.Status != StatusCanceled || child.Error == nil || child.Error.Code != "dependency_failed" {
+			continue
+		}
+		if r.dependencyStateLocked(child) == dependenciesFailed {
+			continue
+		}
+		r.setJobStatusLocked(child, StatusBlocked, now)
+		child.Error = nil
+		r.saveLocked(child)
+		r.publishLocked(child.ID, Event{Type: "status", Data: r.viewLocked(child)})
+		revived = append(revived, child.ID)
+		revived = append(revived, r.reviveDependentsLocked(child, now)...)
+	}
+	return revived
+}
+
+func (r *Registry) addDependentsLocked(job *Job) {
+	for _, id := range job.DependsOn {
+		r.children[id] = append(r.children[id], job.ID)
+	}
+}
+
+// workflowLocked returns the jobs of a workflow in creation order.
+func (r *Registry) workflowLocked(workflowID string) []*Job {
+	var jobs []*Job
+	for _, job := range r.jobs {
+		if job.WorkflowID == workflowID {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	return jobs
+}
+
+// workflowStatus summarizes a workflow: running while any job has not reached
+// a terminal status, then failed or canceled if any job ended that way, and
+// completed once every job completed.
+func workflowStatus(jobs []*Job) string {
+	status := StatusCompleted
+	for _, job := range jobs {
+		switch job.Status {
+		case StatusFailed:
+			status = StatusFailed
+		case StatusCanceled:
+			if status != StatusFailed {
+				status = StatusCanceled
+			}
+		case StatusCompleted:
+		default:
+			return StatusRunning
+		}
+	}
+	return status
+}
+
+func (r *Registry) workflowViewLocked(workflowID string) (WorkflowView, bool) {
+	jobs := r.workflowLocked(workflowID)
+	if len(jobs) == 0 {
+		return WorkflowView{}, false
+	}
+	view := WorkflowView{WorkflowID: workflowID, Status: workflowStatus(jobs), Jobs: make([]JobView, 0, len(jobs))}
+	for _, job := range jobs {
+		view.Jobs = append(view.Jobs, r.viewLocked(job))
+	}
+	return view, true
+}
+
+func (r *Registry) HandleGetWorkflow(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	view, ok := r.workflowViewLocked(chi.URLParam(req, "workflow_id"))
+	r.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not_found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, view)
+}
+
+// HandleWorkflowEvents streams status events for every job in a workflow,
+// starting with the current status of each job.
+func (r *Registry) HandleWorkflowEvents(w http.ResponseWriter, req *http.Request) {
+	workflowID := chi.URLParam(req, "workflow_id")
+	r.mu.Lock()
+	view, ok := r.workflowViewLocked(workflowID)
+	r.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "not_found"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	ch := r.subscribe(workflowKey(workflowID))
+	defer r.unsubscribe(workflowKey(workflowID), ch)
+
+	for _, jv := range view.Jobs {
+		r.writeEvent(w, Event{Type: "status", Data: jv})
+	}
+	flusher.Flush()
+	done := isTerminalStatus(view.Status)
+
+	ctx := req.Context()
+	var closeCh <-chan time.Time
+	for {
+		if done && r.sseCloseDelay >= 0 && closeCh == nil {
+			if r.sseCloseDelay == 0 {
+				return
+			}
+			closeCh = time.After(r.sseCloseDelay)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-closeCh:
+			return
+		case ev := <-ch:
+			r.writeEvent(w, ev)
+			flusher.Flush()
+			if shouldCloseAfter(ev) {
+				r.mu.Lock()
+				done = isTerminalStatus(workflowStatus(r.workflowLocked(workflowID)))
+				r.mu.Unlock()
+			}
+		}
+	}
+}
+
+// workflowKey is the subscription key for workflow-wide event streams. It
+// cannot collide with job IDs.
+func workflowKey(workflowID string) string {
+	return "workflow:" + workflowID
+}
@@ -0,0 +1,118 @@
+package jobs
+
+import (
+	"sync"
+
+	"github.com/gaspardpetit/nfrx/core/logx"
+)
+
+// WriteBehindStore queues writes in memory and applies them to the wrapped
+// Store from a background goroutine, so registry handlers never wait on disk
+// or network I/O while holding the registry lock. Only the latest record of a
+// job is kept while its write is pending; call Flush once the server has
+// stopped handling requests.
+type WriteBehindStore struct {
+	store Store
+
+	mu      sync.Mutex
+	jobs    map[string]*Record // nil deletes the job
+	stats   *Stats
+	running bool
+
+	// writeMu serializes batches so Flush returns only after every change
+	// queued before it has been written.
+	writeMu sync.Mutex
+}
+
+// NewWriteBehindStore wraps s so its writes happen in the background.
+func NewWriteBehindStore(s Store) *WriteBehindStore {
+	return &WriteBehindStore{store: s, jobs: make(map[string]*Record)}
+}
+
+func (s *WriteBehindStore) SaveJob(rec Record) error {
+	s.enqueue(func() { s.jobs[rec.ID] = &rec })
+	return nil
+}
+
+func (s *WriteBehindStore) DeleteJob(id string) error {
+	s.enqueue(func() { s.jobs[id] = nil })
+	return nil
+}
+
+func (s *WriteBehindStore) SaveStats(stats Stats) error {
+	s.enqueue(func() { s.stats = &stats })
+	return nil
+}
+
+func (s *WriteBehindStore) LoadJobs() ([]Record, error) {
+	s.Flush()
+	return s.store.LoadJobs()
+}
+
+func (s *WriteBehindStore) LoadStats() (Stats, error) {
+	s.Flush()
+	return s.store.LoadStats()
+}
+
+func (s *WriteBehindStore) enqueue(change func()) {
+	s.mu.Lock()
+	change()
+	if !s.running {
+		s.running = true
+		go s.run()
+	}
+	s.mu.Unlock()
+}
+
+// run writes queued changes until none are left.
+func (s *WriteBehindStore) run() {
+	for {
+		s.writeMu.Lock()
+		s.mu.Lock()
+		jobs, stats := s.takeLocked()
+		if len(jobs) == 0 && stats == nil {
+			s.running = false
+			s.mu.Unlock()
+			s.writeMu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+		s.write(jobs, stats)
+		s.writeMu.Unlock()
+	}
+}
+
+// Flush writes every queued change before returning.
+func (s *WriteBehindStore) Flush() {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	jobs, stats := s.takeLocked()
+	s.mu.Unlock()
+	s.write(jobs, stats)
+}
+
+func (s *WriteBehindStore) takeLocked() (map[string]*Record, *Stats) {
+	jobs, stats := s.jobs, s.stats
+	s.jobs, s.stats = make(map[string]*Record), nil
+	return jobs, stats
+}
+
+func (s *WriteBehindStore) write(jobs map[string]*Record, stats *Stats) {
+	for id, rec := range jobs {
+		if rec == nil {
+			if err := s.store.DeleteJob(id); err != nil {
+				logx.Log.Warn().Err(err).Str("job_id", id).Msg("delete persisted job")
+			}
+			continue
+		}
+		if err := s.store.SaveJob(*rec); err != nil {
+			logx.Log.Warn().Err(err).Str("job_id", id).Msg("persist job")
+		}
+	}
+	if stats != nil {
+		if err := s.store.SaveStats(*stats); err != nil {
+			logx.Log.Warn().Err(err).Msg("persist job stats")
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/gaspardpetit/nfrx/sdk/api/spi"
@@ -37,11 +39,46 @@ var (
 			Help: "Total number of failed jobs reported by agents",
 		},
 	)
+
+	// Transfer channel metrics
+	transferChannelsActive = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "nfrx_transfer_channels_active",
+			Help: "Number of open transfer channels",
+		},
+		[]string{"mode"},
+	)
+
+	transferChannelsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nfrx_transfer_channels_total",
+			Help: "Total number of closed transfer channels by outcome (ok, expired, closed, failed)",
+		},
+		[]string{"mode", "outcome"},
+	)
+
+	transferBytesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "nfrx_transfer_bytes_total",
+			Help: "Total bytes received from transfer writers (in) and sent to readers (out)",
+		},
+		[]string{"mode", "direction"},
+	)
+
+	transferDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "nfrx_transfer_duration_seconds",
+			Help:    "Lifetime of transfer channels, from creation to close",
+			Buckets: []float64{0.1, 0.5, 1, 5, 15, 60, 300, 900, 3600},
+		},
+		[]string{"mode", "outcome"},
+	)
 )
 
 // Register registers server-specific and agent-common metrics.
 func Register(r spi.MetricsRegistry) {
-	r.MustRegister(buildInfo, agentJobsInflight, agentJobsTotal, agentJobsFailedTotal)
+	r.MustRegister(buildInfo, agentJobsInflight, agentJobsTotal, agentJobsFailedTotal,
+		transferChannelsActive, transferChannelsTotal, transferBytesTotal, transferDuration)
 }
 
 // SetServerBuildInfo sets the build info metric for the server.
@@ -60,3 +97,21 @@ func AgentJobEnd(success bool) {
 		agentJobsFailedTotal.Inc()
 	}
 }
+
+// TransferOpened counts a new transfer channel as active.
+func TransferOpened(mode string) { transferChannelsActive.WithLabelValues(mode).Inc() }
+
+// TransferClosed records the outcome and lifetime of a transfer channel.
+func TransferClosed(mode, outcome string, lifetime time.Duration) {
+	transferChannelsActive.WithLabelValues(mode).Dec()
+	transferChannelsTotal.WithLabelValues(mode, outcome).Inc()
+	transferDuration.WithLabelValues(mode, outcome).Observe(lifetime.Seconds())
+}
+
+// TransferBytes adds n bytes moved through a transfer channel; direction is
+// "in" for bytes received from the writer and "out" for bytes sent to readers.
+func TransferBytes(mode, direction string, n int64) {
+	if n > 0 {
+		transferBytesTotal.WithLabelValues(mode, direction).Add(float64(n))
+	}
+}
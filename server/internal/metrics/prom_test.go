@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
@@ -42,6 +43,31 @@ func TestAgentCommonMetrics(t *testing.T) {
 	}
 }
 
+func TestTransferMetrics(t *testing.T) {
+	reg := testRegistry{prometheus.NewRegistry()}
+	Register(reg)
+	transferChannelsActive.Reset()
+	transferChannelsTotal.Reset()
+	transferBytesTotal.Reset()
+	transferDuration.Reset()
+	TransferOpened("stream")
+	TransferOpened("stream")
+	TransferBytes("stream", "in", 42)
+	TransferClosed("stream", "ok", time.Second)
+	if v := testutil.ToFloat64(transferChannelsActive.WithLabelValues("stream")); v != 1 {
+		t.Fatalf("active: %v", v)
+	}
+	if v := testutil.ToFloat64(transferChannelsTotal.WithLabelValues("stream", "ok")); v != 1 {
+		t.Fatalf("channels_total: %v", v)
+	}
+	if v := testutil.ToFloat64(transferBytesTotal.WithLabelValues("stream", "in")); v != 42 {
+		t.Fatalf("bytes_total: %v", v)
+	}
+	if n := testutil.CollectAndCount(transferDuration); n != 1 {
+		t.Fatalf("duration series: %d", n)
+	}
+}
+
 type testRegistry struct{ *prometheus.Registry }
 
 func (r testRegistry) MustRegister(cs ...spi.Collector) {
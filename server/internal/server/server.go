@@ -11,6 +11,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/gaspardpetit/nfrx/api/generated"
+	"github.com/gaspardpetit/nfrx/core/logx"
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
 	baseauth "github.com/gaspardpetit/nfrx/sdk/base/auth"
 	"github.com/gaspardpetit/nfrx/sdk/base/inflight"
 	"github.com/gaspardpetit/nfrx/server/internal/adapters"
@@ -25,6 +27,12 @@ import (
 
 // New constructs the HTTP handler for the server.
 func New(cfg config.ServerConfig, stateReg *serverstate.Registry, plugins []plugin.Plugin) http.Handler {
+	return NewWithJobStore(cfg, stateReg, plugins, nil)
+}
+
+// NewWithJobStore is New with jobs persisted to store; a nil store keeps
+// jobs in memory only.
+func NewWithJobStore(cfg config.ServerConfig, stateReg *serverstate.Registry, plugins []plugin.Plugin, store jobs.Store) http.Handler {
 	r := chi.NewRouter()
 	if len(cfg.AllowedOrigins) > 0 {
 		r.Use(cors.Handler(cors.Options{
@@ -80,12 +88,48 @@ func New(cfg config.ServerConfig, stateReg *serverstate.Registry, plugins []plug
 	impl := &api.API{StateReg: stateReg}
 	wrapper := generated.ServerInterfaceWrapper{Handler: impl}
 	transferReg := transfer.NewRegistry(60 * time.Second)
+	transferReg.SetSpool(cfg.TransferSpoolDir, cfg.TransferMaxBytes)
+	transferReg.SetTokenTTL(cfg.TransferTokenTTL)
+	transferReg.SetOriginPatterns(cfg.AllowedOrigins)
 	jobReg := jobs.NewRegistry(transferReg, cfg.JobsSSECloseDelay, cfg.JobsClientTTL)
+	jobReg.SetStore(store)
+	jobReg.SetPriorityAging(cfg.JobsPriorityAging)
+	jobReg.SetLease(cfg.JobsLeaseTTL, cfg.JobsMaxAttempts)
+	jobReg.SetJobRetention(cfg.JobsRetention)
+	if err := jobReg.SetWebhookAllowedNetworks(cfg.JobsWebhookAllowedNetworks); err != nil {
+		logx.Log.Error().Err(err).Msg("configure job webhook networks")
+	}
+	jobReg.SetPartialHistory(cfg.JobsPartialHistory)
+	if cfg.JobsResultDir != "" {
+		if spool, err := jobs.NewResultSpool(cfg.JobsResultDir, cfg.JobsResultTTL, cfg.JobsResultMaxBytes, cfg.JobsSpoolMaxBytes); err != nil {
+			logx.Log.Error().Err(err).Str("dir", cfg.JobsResultDir).Msg("open job result spool")
+		} else {
+			jobReg.SetResultSpool(spool)
+		}
+	}
+	for _, p := range plugins {
+		if u, ok := p.(spi.JobTrackerUser); ok {
+			u.SetJobTracker(jobReg)
+		}
+		if rp, ok := p.(spi.TransferRelayProvider); ok {
+			jobReg.AddTransferRelay(rp.TransferRelay())
+		}
+	}
+	if n, err := jobReg.Restore(cfg.JobsRecoveryPolicy); err != nil {
+		logx.Log.Error().Err(err).Msg("restore jobs")
+	} else if n > 0 {
+		logx.Log.Info().Int("jobs", n).Msg("restored jobs from store")
+	}
 	stateReg.Add(serverstate.Element{
 		ID:   "jobs",
 		Data: func() interface{} { return jobReg.StateSnapshot() },
 		HTML: func() string { return jobReg.StateHTML() },
 	})
+	stateReg.Add(serverstate.Element{
+		ID:   "transfer",
+		Data: func() interface{} { return transferReg.StateSnapshot() },
+		HTML: func() string { return transferReg.StateHTML() },
+	})
 
 	r.Get("/healthz", wrapper.GetHealthz)
 	r.Route("/api", func(ar chi.Router) {
@@ -103,14 +147,23 @@ func New(cfg config.ServerConfig, stateReg *serverstate.Registry, plugins []plug
 			if cfg.ClientKey != "" && cfg.ClientKey != cfg.APIKey {
 				secrets = append(secrets, cfg.ClientKey)
 			}
-			tr.Use(baseauth.BearerAnyOrRolesMiddleware(secrets, roles))
-			tr.Post("/", transferReg.HandleCreate)
-			tr.Get("/{channel_id}", func(w http.ResponseWriter, r *http.Request) {
+			auth := baseauth.BearerAnyOrRolesMiddleware(secrets, roles)
+			tr.With(auth).Post("/", transferReg.HandleCreate)
+			// Channel tokens stand in for the API keys on a single channel.
+			channelID := func(r *http.Request) string { return chi.URLParam(r, "channel_id") }
+			ch := tr.With(transferReg.TokenAuth(channelID, auth))
+			ch.Get("/{channel_id}", func(w http.ResponseWriter, r *http.Request) {
 				transferReg.HandleReader(w, r, chi.URLParam(r, "channel_id"))
 			})
-			tr.Post("/{channel_id}", func(w http.ResponseWriter, r *http.Request) {
+			ch.Post("/{channel_id}", func(w http.ResponseWriter, r *http.Request) {
 				transferReg.HandleWriter(w, r, chi.URLParam(r, "channel_id"))
 			})
+			ch.Head("/{channel_id}", func(w http.ResponseWriter, r *http.Request) {
+				transferReg.HandleStatus(w, r, chi.URLParam(r, "channel_id"))
+			})
+			tr.With(transferReg.WebSocketTokenAuth(channelID, auth)).Get("/{channel_id}/ws", func(w http.ResponseWriter, r *http.Request) {
+				transferReg.HandleWebSocket(w, r, chi.URLParam(r, "channel_id"))
+			})
 		})
 		ar.Route("/", func(jr chi.Router) {
 			clientRoles := append([]string{}, cfg.APIHTTPRoles...)
@@ -24,20 +24,31 @@ const redisKey = "nfrx:state"
 // NewRedisStore connects to the given Redis URL and returns a Store.
 // The underlying key is initialized to a default state if it does not exist.
 func NewRedisStore(addr string) (*redisStore, error) {
-	opts, err := parseRedisURL(addr)
+	c, err := NewRedisClient(addr)
 	if err != nil {
 		return nil, err
 	}
-	c := redis.NewUniversalClient(opts)
 	rs := &redisStore{client: c, key: redisKey, ctx: context.Background()}
-	if err := c.Ping(rs.ctx).Err(); err != nil {
-		return nil, err
-	}
 	b, _ := json.Marshal(State{Status: "not_ready"})
 	_ = c.SetNX(rs.ctx, rs.key, b, 0).Err()
 	return rs, nil
 }
 
+// NewRedisClient connects to the given Redis URL and verifies the connection.
+// It accepts the same URL forms as NewRedisStore.
+func NewRedisClient(addr string) (redis.UniversalClient, error) {
+	opts, err := parseRedisURL(addr)
+	if err != nil {
+		return nil, err
+	}
+	c := redis.NewUniversalClient(opts)
+	if err := c.Ping(context.Background()).Err(); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
 // parseRedisURL parses addr into UniversalOptions supporting single, cluster,
 // and sentinel Redis deployments. If no scheme is present, addr is treated as
 // a plain host:port string.
@@ -0,0 +1,682 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ModeStream   = "stream"
+	ModeBuffered = "buffered"
+)
+
+var ErrInvalidMode = errors.New("invalid transfer mode")
+
+// Options configures a channel created with CreateWith.
+type Options struct {
+	// Buffered spools the body to a temporary file instead of piping it, so
+	// that writers and readers can resume and several readers can fetch it.
+	Buffered bool
+	// Readers is the number of complete reads a buffered channel serves
+	// before it is closed, and the number of readers it accepts at once.
+	Readers int
+	// Size, ContentType and SHA256 (hex) declare the body the writer must
+	// send; the transfer fails when it does not match. Zero values are not
+	// checked.
+	Size        int64
+	ContentType string
+	SHA256      string
+	// ContentEncoding declares the encoding of the body, such as "gzip".
+	// Readers are served it as Content-Encoding; when empty they get the
+	// writer's Content-Encoding instead. Size and SHA256 apply to the
+	// encoded bytes.
+	ContentEncoding string
+	// MaxBytes caps the body of this channel (0 for no per-channel limit).
+	MaxBytes int64
+	// JobID is the job whose payload or result the channel carries; it is
+	// only reported in the state view.
+	JobID string
+}
+
+// OptionsFor validates a requested mode ("stream", "buffered" or empty for
+// stream) and reader count.
+func OptionsFor(mode string, readers int) (Options, error) {
+	switch strings.TrimSpace(mode) {
+	case "", ModeStream:
+		if readers > 1 {
+			return Options{}, ErrInvalidMode
+		}
+		return Options{}, nil
+	case ModeBuffered:
+		if readers < 0 {
+			return Options{}, ErrInvalidMode
+		}
+		return Options{Buffered: true, Readers: readers}, nil
+	default:
+		return Options{}, ErrInvalidMode
+	}
+}
+
+// Mode returns the name of the mode o selects.
+func (o Options) Mode() string {
+	if o.Buffered {
+		return ModeBuffered
+	}
+	return ModeStream
+}
+
+// spool is the state of a buffered channel, guarded by the channel's mutex.
+type spool struct {
+	file     *os.File
+	size     int64
+	total    int64 // -1 until the writer declares it
+	maxBytes int64
+	hash     *verifier
+	digest   string // set once complete
+	complete bool
+	writing  bool
+	readers  int
+	reads    int
+	maxReads int
+	// encoding is the Content-Encoding readers are served, known once the
+	// channel declared it or the first upload started.
+	encoding      string
+	encodingKnown bool
+	// changed is closed and replaced whenever size, total or complete change.
+	changed chan struct{}
+}
+
+func (sp *spool) notifyLocked() {
+	close(sp.changed)
+	sp.changed = make(chan struct{})
+}
+
+func (sp *spool) remove() {
+	_ = sp.file.Close()
+	_ = os.Remove(sp.file.Name())
+}
+
+// SetSpool sets where buffered channels keep their bodies (empty for the
+// system temp directory) and how large a body may grow (0 for no limit).
+func (r *Registry) SetSpool(dir string, maxBytes int64) {
+	r.mu.Lock()
+	r.spoolDir = dir
+	r.maxBytes = maxBytes
+	r.mu.Unlock()
+}
+
+// CreateWith opens a channel in the mode selected by opts. A buffered channel
+// stays open until it has served all its reads, it is closed, or nobody has
+// been attached to it for the TTL.
+func (r *Registry) CreateWith(opts Options) (string, time.Time, error) {
+	if err := opts.validate(); err != nil {
+		return "", time.Time{}, err
+	}
+	if !opts.Buffered {
+		id, expires := r.create(opts, nil)
+		return id, expires, nil
+	}
+	r.mu.Lock()
+	dir, maxBytes := r.spoolDir, r.maxBytes
+	r.mu.Unlock()
+	if limit := opts.limit(); limit >= 0 && (maxBytes <= 0 || limit < maxBytes) {
+		maxBytes = limit
+	}
+	f, err := os.CreateTemp(dir, "nfrx-transfer-*")
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	reads := opts.Readers
+	if reads <= 0 {
+		reads = 1
+	}
+	sp := &spool{file: f, total: -1, maxBytes: maxBytes, hash: newVerifier(-1), maxReads: reads, changed: make(chan struct{})}
+	if opts.ContentEncoding != "" {
+		sp.encoding, sp.encodingKnown = opts.contentEncoding(nil), true
+	}
+	id, expires := r.create(opts, sp)
+	return id, expires, nil
+}
+
+// HandleStatus answers HEAD requests with the progress of a channel, so that
+// an interrupted writer knows where to resume.
+func (r *Registry) HandleStatus(w http.ResponseWriter, _ *http.Request, id string) {
+	ch := r.lookup(id)
+	if ch == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.closed {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+	if ch.buf == nil {
+		w.Header().Set("X-Transfer-Mode", ModeStream)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	sp := ch.buf
+	w.Header().Set("X-Transfer-Mode", ModeBuffered)
+	w.Header().Set("X-Transfer-Offset", strconv.FormatInt(sp.size, 10))
+	if sp.total >= 0 {
+		w.Header().Set("X-Transfer-Length", strconv.FormatInt(sp.total, 10))
+	}
+	w.Header().Set("X-Transfer-Complete", strconv.FormatBool(sp.complete))
+	if sp.complete {
+		w.Header().Set(TrailerSHA256, sp.digest)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeBuffered appends a writer's body to the spool. A Content-Range header
+// resumes an earlier upload; bytes the channel already holds are skipped, so
+// resending the whole body also resumes.
+func (r *Registry) writeBuffered(w http.ResponseWriter, req *http.Request, ch *channel) {
+	start, total, ranged, err := parseContentRange(req.Header.Get("Content-Range"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_content_range"})
+		return
+	}
+	if !ranged && req.ContentLength >= 0 {
+		total = req.ContentLength
+	}
+	if err := ch.opts.checkWriter(req, total); err != nil {
+		writeError(w, err)
+		return
+	}
+	sp := ch.buf
+	ch.mu.Lock()
+	if ch.closed {
+		err := ch.closedErrLocked()
+		ch.mu.Unlock()
+		writeError(w, err)
+		return
+	}
+	if sp.writing {
+		ch.mu.Unlock()
+		writeError(w, ErrRoleTaken)
+		return
+	}
+	size := sp.size
+	if sp.complete {
+		ch.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "offset": size})
+		return
+	}
+	if start > size || (total >= 0 && sp.total >= 0 && total != sp.total) {
+		ch.mu.Unlock()
+		w.Header().Set("X-Transfer-Offset", strconv.FormatInt(size, 10))
+		writeJSON(w, http.StatusRequestedRangeNotSatisfiable, map[string]any{"error": "invalid_range", "offset": size})
+		return
+	}
+	if total >= 0 && sp.maxBytes > 0 && total > sp.maxBytes {
+		ch.mu.Unlock()
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]any{"error": "transfer_too_large"})
+		return
+	}
+	if enc := ch.opts.contentEncoding(req); !sp.encodingKnown {
+		sp.encoding, sp.encodingKnown = enc, true
+		sp.notifyLocked()
+	} else if enc != sp.encoding {
+		// A resumed upload must continue the same encoded body.
+		ch.mu.Unlock()
+		writeError(w, ErrContentEncoding)
+		return
+	}
+	if total >= 0 && sp.total < 0 {
+		sp.total = total
+		sp.notifyLocked()
+	}
+	limit := sp.total
+	if limit < 0 && sp.maxBytes > 0 {
+		limit = sp.maxBytes
+	}
+	sp.writing = true
+	ch.holdLocked()
+	ch.mu.Unlock()
+
+	stop := interrupt(ch, w, true)
+	size, err = ch.fill(req.Body, size, size-start, limit)
+	stop()
+
+	ch.mu.Lock()
+	sp.writing = false
+	if err == nil && !ranged && sp.total < 0 {
+		// A plain body that ended cleanly is the whole transfer.
+		sp.total = size
+	}
+	if sp.total >= 0 && size >= sp.total && !sp.complete {
+		// Readers only see the end of the body once it has been verified.
+		if err = sp.hash.check(ch.opts); err == nil {
+			sp.complete = true
+			sp.digest = sp.hash.digest()
+			sp.notifyLocked()
+		}
+	}
+	complete := sp.complete
+	closed := ch.closed
+	closedErr := ch.closedErrLocked()
+	r.idleLocked(ch)
+	ch.mu.Unlock()
+
+	switch {
+	case closed:
+		writeError(w, closedErr)
+	case errors.Is(err, ErrSizeMismatch) || errors.Is(err, ErrChecksumMismatch):
+		r.Close(ch.id, err)
+		writeError(w, err)
+	case errors.Is(err, ErrTooLarge):
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]any{"error": "transfer_too_large", "offset": size})
+	case complete:
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "offset": size})
+	default:
+		// Whatever arrived before the body ended is kept for a resume.
+		writeJSON(w, http.StatusAccepted, map[string]any{"status": "incomplete", "offset": size})
+	}
+}
+
+// fill skips the first skip bytes of body and appends the rest to the spool
+// at off, never growing it past limit (-1 for no limit). It returns the new
+// size of the spool.
+func (ch *channel) fill(body io.Reader, off, skip, limit int64) (int64, error) {
+	if skip > 0 {
+		if _, err := io.CopyN(io.Discard, body, skip); err != nil {
+			return off, err
+		}
+	}
+	sp := ch.buf
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			if limit >= 0 && off+int64(n) > limit {
+				return off, ErrTooLarge
+			}
+			if _, werr := sp.file.WriteAt(buf[:n], off); werr != nil {
+				return off, werr
+			}
+			off += int64(n)
+			ch.count(true, n)
+			// Resumed uploads append in order, so the running hash covers
+			// the whole body.
+			_, _ = sp.hash.Write(buf[:n])
+			ch.mu.Lock()
+			sp.size = off
+			sp.notifyLocked()
+			ch.mu.Unlock()
+		}
+		if errors.Is(err, io.EOF) {
+			return off, nil
+		}
+		if err != nil {
+			return off, err
+		}
+	}
+}
+
+// readBuffered serves the spooled body, or the part selected by a Range
+// header, streaming it as the writer uploads.
+func (r *Registry) readBuffered(w http.ResponseWriter, req *http.Request, ch *channel) {
+	rng, err := parseRange(req.Header.Get("Range"))
+	if err != nil {
+		writeJSON(w, http.StatusRequestedRangeNotSatisfiable, map[string]any{"error": "invalid_range"})
+		return
+	}
+	if err := r.attachReader(ch); err != nil {
+		writeError(w, err)
+		return
+	}
+	full := false
+	defer func() { r.detachReader(ch, full) }()
+
+	ctx := req.Context()
+	// The encoding goes in the header, so it must be known before serving
+	// any byte.
+	if err := ch.await(ctx, func(sp *spool) bool { return sp.encodingKnown }); err != nil {
+		writeError(w, err)
+		return
+	}
+	start, end, total := int64(0), int64(-1), int64(-1)
+	if rng != nil {
+		// The total is needed to resolve the range and answer with
+		// Content-Range.
+		if err := ch.await(ctx, func(sp *spool) bool { return sp.total >= 0 }); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	ch.mu.Lock()
+	total = ch.buf.total
+	complete, digest, encoding := ch.buf.complete, ch.buf.digest, ch.buf.encoding
+	ch.mu.Unlock()
+
+	w.Header().Set("Content-Type", ch.opts.contentType())
+	if encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+	if complete {
+		setTrailers(w, digest, total)
+	} else {
+		// The digest is only known at the end; trailers need a chunked
+		// response, so no Content-Length is sent.
+		declareTrailers(w)
+	}
+	status := http.StatusOK
+	if rng != nil {
+		var ok bool
+		start, end, ok = rng.resolve(total)
+		if !ok {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", total))
+			writeJSON(w, http.StatusRequestedRangeNotSatisfiable, map[string]any{"error": "invalid_range"})
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, total))
+		if complete {
+			w.Header().Set("Content-Length", strconv.FormatInt(end-start, 10))
+		}
+		status = http.StatusPartialContent
+	} else if total >= 0 {
+		end = total
+		if complete {
+			w.Header().Set("Content-Length", strconv.FormatInt(total, 10))
+		}
+	}
+	w.WriteHeader(status)
+
+	stop := interrupt(ch, w, false)
+	defer stop()
+	dst := io.Writer(w)
+	if flusher, ok := w.(http.Flusher); ok {
+		dst = &flushWriter{w: w, f: flusher}
+	}
+	if _, err := ch.copyFrom(ctx, dst, start, end); err != nil {
+		// Break the response so the reader cannot mistake it for a
+		// complete one.
+		panic(http.ErrAbortHandler)
+	}
+	// Only a read of the whole body uses up one of the channel's reads;
+	// ranges, even one resuming to the end, do not.
+	full = start == 0 && end == total
+	ch.mu.Lock()
+	if sp := ch.buf; !complete && sp.complete {
+		setTrailers(w, sp.digest, sp.size)
+	}
+	ch.mu.Unlock()
+}
+
+func (r *Registry) receiveBuffered(ctx context.Context, ch *channel, dst io.Writer) error {
+	if err := r.attachReader(ch); err != nil {
+		return err
+	}
+	full := false
+	defer func() { r.detachReader(ch, full) }()
+	if _, err := ch.copyFrom(ctx, dst, 0, -1); err != nil {
+		return err
+	}
+	if c, ok := dst.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			return err
+		}
+	}
+	full = true
+	return nil
+}
+
+func (r *Registry) attachReader(ch *channel) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	sp := ch.buf
+	switch {
+	case ch.closed:
+		return ch.closedErrLocked()
+	case sp.reads >= sp.maxReads:
+		return ErrClosed
+	case sp.readers >= sp.maxReads:
+		return ErrRoleTaken
+	}
+	sp.readers++
+	ch.holdLocked()
+	return nil
+}
+
+// detachReader releases a reader. Once the channel has served all its
+// complete reads and the last reader is gone it is closed.
+func (r *Registry) detachReader(ch *channel, full bool) {
+	ch.mu.Lock()
+	sp := ch.buf
+	sp.readers--
+	if full {
+		sp.reads++
+	}
+	done := sp.complete && sp.reads >= sp.maxReads && sp.readers == 0
+	if !done {
+		r.idleLocked(ch)
+	}
+	ch.mu.Unlock()
+	if done {
+		r.Close(ch.id, nil)
+	}
+}
+
+// holdLocked stops the expiry timer while someone is attached.
+func (ch *channel) holdLocked() {
+	if ch.timer != nil {
+		ch.timer.Stop()
+		ch.timer = nil
+	}
+}
+
+// idleLocked restarts the expiry timer of a buffered channel nobody is
+// attached to, so an interrupted transfer can resume within the TTL.
+func (r *Registry) idleLocked(ch *channel) {
+	sp := ch.buf
+	if ch.closed || sp.writing || sp.readers > 0 {
+		return
+	}
+	ch.holdLocked()
+	id := ch.id
+	ch.expiresAt = time.Now().Add(r.ttl)
+	ch.timer = time.AfterFunc(r.ttl, func() {
+		r.expire(id)
+	})
+}
+
+func (ch *channel) closedErrLocked() error {
+	if ch.err == nil {
+		return ErrClosed
+	}
+	return ch.err
+}
+
+// await blocks until cond holds for the spool, the channel is closed or ctx
+// is done.
+func (ch *channel) await(ctx context.Context, cond func(*spool) bool) error {
+	for {
+		ch.mu.Lock()
+		if ch.closed {
+			err := ch.closedErrLocked()
+			ch.mu.Unlock()
+			return err
+		}
+		if cond(ch.buf) {
+			ch.mu.Unlock()
+			return nil
+		}
+		changed := ch.buf.changed
+		ch.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ch.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// copyFrom copies the spooled bytes from off up to end (exclusive, -1 for
+// the end of the body) into dst, waiting for the writer as needed. It
+// returns the offset it reached.
+func (ch *channel) copyFrom(ctx context.Context, dst io.Writer, off, end int64) (int64, error) {
+	sp := ch.buf
+	buf := make([]byte, 32*1024)
+	for end < 0 || off < end {
+		var limit int64
+		var complete bool
+		err := ch.await(ctx, func(sp *spool) bool {
+			limit, complete = sp.size, sp.complete
+			if end >= 0 && limit > end {
+				limit = end
+			}
+			return limit > off || complete
+		})
+		if err != nil {
+			return off, err
+		}
+		if limit <= off {
+			if end >= 0 {
+				// The writer finished short of the requested range.
+				return off, io.ErrUnexpectedEOF
+			}
+			return off, nil
+		}
+		for off < limit {
+			n := min(int64(len(buf)), limit-off)
+			m, err := sp.file.ReadAt(buf[:n], off)
+			if m > 0 {
+				if _, werr := dst.Write(buf[:m]); werr != nil {
+					return off, werr
+				}
+				off += int64(m)
+				ch.count(false, m)
+			}
+			if err != nil && !errors.Is(err, io.EOF) {
+				return off, err
+			}
+		}
+	}
+	return off, nil
+}
+
+// interrupt unblocks the request body read (read) or the response write on w
+// once the channel is closed. The returned func stops watching.
+func interrupt(ch *channel, w http.ResponseWriter, read bool) func() {
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ch.done:
+			select {
+			case <-stop:
+				// The handler was done with w before the channel closed;
+				// a deadline now would break the next request on the
+				// connection.
+				return
+			default:
+			}
+			rc := http.NewResponseController(w)
+			if read {
+				_ = rc.SetReadDeadline(time.Now())
+			} else {
+				_ = rc.SetWriteDeadline(time.Now())
+			}
+		case <-stop:
+		}
+	}()
+	return func() { close(stop) }
+}
+
+// parseContentRange parses "bytes start-end/total" or "bytes start-end/*".
+// An empty header starts at zero with an unknown total.
+func parseContentRange(h string) (start, total int64, ranged bool, err error) {
+	if h == "" {
+		return 0, -1, false, nil
+	}
+	spec, ok := strings.CutPrefix(strings.TrimSpace(h), "bytes ")
+	if !ok {
+		return 0, 0, false, errors.New("unsupported unit")
+	}
+	span, size, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, false, errors.New("missing total")
+	}
+	first, _, ok := strings.Cut(span, "-")
+	if !ok {
+		return 0, 0, false, errors.New("missing range")
+	}
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil || start < 0 {
+		return 0, 0, false, errors.New("invalid start")
+	}
+	total = -1
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil || total < 0 {
+			return 0, 0, false, errors.New("invalid total")
+		}
+	}
+	return start, total, true, nil
+}
+
+// byteRange is a single range of a Range header: start-end, start- or, with
+// suffix set, the last end bytes.
+type byteRange struct {
+	start, end int64
+	suffix     bool
+}
+
+// parseRange parses a single-range "bytes=" header; an empty header yields
+// nil.
+func parseRange(h string) (*byteRange, error) {
+	if h == "" {
+		return nil, nil
+	}
+	spec, ok := strings.CutPrefix(strings.TrimSpace(h), "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil, errors.New("unsupported range")
+	}
+	first, last, ok := strings.Cut(spec, "-")
+	if !ok {
+		return nil, errors.New("invalid range")
+	}
+	rng := &byteRange{end: -1}
+	var err error
+	if first == "" {
+		rng.suffix = true
+		if rng.end, err = strconv.ParseInt(last, 10, 64); err != nil || rng.end <= 0 {
+			return nil, errors.New("invalid suffix")
+		}
+		return rng, nil
+	}
+	if rng.start, err = strconv.ParseInt(first, 10, 64); err != nil || rng.start < 0 {
+		return nil, errors.New("invalid start")
+	}
+	if last != "" {
+		if rng.end, err = strconv.ParseInt(last, 10, 64); err != nil || rng.end < rng.start {
+			return nil, errors.New("invalid end")
+		}
+	}
+	return rng, nil
+}
+
+// resolve returns the half-open span the range selects in a body of total
+// bytes, and false when it is not satisfiable.
+func (b *byteRange) resolve(total int64) (start, end int64, ok bool) {
+	if b.suffix {
+		return max(total-b.end, 0), total, total > 0
+	}
+	if b.start >= total {
+		return 0, 0, false
+	}
+	end = total
+	if b.end >= 0 && b.end+1 < total {
+		end = b.end + 1
+	}
+	return b.start, end, true
+}
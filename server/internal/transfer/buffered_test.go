@@ -0,0 +1,144 @@
+package transfer
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newBufferedServer(t *testing.T, readers int) (*Registry, *httptest.Server, string) {
+	t.Helper()
+	reg, srv := newTestServer(t)
+	id, _, err := reg.CreateWith(Options{Buffered: true, Readers: readers})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	return reg, srv, srv.URL + "/" + id
+}
+
+func newTestServer(t *testing.T) (*Registry, *httptest.Server) {
+	t.Helper()
+	reg := NewRegistry(time.Minute)
+	reg.SetSpool(t.TempDir(), 1<<20)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{id}", func(w http.ResponseWriter, r *http.Request) { reg.HandleReader(w, r, r.PathValue("id")) })
+	mux.HandleFunc("POST /{id}", func(w http.ResponseWriter, r *http.Request) { reg.HandleWriter(w, r, r.PathValue("id")) })
+	mux.HandleFunc("HEAD /{id}", func(w http.ResponseWriter, r *http.Request) { reg.HandleStatus(w, r, r.PathValue("id")) })
+	mux.HandleFunc("GET /{id}/ws", func(w http.ResponseWriter, r *http.Request) { reg.HandleWebSocket(w, r, r.PathValue("id")) })
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return reg, srv
+}
+
+func do(t *testing.T, method, url string, body string, header map[string]string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+func TestBufferedWriterResumesWithContentRange(t *testing.T) {
+	_, _, url := newBufferedServer(t, 1)
+	const body = "0123456789"
+
+	resp, out := do(t, http.MethodPost, url, body[:5], map[string]string{"Content-Range": "bytes 0-4/10"})
+	if resp.StatusCode != http.StatusAccepted || !strings.Contains(out, `"offset":5`) {
+		t.Fatalf("first chunk: %d %s", resp.StatusCode, out)
+	}
+	resp, _ = do(t, http.MethodHead, url, "", nil)
+	if got := resp.Header.Get("X-Transfer-Offset"); got != "5" {
+		t.Fatalf("offset %q", got)
+	}
+	resp, out = do(t, http.MethodPost, url, body[7:], map[string]string{"Content-Range": "bytes 7-9/10"})
+	if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("gap accepted: %d %s", resp.StatusCode, out)
+	}
+	// Overlapping bytes the channel already holds are skipped.
+	resp, out = do(t, http.MethodPost, url, body[3:], map[string]string{"Content-Range": "bytes 3-9/10"})
+	if resp.StatusCode != http.StatusOK || !strings.Contains(out, `"offset":10`) {
+		t.Fatalf("resume: %d %s", resp.StatusCode, out)
+	}
+	resp, out = do(t, http.MethodGet, url, "", nil)
+	if resp.StatusCode != http.StatusOK || out != body {
+		t.Fatalf("read: %d %q", resp.StatusCode, out)
+	}
+	resp, _ = do(t, http.MethodHead, url, "", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("channel still open after its read: %d", resp.StatusCode)
+	}
+}
+
+func TestBufferedRangeReadsAndFanOut(t *testing.T) {
+	_, _, url := newBufferedServer(t, 2)
+	body := bytes.Repeat([]byte("abcdefgh"), 8*1024)
+
+	// A reader attached before the writer follows the upload as it arrives.
+	early := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			early <- err.Error()
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		data, _ := io.ReadAll(resp.Body)
+		early <- string(data)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	resp, out := do(t, http.MethodPost, url, string(body), nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("upload: %d %s", resp.StatusCode, out)
+	}
+	if got := <-early; got != string(body) {
+		t.Fatalf("early reader got %d bytes", len(got))
+	}
+
+	// Only a read covering the whole body is complete; ranges short of the
+	// end or resuming to it are not.
+	resp, out = do(t, http.MethodGet, url, "", map[string]string{"Range": "bytes=2-5"})
+	if resp.StatusCode != http.StatusPartialContent || out != "cdef" {
+		t.Fatalf("range: %d %q", resp.StatusCode, out)
+	}
+	if got := resp.Header.Get("Content-Range"); got != "bytes 2-5/65536" {
+		t.Fatalf("content-range %q", got)
+	}
+	resp, _ = do(t, http.MethodGet, url, "", map[string]string{"Range": "bytes=70000-"})
+	if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("range past end: %d", resp.StatusCode)
+	}
+	resp, out = do(t, http.MethodGet, url, "", map[string]string{"Range": "bytes=60000-"})
+	if resp.StatusCode != http.StatusPartialContent || out != string(body[60000:]) {
+		t.Fatalf("resumed read: %d %d bytes", resp.StatusCode, len(out))
+	}
+	resp, out = do(t, http.MethodGet, url, "", map[string]string{"Range": "bytes=0-"})
+	if resp.StatusCode != http.StatusPartialContent || out != string(body) {
+		t.Fatalf("whole-body range: %d %d bytes", resp.StatusCode, len(out))
+	}
+	resp, _ = do(t, http.MethodGet, url, "", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("channel still open after two complete reads: %d", resp.StatusCode)
+	}
+}
+
+func TestBufferedUploadRejectsOversizedBody(t *testing.T) {
+	_, _, url := newBufferedServer(t, 1)
+	resp, out := do(t, http.MethodPost, url, strings.Repeat("x", 2<<20), nil)
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized upload: %d %s", resp.StatusCode, out)
+	}
+}
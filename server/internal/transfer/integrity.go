@@ -0,0 +1,158 @@
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Trailers sent to readers once the whole body has gone through.
+const (
+	TrailerSHA256 = "X-Transfer-Sha256"
+	TrailerSize   = "X-Transfer-Size"
+)
+
+var (
+	ErrInvalidOptions   = errors.New("invalid transfer options")
+	ErrTooLarge         = errors.New("transfer too large")
+	ErrSizeMismatch     = errors.New("transfer size mismatch")
+	ErrChecksumMismatch = errors.New("transfer checksum mismatch")
+	ErrContentType      = errors.New("transfer content type mismatch")
+	ErrContentEncoding  = errors.New("transfer content encoding mismatch")
+)
+
+func (o Options) validate() error {
+	if o.Size < 0 || o.MaxBytes < 0 || (o.MaxBytes > 0 && o.Size > o.MaxBytes) {
+		return ErrInvalidOptions
+	}
+	if o.SHA256 != "" {
+		if b, err := hex.DecodeString(o.SHA256); err != nil || len(b) != sha256.Size {
+			return ErrInvalidOptions
+		}
+	}
+	if o.ContentType != "" {
+		if _, _, err := mime.ParseMediaType(o.ContentType); err != nil {
+			return ErrInvalidOptions
+		}
+	}
+	if strings.ContainsAny(o.ContentEncoding, " \t,;") {
+		return ErrInvalidOptions
+	}
+	return nil
+}
+
+// limit is the most bytes the channel accepts, or -1 for no limit.
+func (o Options) limit() int64 {
+	switch {
+	case o.Size > 0:
+		return o.Size
+	case o.MaxBytes > 0:
+		return o.MaxBytes
+	}
+	return -1
+}
+
+func (o Options) contentType() string {
+	if o.ContentType == "" {
+		return "application/octet-stream"
+	}
+	return o.ContentType
+}
+
+// contentEncoding is the encoding the writer of req declares for its body,
+// falling back to the one the channel declared; "" stands for identity.
+func (o Options) contentEncoding(req *http.Request) string {
+	enc := ""
+	if req != nil {
+		enc = strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+	}
+	if enc == "" {
+		enc = strings.ToLower(o.ContentEncoding)
+	}
+	if enc == "identity" {
+		return ""
+	}
+	return enc
+}
+
+// checkWriter rejects a writer whose headers contradict what the channel
+// declared, before any byte is read. length is the body size the writer
+// announced, or -1.
+func (o Options) checkWriter(req *http.Request, length int64) error {
+	if got := req.Header.Get("Content-Type"); o.ContentType != "" && got != "" {
+		gotType, _, _ := mime.ParseMediaType(got)
+		wantType, _, _ := mime.ParseMediaType(o.ContentType)
+		if gotType != "application/octet-stream" && !strings.EqualFold(gotType, wantType) {
+			return ErrContentType
+		}
+	}
+	if o.ContentEncoding != "" && o.contentEncoding(req) != o.contentEncoding(nil) {
+		return ErrContentEncoding
+	}
+	if length < 0 {
+		return nil
+	}
+	if o.Size > 0 && length != o.Size {
+		return ErrSizeMismatch
+	}
+	if o.MaxBytes > 0 && length > o.MaxBytes {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// verifier counts and hashes the bytes written to it and refuses to grow past
+// max (-1 for no limit).
+type verifier struct {
+	hash hash.Hash
+	n    int64
+	max  int64
+}
+
+func newVerifier(max int64) *verifier {
+	return &verifier{hash: sha256.New(), max: max}
+}
+
+func (v *verifier) Write(p []byte) (int, error) {
+	if v.max >= 0 && v.n+int64(len(p)) > v.max {
+		return 0, ErrTooLarge
+	}
+	v.n += int64(len(p))
+	return v.hash.Write(p)
+}
+
+func (v *verifier) digest() string {
+	return hex.EncodeToString(v.hash.Sum(nil))
+}
+
+// check compares the complete body with what the channel declared.
+func (v *verifier) check(o Options) error {
+	if o.Size > 0 && v.n != o.Size {
+		return ErrSizeMismatch
+	}
+	if o.SHA256 != "" && !strings.EqualFold(v.digest(), o.SHA256) {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// declareTrailers announces the digest trailers; they must be declared
+// before the header is written.
+func declareTrailers(w http.ResponseWriter) {
+	w.Header().Set("Trailer", TrailerSHA256+", "+TrailerSize)
+}
+
+func setTrailers(w http.ResponseWriter, digest string, size int64) {
+	w.Header().Set(TrailerSHA256, digest)
+	w.Header().Set(TrailerSize, strconv.FormatInt(size, 10))
+}
+
+// isIntegrityError reports whether err rejects the body the writer sent.
+func isIntegrityError(err error) bool {
+	return errors.Is(err, ErrTooLarge) || errors.Is(err, ErrSizeMismatch) || errors.Is(err, ErrChecksumMismatch) || errors.Is(err, ErrContentType) || errors.Is(err, ErrContentEncoding)
+}
@@ -0,0 +1,169 @@
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func sum(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+// streamRead starts a reader on a streaming channel and returns the body it
+// got, its digest trailer and any read error.
+func streamRead(url string) <-chan [3]string {
+	out := make(chan [3]string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			out <- [3]string{"", "", err.Error()}
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		data, err := io.ReadAll(resp.Body)
+		errMsg := ""
+		if err != nil {
+			errMsg = err.Error()
+		}
+		out <- [3]string{string(data), resp.Trailer.Get(TrailerSHA256), errMsg}
+	}()
+	return out
+}
+
+func TestStreamTransferSendsDigestTrailer(t *testing.T) {
+	reg, srv := newTestServer(t)
+	const body = "hello world"
+	id, _, err := reg.CreateWith(Options{SHA256: sum(body), ContentType: "audio/wav"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	url := srv.URL + "/" + id
+	got := streamRead(url)
+	resp, out := do(t, http.MethodPost, url, body, map[string]string{"Content-Type": "audio/wav"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("upload: %d %s", resp.StatusCode, out)
+	}
+	res := <-got
+	if res[0] != body || res[1] != sum(body) || res[2] != "" {
+		t.Fatalf("reader got %q digest %q err %q", res[0], res[1], res[2])
+	}
+}
+
+func TestStreamTransferFailsOnChecksumMismatch(t *testing.T) {
+	reg, srv := newTestServer(t)
+	id, _, err := reg.CreateWith(Options{SHA256: sum("expected")})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	url := srv.URL + "/" + id
+	got := streamRead(url)
+	resp, out := do(t, http.MethodPost, url, "tampered", nil)
+	if resp.StatusCode != http.StatusUnprocessableEntity || !strings.Contains(out, "checksum_mismatch") {
+		t.Fatalf("upload: %d %s", resp.StatusCode, out)
+	}
+	if res := <-got; res[2] == "" {
+		t.Fatalf("reader accepted a body failing its checksum: %q", res[0])
+	}
+}
+
+func TestTransferRejectsWritersContradictingDeclarations(t *testing.T) {
+	reg, srv := newTestServer(t)
+	for name, tc := range map[string]struct {
+		opts   Options
+		header map[string]string
+		status int
+	}{
+		"size":         {Options{Size: 4}, nil, http.StatusUnprocessableEntity},
+		"max bytes":    {Options{MaxBytes: 4}, nil, http.StatusRequestEntityTooLarge},
+		"content type": {Options{ContentType: "audio/wav"}, map[string]string{"Content-Type": "text/plain"}, http.StatusUnsupportedMediaType},
+		"buffered max": {Options{Buffered: true, MaxBytes: 4}, nil, http.StatusRequestEntityTooLarge},
+		"encoding":     {Options{ContentEncoding: "zstd"}, map[string]string{"Content-Encoding": "br"}, http.StatusUnsupportedMediaType},
+	} {
+		id, _, err := reg.CreateWith(tc.opts)
+		if err != nil {
+			t.Fatalf("%s: create: %v", name, err)
+		}
+		if resp, out := do(t, http.MethodPost, srv.URL+"/"+id, "too long", tc.header); resp.StatusCode != tc.status {
+			t.Fatalf("%s: %d %s", name, resp.StatusCode, out)
+		}
+	}
+	if _, _, err := reg.CreateWith(Options{SHA256: "not-hex"}); err != ErrInvalidOptions {
+		t.Fatalf("invalid digest accepted: %v", err)
+	}
+	if _, _, err := reg.CreateWith(Options{ContentEncoding: "gzip, br"}); err != ErrInvalidOptions {
+		t.Fatalf("invalid encoding accepted: %v", err)
+	}
+}
+
+func TestTransferPassesContentEncodingToReaders(t *testing.T) {
+	reg, srv := newTestServer(t)
+	// Encodings the HTTP client does not decode by itself, so the header
+	// reaches the test.
+	const body = "opaque zstd frame"
+	id, _, _ := reg.CreateWith(Options{})
+	url := srv.URL + "/" + id
+	got := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			got <- err.Error()
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		got <- resp.Header.Get("Content-Encoding")
+	}()
+	if resp, out := do(t, http.MethodPost, url, body, map[string]string{"Content-Encoding": "zstd"}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("upload: %d %s", resp.StatusCode, out)
+	}
+	if enc := <-got; enc != "zstd" {
+		t.Fatalf("stream reader encoding = %q", enc)
+	}
+
+	id, _, _ = reg.CreateWith(Options{Buffered: true})
+	url = srv.URL + "/" + id
+	do(t, http.MethodPost, url, body[:4], map[string]string{"Content-Range": "bytes 0-3/17", "Content-Encoding": "zstd"})
+	resp, out := do(t, http.MethodPost, url, body[4:], map[string]string{"Content-Range": "bytes 4-16/17", "Content-Encoding": "br"})
+	if resp.StatusCode != http.StatusUnsupportedMediaType || !strings.Contains(out, "content_encoding_mismatch") {
+		t.Fatalf("resume with another encoding: %d %s", resp.StatusCode, out)
+	}
+	do(t, http.MethodPost, url, body[4:], map[string]string{"Content-Range": "bytes 4-16/17", "Content-Encoding": "zstd"})
+	resp, out = do(t, http.MethodGet, url, "", nil)
+	if out != body || resp.Header.Get("Content-Encoding") != "zstd" {
+		t.Fatalf("buffered read %q encoding %q", out, resp.Header.Get("Content-Encoding"))
+	}
+}
+
+func TestBufferedTransferVerifiesResumedUpload(t *testing.T) {
+	reg, srv := newTestServer(t)
+	const body = "0123456789"
+	id, _, err := reg.CreateWith(Options{Buffered: true, SHA256: sum(body)})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	url := srv.URL + "/" + id
+	do(t, http.MethodPost, url, body[:4], map[string]string{"Content-Range": "bytes 0-3/10"})
+	resp, out := do(t, http.MethodPost, url, body[4:], map[string]string{"Content-Range": "bytes 4-9/10"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("resume: %d %s", resp.StatusCode, out)
+	}
+	resp, out = do(t, http.MethodGet, url, "", nil)
+	if out != body || resp.Header.Get(TrailerSHA256) != sum(body) {
+		t.Fatalf("read %q digest %q", out, resp.Header.Get(TrailerSHA256))
+	}
+
+	id, _, _ = reg.CreateWith(Options{Buffered: true, SHA256: sum(body)})
+	url = srv.URL + "/" + id
+	resp, out = do(t, http.MethodPost, url, "9876543210", nil)
+	if resp.StatusCode != http.StatusUnprocessableEntity || !strings.Contains(out, "checksum_mismatch") {
+		t.Fatalf("tampered upload: %d %s", resp.StatusCode, out)
+	}
+	if resp, _ = do(t, http.MethodGet, url, "", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("tampered channel still readable: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,249 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/gaspardpetit/nfrx/server/internal/metrics"
+)
+
+// maxStateChannels caps the channels listed in the state view.
+const maxStateChannels = 100
+
+// StateView describes the open channels for the server state page.
+type StateView struct {
+	Summary  StateSummary  `json:"summary"`
+	Channels []ChannelView `json:"channels"`
+}
+
+type StateSummary struct {
+	ActiveChannels   int   `json:"active_channels"`
+	StreamChannels   int   `json:"stream_channels"`
+	BufferedChannels int   `json:"buffered_channels"`
+	WaitingChannels  int   `json:"waiting_channels"`
+	BytesReceived    int64 `json:"bytes_received"`
+	OldestSeconds    int64 `json:"oldest_seconds"`
+}
+
+// ChannelView is one open channel. State is "waiting" until both sides of a
+// streaming channel are attached, then "streaming"; a buffered channel is
+// "waiting" for its writer, "uploading" while one is attached and "complete"
+// once the body is stored.
+type ChannelView struct {
+	ID         string `json:"id"`
+	JobID      string `json:"job_id,omitempty"`
+	Mode       string `json:"mode"`
+	State      string `json:"state"`
+	AgeSeconds int64  `json:"age_seconds"`
+	Bytes      int64  `json:"bytes"`
+	Size       int64  `json:"size,omitempty"`
+	Readers    int    `json:"readers"`
+	Writer     bool   `json:"writer"`
+	ExpiresAt  string `json:"expires_at,omitempty"`
+}
+
+func (r *Registry) StateSnapshot() StateView {
+	r.mu.Lock()
+	channels := make([]*channel, 0, len(r.channels))
+	for _, ch := range r.channels {
+		channels = append(channels, ch)
+	}
+	r.mu.Unlock()
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].createdAt.Before(channels[j].createdAt)
+	})
+
+	now := time.Now()
+	state := StateView{Channels: make([]ChannelView, 0, min(len(channels), maxStateChannels))}
+	for _, ch := range channels {
+		view := ch.view(now)
+		state.Summary.ActiveChannels++
+		if ch.buf != nil {
+			state.Summary.BufferedChannels++
+		} else {
+			state.Summary.StreamChannels++
+		}
+		if view.State == "waiting" {
+			state.Summary.WaitingChannels++
+		}
+		state.Summary.BytesReceived += view.Bytes
+		if view.AgeSeconds > state.Summary.OldestSeconds {
+			state.Summary.OldestSeconds = view.AgeSeconds
+		}
+		if len(state.Channels) < maxStateChannels {
+			state.Channels = append(state.Channels, view)
+		}
+	}
+	return state
+}
+
+func (ch *channel) view(now time.Time) ChannelView {
+	view := ChannelView{
+		ID:         ch.id,
+		JobID:      ch.opts.JobID,
+		Mode:       ch.opts.Mode(),
+		AgeSeconds: int64(now.Sub(ch.createdAt) / time.Second),
+		Bytes:      ch.received.Load(),
+		Size:       ch.opts.Size,
+	}
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if sp := ch.buf; sp != nil {
+		view.Readers = sp.readers
+		view.Writer = sp.writing
+		switch {
+		case sp.complete:
+			view.State = "complete"
+		case sp.writing:
+			view.State = "uploading"
+		default:
+			view.State = "waiting"
+		}
+		return view
+	}
+	if ch.reader != nil {
+		view.Readers = 1
+	}
+	view.Writer = ch.writer != nil
+	view.State = "waiting"
+	if view.Readers == 1 && view.Writer {
+		view.State = "streaming"
+	}
+	if ch.timer != nil {
+		// Streaming channels expire unless both sides attach in time.
+		view.ExpiresAt = ch.expiresAt.UTC().Format(time.RFC3339)
+	}
+	return view
+}
+
+// count records n bytes taken from the writer (in) or handed to a reader.
+func (ch *channel) count(in bool, n int) {
+	direction := "out"
+	if in {
+		direction = "in"
+		ch.received.Add(int64(n))
+	}
+	metrics.TransferBytes(ch.opts.Mode(), direction, int64(n))
+}
+
+// meter counts the bytes written through it on its channel.
+type meter struct {
+	w  io.Writer
+	ch *channel
+	in bool
+}
+
+func (m *meter) Write(p []byte) (int, error) {
+	n, err := m.w.Write(p)
+	m.ch.count(m.in, n)
+	return n, err
+}
+
+// outcome classifies the error a channel was closed with for metrics.
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrExpired):
+		return "expired"
+	case errors.Is(err, ErrClosed), errors.Is(err, ErrCanceled), errors.Is(err, context.Canceled):
+		return "closed"
+	}
+	return "failed"
+}
+
+func (r *Registry) StateHTML() string {
+	return `
+<div class="transfer-view">
+  <style>
+    .transfer-view { display: grid; gap: 1rem; }
+    .transfer-summary { display: grid; gap: 0.75rem; grid-template-columns: repeat(auto-fit, minmax(140px, 1fr)); }
+    .transfer-card, .transfer-panel { border: 1px solid var(--border); border-radius: var(--radius-md); background: var(--panel-strong); }
+    .transfer-card { padding: 0.85rem 0.95rem; }
+    .transfer-k { color: var(--muted); font-size: 0.76rem; letter-spacing: 0.06em; text-transform: uppercase; }
+    .transfer-v { margin-top: 0.28rem; font-size: 1.45rem; font-weight: 700; line-height: 1; }
+    .transfer-sub { margin-top: 0.25rem; color: var(--muted); font-size: 0.84rem; }
+    .transfer-panel { padding: 0.95rem 1rem; }
+    .transfer-panel h4 { margin: 0 0 0.75rem; font-size: 0.96rem; }
+    .transfer-pill { display: inline-flex; align-items: center; padding: 0.16rem 0.46rem; border-radius: 999px; border: 1px solid var(--border); font-size: 0.76rem; color: var(--muted); }
+    .transfer-pill.live { color: var(--ok); }
+    .transfer-pill.warn { color: var(--warn); }
+    .transfer-table { width: 100%; border-collapse: collapse; font-size: 0.88rem; }
+    .transfer-table th, .transfer-table td { text-align: left; padding: 0.56rem 0.45rem; border-bottom: 1px solid var(--border); vertical-align: top; }
+    .transfer-table th { color: var(--muted); font-size: 0.74rem; text-transform: uppercase; letter-spacing: 0.06em; }
+    .transfer-table td { color: var(--text); }
+    .transfer-table code { font-family: var(--mono); font-size: 0.79rem; }
+    .transfer-empty { color: var(--muted); font-size: 0.9rem; }
+  </style>
+  <div class="transfer-summary"></div>
+  <section class="transfer-panel">
+    <h4>Open Channels</h4>
+    <div class="transfer-channels"></div>
+  </section>
+  <script>(function(){
+    function esc(v){ return String(v == null ? '' : v).replace(/[&<>"]/g, function(c){ return ({'&':'&amp;','<':'&lt;','>':'&gt;','"':'&quot;'})[c]; }); }
+    function secs(v){
+      v = Number(v || 0);
+      if (!isFinite(v) || v <= 0) return '0s';
+      if (v < 60) return Math.round(v) + 's';
+      if (v < 3600) return Math.floor(v/60) + 'm';
+      return Math.floor(v/3600) + 'h';
+    }
+    function bytes(v){
+      v = Number(v || 0);
+      var units = ['B','KiB','MiB','GiB','TiB'];
+      var i = 0;
+      while (v >= 1024 && i < units.length - 1) { v /= 1024; i++; }
+      return (i ? v.toFixed(v < 10 ? 1 : 0) : v) + ' ' + units[i];
+    }
+    function stateTone(s){
+      if (s === 'streaming' || s === 'uploading') return 'live';
+      if (s === 'waiting') return 'warn';
+      return '';
+    }
+    function render(state, container){
+      state = state || {};
+      var summary = state.summary || {};
+      var channels = state.channels || [];
+      var summaryHost = container.querySelector('.transfer-summary');
+      if (summaryHost) {
+        summaryHost.innerHTML = [
+          ['Open Channels', summary.active_channels || 0, (summary.stream_channels || 0) + ' stream, ' + (summary.buffered_channels || 0) + ' buffered'],
+          ['Waiting', summary.waiting_channels || 0, 'Missing a reader or writer'],
+          ['Received', bytes(summary.bytes_received), 'Bytes taken by open channels'],
+          ['Oldest', secs(summary.oldest_seconds), 'Age of the oldest open channel']
+        ].map(function(card){
+          return '<article class="transfer-card"><div class="transfer-k">'+card[0]+'</div><div class="transfer-v">'+card[1]+'</div><div class="transfer-sub">'+card[2]+'</div></article>';
+        }).join('');
+      }
+      var host = container.querySelector('.transfer-channels');
+      if (!host) return;
+      if (!channels.length) {
+        host.innerHTML = '<div class="transfer-empty">No open channels.</div>';
+        return;
+      }
+      host.innerHTML = '<table class="transfer-table"><thead><tr><th>State</th><th>Channel</th><th>Job</th><th>Age</th><th>Bytes</th><th>Attached</th></tr></thead><tbody>' +
+        channels.map(function(c){
+          var size = c.size ? ' / ' + bytes(c.size) : '';
+          var attached = (c.writer ? 'writer' : 'no writer') + ', ' + (c.readers || 0) + ' reader' + (c.readers === 1 ? '' : 's');
+          return '<tr>' +
+            '<td><span class="transfer-pill '+stateTone(c.state)+'">'+esc(c.state || '')+'</span></td>' +
+            '<td><div>'+esc(c.mode || '')+'</div><code>'+esc(c.id || '')+'</code></td>' +
+            '<td><code>'+esc(c.job_id || '-')+'</code></td>' +
+            '<td>'+esc(secs(c.age_seconds))+'</td>' +
+            '<td>'+esc(bytes(c.bytes) + size)+'</td>' +
+            '<td>'+esc(attached)+'</td>' +
+          '</tr>';
+        }).join('') +
+        '</tbody></table>';
+    }
+    if (!window.NFRX) window.NFRX = { _renderers:{}, registerRenderer:function(id,fn){ this._renderers[id]=fn; } };
+    var section = (document.currentScript && document.currentScript.closest('section')) || null;
+    var id = (section && section.dataset && section.dataset.pluginId) || 'transfer';
+    window.NFRX.registerRenderer(id, function(state, container){ render(state, container); });
+  })();</script>
+</div>`
+}
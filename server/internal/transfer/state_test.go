@@ -0,0 +1,55 @@
+package transfer
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStateSnapshotListsOpenChannels(t *testing.T) {
+	reg, srv := newTestServer(t)
+	streamID, _, _ := reg.CreateWith(Options{JobID: "job-1"})
+	bufferedID, _, _ := reg.CreateWith(Options{Buffered: true, Readers: 1})
+	if resp, out := do(t, http.MethodPost, srv.URL+"/"+bufferedID, "hello", map[string]string{"Content-Range": "bytes 0-4/10"}); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("partial upload: %d %s", resp.StatusCode, out)
+	}
+	go func() {
+		resp, err := http.Get(srv.URL + "/" + streamID)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for reg.lookup(streamID).view(time.Now()).Readers == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	state := reg.StateSnapshot()
+	if state.Summary.ActiveChannels != 2 || state.Summary.StreamChannels != 1 || state.Summary.BufferedChannels != 1 {
+		t.Fatalf("summary %+v", state.Summary)
+	}
+	if state.Summary.BytesReceived != 5 || state.Summary.WaitingChannels != 2 {
+		t.Fatalf("summary %+v", state.Summary)
+	}
+	byID := map[string]ChannelView{}
+	for _, c := range state.Channels {
+		byID[c.ID] = c
+	}
+	if c := byID[streamID]; c.JobID != "job-1" || c.Mode != ModeStream || c.Readers != 1 || c.Writer || c.State != "waiting" || c.ExpiresAt == "" {
+		t.Fatalf("stream channel %+v", c)
+	}
+	if c := byID[bufferedID]; c.Mode != ModeBuffered || c.Bytes != 5 || c.Writer || c.State != "waiting" {
+		t.Fatalf("buffered channel %+v", c)
+	}
+
+	if resp, out := do(t, http.MethodPost, srv.URL+"/"+streamID, "data", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("upload: %d %s", resp.StatusCode, out)
+	}
+	if state := reg.StateSnapshot(); state.Summary.ActiveChannels != 1 {
+		t.Fatalf("closed channel still listed: %+v", state.Summary)
+	}
+	if html := reg.StateHTML(); !strings.Contains(html, "transfer-channels") {
+		t.Fatalf("state html missing channel list")
+	}
+}
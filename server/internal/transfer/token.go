@@ -0,0 +1,139 @@
+package transfer
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTokenTTL is how long channel tokens stay valid unless SetTokenTTL
+// says otherwise.
+const DefaultTokenTTL = 15 * time.Minute
+
+// TokenHeader carries a channel token; the "token" query parameter is
+// accepted as well, for clients that can only be handed a URL.
+const TokenHeader = "X-Transfer-Token"
+
+var ErrInvalidToken = errors.New("invalid transfer token")
+
+func newTokenKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("transfer: read random token key: " + err.Error())
+	}
+	return key
+}
+
+// SetTokenTTL sets how long the tokens handed out for new channels stay
+// valid.
+func (r *Registry) SetTokenTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	r.mu.Lock()
+	r.tokenTTL = ttl
+	r.mu.Unlock()
+}
+
+// Token returns a capability token granting role on channel id, and its
+// expiry. Tokens are signed with a key generated at startup, so they are only
+// valid on this server and do not outlive it, like the channels themselves.
+func (r *Registry) Token(id string, role Role) (string, time.Time) {
+	r.mu.Lock()
+	ttl := r.tokenTTL
+	r.mu.Unlock()
+	expires := time.Now().Add(ttl)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	tag := roleTag(role)
+	return tag + "." + exp + "." + r.sign(id, tag, exp), expires
+}
+
+func (r *Registry) sign(id, tag, exp string) string {
+	mac := hmac.New(sha256.New, r.tokenKey)
+	mac.Write([]byte(id + "\n" + tag + "\n" + exp))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func roleTag(role Role) string {
+	if role == Writer {
+		return "w"
+	}
+	return "r"
+}
+
+// checkToken verifies token for channel id. GET requires a reader token and
+// POST a writer token; HEAD, which reports progress, accepts either.
+func (r *Registry) checkToken(token, id, method string) error {
+	tag, rest, _ := strings.Cut(token, ".")
+	exp, sig, ok := strings.Cut(rest, ".")
+	if !ok || (tag != "r" && tag != "w") {
+		return ErrInvalidToken
+	}
+	switch method {
+	case http.MethodGet:
+		ok = tag == "r"
+	case http.MethodPost:
+		ok = tag == "w"
+	case http.MethodHead:
+		ok = true
+	default:
+		ok = false
+	}
+	if !ok || !hmac.Equal([]byte(sig), []byte(r.sign(id, tag, exp))) {
+		return ErrInvalidToken
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// TokenAuth lets requests carrying a channel token through on its own and
+// sends the others through fallback, the regular API authentication. A token
+// that does not grant access to the channel is refused outright. channelID
+// extracts the channel from the request.
+func (r *Registry) TokenAuth(channelID func(*http.Request) string, fallback func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return r.tokenAuth(channelID, fallback, func(req *http.Request) string { return req.Method })
+}
+
+// WebSocketTokenAuth is TokenAuth for the WebSocket route, where the role
+// query parameter picks the token role: a writer sends the body like a POST,
+// although its upgrade request is a GET.
+func (r *Registry) WebSocketTokenAuth(channelID func(*http.Request) string, fallback func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return r.tokenAuth(channelID, fallback, func(req *http.Request) string {
+		if req.URL.Query().Get("role") == "writer" {
+			return http.MethodPost
+		}
+		return http.MethodGet
+	})
+}
+
+// tokenAuth checks tokens against the role of the method that method maps
+// each request to.
+func (r *Registry) tokenAuth(channelID func(*http.Request) string, fallback func(http.Handler) http.Handler, method func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		authed := fallback(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			token := req.Header.Get(TokenHeader)
+			if token == "" {
+				token = req.URL.Query().Get("token")
+			}
+			if token == "" {
+				authed.ServeHTTP(w, req)
+				return
+			}
+			if err := r.checkToken(token, channelID(req), method(req)); err != nil {
+				writeJSON(w, http.StatusForbidden, map[string]any{"error": "invalid_transfer_token"})
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package transfer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenAuthGrantsOnlyItsChannelAndRole(t *testing.T) {
+	reg := NewRegistry(time.Minute)
+	denied := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusUnauthorized) })
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	h := reg.TokenAuth(func(r *http.Request) string { return strings.TrimPrefix(r.URL.Path, "/") }, denied)(ok)
+
+	id, _ := reg.Create()
+	other, _ := reg.Create()
+	reader, _ := reg.Token(id, Reader)
+	writer, _ := reg.Token(id, Writer)
+	// SetTokenTTL refuses non-positive values.
+	reg.tokenTTL = -time.Second
+	expired, _ := reg.Token(id, Reader)
+
+	for _, tc := range []struct {
+		method, path, token string
+		header              bool
+		want                int
+	}{
+		{http.MethodGet, "/" + id, reader, false, http.StatusOK},
+		{http.MethodGet, "/" + id, reader, true, http.StatusOK},
+		{http.MethodPost, "/" + id, writer, true, http.StatusOK},
+		{http.MethodHead, "/" + id, writer, true, http.StatusOK},
+		{http.MethodPost, "/" + id, reader, true, http.StatusForbidden},
+		{http.MethodGet, "/" + other, reader, true, http.StatusForbidden},
+		{http.MethodGet, "/" + id, expired, true, http.StatusForbidden},
+		{http.MethodGet, "/" + id, reader[:len(reader)-2] + "xx", true, http.StatusForbidden},
+		{http.MethodGet, "/" + id, "", false, http.StatusUnauthorized},
+	} {
+		target := tc.path
+		if tc.token != "" && !tc.header {
+			target += "?token=" + tc.token
+		}
+		req := httptest.NewRequest(tc.method, target, nil)
+		if tc.header {
+			req.Header.Set(TokenHeader, tc.token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Fatalf("%s %s with %q: status %d, want %d", tc.method, tc.path, tc.token, rec.Code, tc.want)
+		}
+	}
+
+	// A WebSocket writer upgrades with a GET but needs the writer token on
+	// the WebSocket route, and nowhere else.
+	ws := reg.WebSocketTokenAuth(func(r *http.Request) string { return strings.TrimPrefix(r.URL.Path, "/") }, denied)(ok)
+	for _, tc := range []struct {
+		handler http.Handler
+		token   string
+		want    int
+	}{
+		{ws, writer, http.StatusOK},
+		{ws, reader, http.StatusForbidden},
+		{h, writer, http.StatusForbidden},
+		{h, reader, http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/"+id+"?role=writer", nil)
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set(TokenHeader, tc.token)
+		rec := httptest.NewRecorder()
+		tc.handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Fatalf("websocket writer with %q: status %d, want %d", tc.token, rec.Code, tc.want)
+		}
+	}
+}
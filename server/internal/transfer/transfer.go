@@ -1,14 +1,18 @@
 package transfer
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/gaspardpetit/nfrx/server/internal/metrics"
 )
 
 const DefaultTTL = 60 * time.Second
@@ -18,6 +22,7 @@ var (
 	ErrExpired   = errors.New("transfer expired")
 	ErrRoleTaken = errors.New("transfer role already attached")
 	ErrClosed    = errors.New("transfer closed")
+	ErrCanceled  = errors.New("transfer canceled")
 )
 
 type Role int
@@ -31,41 +36,100 @@ type Registry struct {
 	mu       sync.Mutex
 	channels map[string]*channel
 	ttl      time.Duration
+	spoolDir string
+	maxBytes int64
+	tokenKey []byte
+	tokenTTL time.Duration
+	origins  []string
 }
 
 type channel struct {
 	id        string
+	createdAt time.Time
 	expiresAt time.Time
 	reader    *endpoint
 	writer    *endpoint
 	ready     chan struct{}
 	done      chan struct{}
+	copied    chan struct{}
 	timer     *time.Timer
 	mu        sync.Mutex
 	closed    bool
+	piping    bool
 	err       error
+	// opts holds what the creator declared about the body.
+	opts Options
+	// buf is set on buffered channels, which spool the body instead of
+	// piping it.
+	buf *spool
+	// received counts the bytes taken from the writer so far.
+	received atomic.Int64
 }
 
 type endpoint struct {
-	w http.ResponseWriter
+	w io.Writer
 	r *http.Request
+	// body is what a writer sends; nil means the request body.
+	body io.Reader
+	// abort interrupts the endpoint when the channel closes mid-copy; nil
+	// means setting a deadline on its http.ResponseWriter.
+	abort func()
+	// digest and size describe the body once it has been copied in full.
+	digest string
+	size   int64
+}
+
+func (ep *endpoint) source() io.Reader {
+	if ep.body != nil {
+		return ep.body
+	}
+	return ep.r.Body
+}
+
+// interrupt stops a copy blocked on ep; read selects the direction to break
+// on an HTTP endpoint.
+func (ep *endpoint) interrupt(read bool) {
+	if ep.abort != nil {
+		ep.abort()
+		return
+	}
+	rw, ok := ep.w.(http.ResponseWriter)
+	if !ok {
+		return
+	}
+	rc := http.NewResponseController(rw)
+	if read {
+		_ = rc.SetReadDeadline(time.Now())
+	} else {
+		_ = rc.SetWriteDeadline(time.Now())
+	}
 }
 
 func NewRegistry(ttl time.Duration) *Registry {
 	if ttl <= 0 {
 		ttl = DefaultTTL
 	}
-	return &Registry{channels: make(map[string]*channel), ttl: ttl}
+	return &Registry{channels: make(map[string]*channel), ttl: ttl, tokenKey: newTokenKey(), tokenTTL: DefaultTokenTTL}
 }
 
+// Create opens a streaming channel that pipes one writer into one reader.
 func (r *Registry) Create() (string, time.Time) {
+	return r.create(Options{}, nil)
+}
+
+func (r *Registry) create(opts Options, buf *spool) (string, time.Time) {
 	id := uuid.NewString()
-	expires := time.Now().Add(r.ttl)
+	now := time.Now()
+	expires := now.Add(r.ttl)
 	ch := &channel{
 		id:        id,
+		createdAt: now,
 		expiresAt: expires,
 		ready:     make(chan struct{}),
 		done:      make(chan struct{}),
+		copied:    make(chan struct{}),
+		opts:      opts,
+		buf:       buf,
 	}
 	ch.timer = time.AfterFunc(r.ttl, func() {
 		r.expire(id)
@@ -73,6 +137,7 @@ func (r *Registry) Create() (string, time.Time) {
 	r.mu.Lock()
 	r.channels[id] = ch
 	r.mu.Unlock()
+	metrics.TransferOpened(opts.Mode())
 	return id, expires
 }
 
@@ -88,6 +153,12 @@ func (r *Registry) expire(id string) {
 	ch.close(ErrExpired)
 }
 
+func (r *Registry) lookup(id string) *channel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.channels[id]
+}
+
 func (r *Registry) Attach(id string, role Role, ep *endpoint) (*channel, error) {
 	r.mu.Lock()
 	ch := r.channels[id]
@@ -153,6 +224,22 @@ func (ch *channel) close(err error) {
 	ch.err = err
 	ch.mu.Unlock()
 	close(ch.done)
+	metrics.TransferClosed(ch.opts.Mode(), outcome(err), time.Since(ch.createdAt))
+	if ch.buf != nil {
+		ch.buf.remove()
+	}
+}
+
+// wait blocks until the channel is closed and any copy into the reader has
+// stopped, so the reader's writer is no longer in use.
+func (ch *channel) wait() {
+	<-ch.done
+	ch.mu.Lock()
+	piping := ch.piping
+	ch.mu.Unlock()
+	if piping {
+		<-ch.copied
+	}
 }
 
 func (ch *channel) error() error {
@@ -161,23 +248,71 @@ func (ch *channel) error() error {
 	return ch.err
 }
 
-func (r *Registry) HandleCreate(w http.ResponseWriter, _ *http.Request) {
-	id, expires := r.Create()
+// HandleCreate opens a channel. The optional body selects the mode:
+// {"mode":"buffered","readers":2}.
+func (r *Registry) HandleCreate(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		Mode            string `json:"mode"`
+		Readers         int    `json:"readers"`
+		Size            int64  `json:"size"`
+		ContentType     string `json:"content_type"`
+		ContentEncoding string `json:"content_encoding"`
+		SHA256          string `json:"sha256"`
+		MaxBytes        int64  `json:"max_bytes"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_request"})
+		return
+	}
+	opts, err := OptionsFor(body.Mode, body.Readers)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_mode"})
+		return
+	}
+	opts.Size, opts.ContentType, opts.SHA256, opts.MaxBytes = body.Size, body.ContentType, body.SHA256, body.MaxBytes
+	opts.ContentEncoding = body.ContentEncoding
+	id, expires, err := r.CreateWith(opts)
+	if errors.Is(err, ErrInvalidOptions) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_transfer_options"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": "transfer_spool_unavailable"})
+		return
+	}
+	readerToken, tokenExpires := r.Token(id, Reader)
+	writerToken, _ := r.Token(id, Writer)
 	resp := map[string]any{
-		"channel_id": id,
-		"expires_at": expires.UTC().Format(time.RFC3339),
+		"channel_id":       id,
+		"expires_at":       expires.UTC().Format(time.RFC3339),
+		"mode":             opts.Mode(),
+		"reader_token":     readerToken,
+		"writer_token":     writerToken,
+		"token_expires_at": tokenExpires.UTC().Format(time.RFC3339),
 	}
 	writeJSON(w, http.StatusOK, resp)
 }
 
 func (r *Registry) HandleReader(w http.ResponseWriter, req *http.Request, id string) {
+	if ch := r.lookup(id); ch != nil && ch.buf != nil {
+		r.readBuffered(w, req, ch)
+		return
+	}
+	// The writer streams into w as soon as both sides are attached, so the
+	// headers must be in place before attaching.
+	if ch := r.lookup(id); ch != nil {
+		w.Header().Set("Content-Type", ch.opts.contentType())
+		if enc := ch.opts.contentEncoding(nil); enc != "" {
+			w.Header().Set("Content-Encoding", enc)
+		}
+	}
+	declareTrailers(w)
 	ep := &endpoint{w: w, r: req}
 	ch, err := r.Attach(id, Reader, ep)
 	if err != nil {
 		writeError(w, err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/octet-stream")
 
 	ctx := req.Context()
 	select {
@@ -187,52 +322,163 @@ func (r *Registry) HandleReader(w http.ResponseWriter, req *http.Request, id str
 		return
 	case <-ctx.Done():
 		r.Close(id, ctx.Err())
+		ch.wait()
 		return
 	}
-	<-ch.done
+	ch.wait()
+	if ch.error() != nil {
+		// Part of the body may already have been sent; break the response
+		// so the reader cannot mistake it for a complete one.
+		panic(http.ErrAbortHandler)
+	}
 }
 
 func (r *Registry) HandleWriter(w http.ResponseWriter, req *http.Request, id string) {
-	ep := &endpoint{w: w, r: req}
-	ch, err := r.Attach(id, Writer, ep)
-	if err != nil {
+	if ch := r.lookup(id); ch != nil && ch.buf != nil {
+		r.writeBuffered(w, req, ch)
+		return
+	}
+	if ch := r.lookup(id); ch != nil {
+		if err := ch.opts.checkWriter(req, req.ContentLength); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	if err := r.write(req.Context(), id, &endpoint{w: w, r: req}); err != nil {
 		writeError(w, err)
 		return
 	}
-	ctx := req.Context()
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+// write attaches ep as the writer of channel id and pipes its body to the
+// reader. A nil error means the reader received the whole body.
+func (r *Registry) write(ctx context.Context, id string, ep *endpoint) error {
+	ch, err := r.Attach(id, Writer, ep)
+	if err != nil {
+		return err
+	}
 	select {
 	case <-ch.ready:
 	case <-ch.done:
-		writeError(w, ch.error())
-		return
+		if err := ch.error(); err != nil {
+			return err
+		}
+		return ErrClosed
 	case <-ctx.Done():
 		r.Close(id, ctx.Err())
-		return
+		return ctx.Err()
 	}
 	copyErr := pipe(ch)
 	r.Close(id, copyErr)
 	if copyErr != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "transfer_failed"})
-		return
+		if err := ch.error(); errors.Is(err, ErrCanceled) || isIntegrityError(err) {
+			return err
+		}
+		return copyErr
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+	return nil
+}
+
+// Receive attaches dst as the reader of channel id and blocks until the writer
+// has finished streaming into it, the channel expires or ctx is done. If dst
+// implements io.Closer it is closed once the body has been copied, before the
+// writer is answered, so a failed Close fails the transfer. On a buffered
+// channel it reads the spooled body like any other reader.
+func (r *Registry) Receive(ctx context.Context, id string, dst io.Writer) error {
+	if ch := r.lookup(id); ch != nil && ch.buf != nil {
+		return r.receiveBuffered(ctx, ch, dst)
+	}
+	ch, err := r.Attach(id, Reader, &endpoint{w: dst})
+	if err != nil {
+		return err
+	}
+	select {
+	case <-ch.ready:
+	case <-ch.done:
+		return ch.error()
+	case <-ctx.Done():
+		r.Close(id, ctx.Err())
+		ch.wait()
+		return ctx.Err()
+	}
+	ch.wait()
+	return ch.error()
 }
 
+// Send attaches src as the writer of streaming channel id and blocks until
+// its body has been piped to the reader, the channel expires or ctx is done.
+// If src implements io.Closer, closing the channel mid-copy closes it to
+// interrupt the copy. Buffered channels take uploads over HTTP only.
+func (r *Registry) Send(ctx context.Context, id string, src io.Reader) error {
+	if ch := r.lookup(id); ch != nil && ch.buf != nil {
+		return ErrInvalidMode
+	}
+	ep := &endpoint{body: src}
+	if c, ok := src.(io.Closer); ok {
+		ep.abort = func() { _ = c.Close() }
+	}
+	return r.write(ctx, id, ep)
+}
+
+// pipe copies the writer's body to the reader. Closing the channel while the
+// copy runs, for instance when its job is canceled, interrupts both sides.
 func pipe(ch *channel) error {
 	ch.mu.Lock()
 	reader := ch.reader
 	writer := ch.writer
-	ch.mu.Unlock()
-	if reader == nil || writer == nil {
+	if ch.closed || reader == nil || writer == nil {
+		ch.mu.Unlock()
 		return ErrClosed
 	}
+	ch.piping = true
+	ch.mu.Unlock()
+	defer close(ch.copied)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ch.done:
+			select {
+			case <-stop:
+				// The copy finished before the channel was closed.
+				return
+			default:
+			}
+			writer.interrupt(true)
+			reader.interrupt(false)
+		case <-stop:
+		}
+	}()
+	if rw, ok := reader.w.(http.ResponseWriter); ok && writer.r != nil {
+		// Nothing has been written to the reader yet, so the writer's
+		// encoding can still be passed on.
+		if enc := ch.opts.contentEncoding(writer.r); enc != "" {
+			rw.Header().Set("Content-Encoding", enc)
+		}
+	}
 	flusher, _ := reader.w.(http.Flusher)
 	dst := io.Writer(reader.w)
 	if flusher != nil {
 		dst = &flushWriter{w: reader.w, f: flusher}
 	}
 	buf := make([]byte, 32*1024)
-	_, err := io.CopyBuffer(dst, writer.r.Body, buf)
+	v := newVerifier(ch.opts.limit())
+	src := io.TeeReader(writer.source(), &meter{w: v, ch: ch, in: true})
+	_, err := io.CopyBuffer(&meter{w: dst, ch: ch}, src, buf)
+	if err == nil {
+		err = v.check(ch.opts)
+	}
+	if c, ok := reader.w.(io.Closer); ok && err == nil {
+		err = c.Close()
+	}
+	if err == nil {
+		reader.digest, reader.size = v.digest(), v.n
+		if rw, ok := reader.w.(http.ResponseWriter); ok {
+			setTrailers(rw, reader.digest, reader.size)
+		}
+	}
 	return err
 }
 
@@ -250,22 +496,38 @@ func (fw *flushWriter) Write(p []byte) (int, error) {
 }
 
 func writeError(w http.ResponseWriter, err error) {
-	if err == nil {
-		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_transfer"})
-		return
-	}
+	status, code := errorStatus(err)
+	writeJSON(w, status, map[string]any{"error": code})
+}
+
+// errorStatus maps a channel error to the HTTP status and error code reported
+// to the endpoint.
+func errorStatus(err error) (int, string) {
 	switch {
+	case err == nil:
+		return http.StatusBadRequest, "invalid_transfer"
 	case errors.Is(err, ErrNotFound):
-		writeJSON(w, http.StatusNotFound, map[string]any{"error": "transfer_not_found"})
+		return http.StatusNotFound, "transfer_not_found"
 	case errors.Is(err, ErrExpired):
-		writeJSON(w, http.StatusGone, map[string]any{"error": "transfer_expired"})
+		return http.StatusGone, "transfer_expired"
 	case errors.Is(err, ErrRoleTaken):
-		writeJSON(w, http.StatusConflict, map[string]any{"error": "transfer_in_use"})
+		return http.StatusConflict, "transfer_in_use"
 	case errors.Is(err, ErrClosed):
-		writeJSON(w, http.StatusGone, map[string]any{"error": "transfer_closed"})
-	default:
-		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "transfer_failed"})
+		return http.StatusGone, "transfer_closed"
+	case errors.Is(err, ErrCanceled):
+		return http.StatusGone, "transfer_canceled"
+	case errors.Is(err, ErrTooLarge):
+		return http.StatusRequestEntityTooLarge, "transfer_too_large"
+	case errors.Is(err, ErrSizeMismatch):
+		return http.StatusUnprocessableEntity, "size_mismatch"
+	case errors.Is(err, ErrChecksumMismatch):
+		return http.StatusUnprocessableEntity, "checksum_mismatch"
+	case errors.Is(err, ErrContentType):
+		return http.StatusUnsupportedMediaType, "content_type_mismatch"
+	case errors.Is(err, ErrContentEncoding):
+		return http.StatusUnsupportedMediaType, "content_encoding_mismatch"
 	}
+	return http.StatusBadGateway, "transfer_failed"
 }
 
 func writeJSON(w http.ResponseWriter, status int, payload any) {
@@ -0,0 +1,185 @@
+package transfer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/coder/websocket"
+)
+
+// SetOriginPatterns sets the browser origins allowed to open WebSocket
+// attachments from another host, in the form accepted by
+// websocket.AcceptOptions.OriginPatterns.
+func (r *Registry) SetOriginPatterns(patterns []string) {
+	r.mu.Lock()
+	r.origins = append([]string(nil), patterns...)
+	r.mu.Unlock()
+}
+
+// HandleWebSocket attaches a WebSocket to streaming channel id, as its reader
+// or writer depending on the "role" query parameter, so that browsers can
+// stream a body without fetch upload streams. A WebSocket endpoint pairs with
+// an HTTP one like any other.
+//
+// A writer sends the body as binary messages followed by the text message
+// {"type":"eof"}; a reader receives it as binary messages. Both sides then get
+// a final text message, {"status":"ok",...} or {"error":code}, before the
+// server closes the connection: with a normal closure on success, or with
+// 4000 plus the HTTP status the same error would carry, the error code as
+// reason.
+func (r *Registry) HandleWebSocket(w http.ResponseWriter, req *http.Request, id string) {
+	var role Role
+	switch req.URL.Query().Get("role") {
+	case "reader":
+		role = Reader
+	case "writer":
+		role = Writer
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_role"})
+		return
+	}
+	ch := r.lookup(id)
+	if ch == nil {
+		writeError(w, ErrNotFound)
+		return
+	}
+	if ch.buf != nil {
+		// Buffered channels take resumable fetch uploads instead.
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "websocket_unsupported"})
+		return
+	}
+	if role == Writer {
+		if err := ch.opts.checkWriter(req, -1); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	r.mu.Lock()
+	origins := r.origins
+	r.mu.Unlock()
+	c, err := websocket.Accept(w, req, &websocket.AcceptOptions{OriginPatterns: origins})
+	if err != nil {
+		// Accept has already answered the request.
+		return
+	}
+	c.SetReadLimit(-1)
+	ctx := req.Context()
+	ep := &endpoint{r: req, abort: func() { _ = c.CloseNow() }}
+	if role == Writer {
+		ep.body = &wsBody{ctx: ctx, c: c}
+		finishWebSocket(ctx, c, r.write(ctx, id, ep), nil)
+		return
+	}
+	// Nothing is expected from a reader, but its messages must be read for
+	// the connection to notice it closing.
+	ctx = c.CloseRead(ctx)
+	ep.w = &wsWriter{ctx: ctx, c: c}
+	err = r.read(ctx, id, ep)
+	finishWebSocket(ctx, c, err, map[string]any{"status": "ok", "sha256": ep.digest, "size": ep.size})
+}
+
+// read attaches ep as the reader of channel id and waits for the writer to
+// pipe its body into it.
+func (r *Registry) read(ctx context.Context, id string, ep *endpoint) error {
+	ch, err := r.Attach(id, Reader, ep)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-ch.ready:
+	case <-ch.done:
+		if err := ch.error(); err != nil {
+			return err
+		}
+		return ErrClosed
+	case <-ctx.Done():
+		r.Close(id, ctx.Err())
+		ch.wait()
+		return ctx.Err()
+	}
+	ch.wait()
+	return ch.error()
+}
+
+// finishWebSocket sends the final message and closes c. ok is sent on success,
+// {"status":"ok"} when nil.
+func finishWebSocket(ctx context.Context, c *websocket.Conn, err error, ok map[string]any) {
+	if err != nil {
+		status, code := errorStatus(err)
+		if msg, jerr := json.Marshal(map[string]any{"error": code}); jerr == nil {
+			_ = c.Write(ctx, websocket.MessageText, msg)
+		}
+		_ = c.Close(websocket.StatusCode(4000+status), code)
+		return
+	}
+	if ok == nil {
+		ok = map[string]any{"status": "ok"}
+	}
+	if msg, jerr := json.Marshal(ok); jerr == nil {
+		_ = c.Write(ctx, websocket.MessageText, msg)
+	}
+	_ = c.Close(websocket.StatusNormalClosure, "")
+}
+
+var errWebSocketMessage = errors.New("unexpected websocket message")
+
+// wsBody reads the binary messages of a WebSocket writer as one body, up to
+// its eof message.
+type wsBody struct {
+	ctx context.Context
+	c   *websocket.Conn
+	cur io.Reader
+	eof bool
+}
+
+func (b *wsBody) Read(p []byte) (int, error) {
+	for {
+		if b.eof {
+			return 0, io.EOF
+		}
+		if b.cur != nil {
+			n, err := b.cur.Read(p)
+			if errors.Is(err, io.EOF) {
+				b.cur = nil
+				err = nil
+			}
+			if n > 0 || err != nil {
+				return n, err
+			}
+			continue
+		}
+		typ, r, err := b.c.Reader(b.ctx)
+		if err != nil {
+			return 0, err
+		}
+		if typ == websocket.MessageBinary {
+			b.cur = r
+			continue
+		}
+		var msg struct {
+			Type string `json:"type"`
+		}
+		if err := json.NewDecoder(r).Decode(&msg); err != nil || msg.Type != "eof" {
+			return 0, errWebSocketMessage
+		}
+		b.eof = true
+	}
+}
+
+// wsWriter sends each write to a WebSocket reader as a binary message. A
+// write returns once the message is on the wire, so a slow reader holds the
+// writer back.
+type wsWriter struct {
+	ctx context.Context
+	c   *websocket.Conn
+}
+
+func (w *wsWriter) Write(p []byte) (int, error) {
+	if err := w.c.Write(w.ctx, websocket.MessageBinary, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
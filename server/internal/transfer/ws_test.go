@@ -0,0 +1,151 @@
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func dialWebSocket(t *testing.T, url, role string) *websocket.Conn {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(url, "http")+"/ws?role="+role, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", role, err)
+	}
+	t.Cleanup(func() { _ = c.CloseNow() })
+	c.SetReadLimit(-1)
+	return c
+}
+
+func TestWebSocketWriterToHTTPReader(t *testing.T) {
+	reg, srv := newTestServer(t)
+	id, _ := reg.Create()
+	url := srv.URL + "/" + id
+
+	got := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			got <- err.Error()
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		var b strings.Builder
+		buf := make([]byte, 1024)
+		for {
+			n, err := resp.Body.Read(buf)
+			b.Write(buf[:n])
+			if err != nil {
+				break
+			}
+		}
+		got <- b.String() + "|" + resp.Trailer.Get(TrailerSHA256)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := dialWebSocket(t, url, "writer")
+	for _, part := range []string{"hello ", "web", "socket"} {
+		if err := c.Write(ctx, websocket.MessageBinary, []byte(part)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := c.Write(ctx, websocket.MessageText, []byte(`{"type":"eof"}`)); err != nil {
+		t.Fatalf("eof: %v", err)
+	}
+	typ, msg, err := c.Read(ctx)
+	if err != nil || typ != websocket.MessageText || !strings.Contains(string(msg), `"status":"ok"`) {
+		t.Fatalf("final message: %v %s", err, msg)
+	}
+	if _, _, err := c.Read(ctx); websocket.CloseStatus(err) != websocket.StatusNormalClosure {
+		t.Fatalf("close: %v", err)
+	}
+	sum := sha256.Sum256([]byte("hello websocket"))
+	if want := "hello websocket|" + hex.EncodeToString(sum[:]); <-got != want {
+		t.Fatalf("reader did not get %q", want)
+	}
+}
+
+func TestHTTPWriterToWebSocketReader(t *testing.T) {
+	reg, srv := newTestServer(t)
+	id, _ := reg.Create()
+	url := srv.URL + "/" + id
+	body := strings.Repeat("0123456789", 10000)
+
+	c := dialWebSocket(t, url, "reader")
+	done := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(url, "application/octet-stream", strings.NewReader(body))
+		if err != nil {
+			done <- 0
+			return
+		}
+		_ = resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var b strings.Builder
+	var final struct {
+		Status string `json:"status"`
+		SHA256 string `json:"sha256"`
+		Size   int64  `json:"size"`
+	}
+	for {
+		typ, msg, err := c.Read(ctx)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if typ == websocket.MessageBinary {
+			b.Write(msg)
+			continue
+		}
+		if err := json.Unmarshal(msg, &final); err != nil {
+			t.Fatalf("final message %s: %v", msg, err)
+		}
+		break
+	}
+	if b.String() != body {
+		t.Fatalf("reader got %d bytes", b.Len())
+	}
+	sum := sha256.Sum256([]byte(body))
+	if final.Status != "ok" || final.SHA256 != hex.EncodeToString(sum[:]) || final.Size != int64(len(body)) {
+		t.Fatalf("final message %+v", final)
+	}
+	if status := <-done; status != http.StatusOK {
+		t.Fatalf("writer status %d", status)
+	}
+}
+
+func TestWebSocketErrorsCloseWithStatus(t *testing.T) {
+	reg, srv := newTestServer(t)
+	id, _ := reg.Create()
+	url := srv.URL + "/" + id
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_ = dialWebSocket(t, url, "reader")
+	c := dialWebSocket(t, url, "reader")
+	_, msg, _ := c.Read(ctx)
+	if !strings.Contains(string(msg), "transfer_in_use") {
+		t.Fatalf("second reader: %s", msg)
+	}
+	if _, _, err := c.Read(ctx); websocket.CloseStatus(err) != 4000+http.StatusConflict {
+		t.Fatalf("close: %v", err)
+	}
+
+	resp, _ := do(t, http.MethodGet, url+"/ws?role=owner", "", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid role: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,217 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	llm "github.com/gaspardpetit/nfrx/modules/llm/ext"
+	"github.com/gaspardpetit/nfrx/sdk/api/spi"
+	wp "github.com/gaspardpetit/nfrx/sdk/base/agent/workerproxy"
+	"github.com/gaspardpetit/nfrx/server/internal/adapters"
+	"github.com/gaspardpetit/nfrx/server/internal/config"
+	"github.com/gaspardpetit/nfrx/server/internal/plugin"
+	"github.com/gaspardpetit/nfrx/server/internal/server"
+	"github.com/gaspardpetit/nfrx/server/internal/serverstate"
+)
+
+func TestE2EBatchRunsThroughWorkersAndTracksJob(t *testing.T) {
+	cfg := config.ServerConfig{ClientKey: "secret", RequestTimeout: 5 * time.Second}
+	srvOpts := spi.Options{RequestTimeout: cfg.RequestTimeout, ClientKey: cfg.ClientKey}
+	llmPlugin := llm.New(adapters.ServerState{}, "test", "", "", srvOpts, nil)
+	srv := httptest.NewServer(server.New(cfg, serverstate.NewRegistry(), []plugin.Plugin{llmPlugin}))
+	defer srv.Close()
+
+	ollama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"models":[{"name":"llama3"}]}`))
+		case "/v1/chat/completions":
+			var body struct {
+				Stream   bool `json:"stream"`
+				Messages []struct {
+					Content string `json:"content"`
+				} `json:"messages"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body.Stream || len(body.Messages) == 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "cmpl", "echo": body.Messages[0].Content})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ollama.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wsURL := strings.Replace(srv.URL, "http", "ws", 1) + "/api/llm/connect"
+	go func() {
+		probe := func(pctx context.Context) (wp.ProbeResult, error) {
+			return wp.ProbeResult{Ready: true, Models: []string{"llama3"}, MaxConcurrency: 2}, nil
+		}
+		_ = wp.Run(ctx, wp.Config{ServerURL: wsURL, ClientKey: "secret", BaseURL: ollama.URL + "/v1", ProbeFunc: probe, ProbeInterval: 50 * time.Millisecond, ClientID: "w1", ClientName: "w1", MaxConcurrency: 2})
+	}()
+
+	// wait for worker registration; lines for models no worker serves fail
+	// right away
+	for i := 0; i < 20; i++ {
+		if resp, err := http.Get(srv.URL + "/api/llm/v1/models"); err == nil {
+			var v struct {
+				Data []struct {
+					ID string `json:"id"`
+				} `json:"data"`
+			}
+			if json.NewDecoder(resp.Body).Decode(&v) == nil {
+				_ = resp.Body.Close()
+				if len(v.Data) > 0 {
+					break
+				}
+			} else {
+				_ = resp.Body.Close()
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	var input bytes.Buffer
+	for _, id := range []string{"a", "b", "c"} {
+		input.WriteString(`{"custom_id":"` + id + `","method":"POST","url":"/v1/chat/completions","body":{"model":"llama3","stream":true,"messages":[{"role":"user","content":"` + id + `"}]}}` + "\n")
+	}
+	// No worker serves this model; the line fails instead of waiting for one.
+	input.WriteString(`{"custom_id":"typo","method":"POST","url":"/v1/chat/completions","body":{"model":"llama-3","messages":[{"role":"user","content":"typo"}]}}` + "\n")
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	_ = mw.WriteField("purpose", "batch")
+	fw, _ := mw.CreateFormFile("file", "input.jsonl")
+	_, _ = fw.Write(input.Bytes())
+	_ = mw.Close()
+	resp, err := http.Post(srv.URL+"/api/llm/v1/files", mw.FormDataContentType(), &form)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	var file struct {
+		ID    string `json:"id"`
+		Bytes int    `json:"bytes"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&file)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || file.ID == "" || file.Bytes != input.Len() {
+		t.Fatalf("upload status %d, file %+v", resp.StatusCode, file)
+	}
+
+	type batchView struct {
+		ID            string `json:"id"`
+		Status        string `json:"status"`
+		OutputFileID  string `json:"output_file_id"`
+		ErrorFileID   string `json:"error_file_id"`
+		JobID         string `json:"job_id"`
+		RequestCounts struct {
+			Total     int `json:"total"`
+			Completed int `json:"completed"`
+			Failed    int `json:"failed"`
+		} `json:"request_counts"`
+	}
+	resp, err = http.Post(srv.URL+"/api/llm/v1/batches", "application/json", strings.NewReader(`{"input_file_id":"`+file.ID+`","endpoint":"/v1/chat/completions","completion_window":"24h"}`))
+	if err != nil {
+		t.Fatalf("create batch: %v", err)
+	}
+	var b batchView
+	_ = json.NewDecoder(resp.Body).Decode(&b)
+	_ = resp.Body.Close()
+	if b.Status != "in_progress" || b.RequestCounts.Total != 4 || b.JobID == "" {
+		t.Fatalf("unexpected batch %+v", b)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for b.Status != "completed" {
+		if time.Now().After(deadline) {
+			t.Fatalf("batch did not complete: %+v", b)
+		}
+		time.Sleep(50 * time.Millisecond)
+		resp, err := http.Get(srv.URL + "/api/llm/v1/batches/" + b.ID)
+		if err != nil {
+			t.Fatalf("get batch: %v", err)
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&b)
+		_ = resp.Body.Close()
+	}
+	if b.RequestCounts.Completed != 3 || b.RequestCounts.Failed != 1 || b.OutputFileID == "" || b.ErrorFileID == "" {
+		t.Fatalf("unexpected completed batch %+v", b)
+	}
+
+	resp, err = http.Get(srv.URL + "/api/llm/v1/files/" + b.ErrorFileID + "/content")
+	if err != nil {
+		t.Fatalf("download errors: %v", err)
+	}
+	errLines, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	var failed struct {
+		CustomID string `json:"custom_id"`
+		Error    struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(errLines), &failed); err != nil || failed.CustomID != "typo" || failed.Error.Code != "model_not_found" {
+		t.Fatalf("unexpected error file %s", errLines)
+	}
+
+	resp, err = http.Get(srv.URL + "/api/llm/v1/files/" + b.OutputFileID + "/content")
+	if err != nil {
+		t.Fatalf("download output: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var got []string
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		var line struct {
+			CustomID string `json:"custom_id"`
+			Response struct {
+				StatusCode int `json:"status_code"`
+				Body       struct {
+					Echo string `json:"echo"`
+				} `json:"body"`
+			} `json:"response"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("decode output line %q: %v", sc.Text(), err)
+		}
+		if line.Response.StatusCode != http.StatusOK || line.Response.Body.Echo != line.CustomID {
+			t.Fatalf("unexpected output line %s", sc.Text())
+		}
+		got = append(got, line.CustomID)
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != "a,b,c" {
+		t.Fatalf("output covers %v", got)
+	}
+
+	resp, err = http.Get(srv.URL + "/api/jobs/" + b.JobID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	var job struct {
+		Type     string         `json:"type"`
+		Status   string         `json:"status"`
+		Progress map[string]any `json:"progress"`
+	}
+	_ = json.Unmarshal(body, &job)
+	if job.Type != "llm.batch" || job.Status != "completed" || job.Progress["completed"] != float64(3) {
+		t.Fatalf("unexpected batch job %s", body)
+	}
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaspardpetit/nfrx/server/internal/config"
+	"github.com/gaspardpetit/nfrx/server/internal/server"
+	"github.com/gaspardpetit/nfrx/server/internal/serverstate"
+)
+
+func TestE2ETransferTokensReplaceAPIKeyForOneChannel(t *testing.T) {
+	cfg := config.ServerConfig{APIKey: "secret", RequestTimeout: 5 * time.Second, TransferSpoolDir: t.TempDir(), TransferTokenTTL: time.Minute}
+	srv := httptest.NewServer(server.New(cfg, serverstate.NewRegistry(), nil))
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/transfer", strings.NewReader(`{"mode":"buffered"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	var created struct {
+		ChannelID   string `json:"channel_id"`
+		ReaderToken string `json:"reader_token"`
+		WriterToken string `json:"writer_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	_ = resp.Body.Close()
+	url := srv.URL + "/api/transfer/" + created.ChannelID
+
+	send := func(method, target, token, body string) (int, string) {
+		req, _ := http.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("X-Transfer-Token", token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, target, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	if code, _ := send(http.MethodPost, url, "", "hello"); code != http.StatusUnauthorized {
+		t.Fatalf("upload without credentials: %d", code)
+	}
+	if code, _ := send(http.MethodPost, url, created.ReaderToken, "hello"); code != http.StatusForbidden {
+		t.Fatalf("upload with reader token: %d", code)
+	}
+	if code, body := send(http.MethodPost, url, created.WriterToken, "hello"); code != http.StatusOK {
+		t.Fatalf("upload with writer token: %d %s", code, body)
+	}
+	if code, body := send(http.MethodGet, url+"?token="+created.ReaderToken, "", ""); code != http.StatusOK || body != "hello" {
+		t.Fatalf("download with reader token: %d %q", code, body)
+	}
+}